@@ -1,18 +1,26 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"collaborative-editor/internal/auth"
 	"collaborative-editor/internal/db"
+	"collaborative-editor/internal/events"
 	"collaborative-editor/internal/handlers"
+	"collaborative-editor/internal/mail"
 	"collaborative-editor/internal/middleware"
 	"collaborative-editor/internal/repository"
 	"collaborative-editor/internal/routes"
+	"collaborative-editor/internal/search"
 	"collaborative-editor/internal/services"
+	"collaborative-editor/internal/storage"
 	"collaborative-editor/internal/websocket"
+	"collaborative-editor/pkg/document"
 
 	"github.com/joho/godotenv"
 )
@@ -39,28 +47,136 @@ func main() {
 	textRepo := repository.NewCouchbaseTextRepository()
 	docRepo := repository.NewCouchbaseDocumentRepository()
 	blacklistRepo := repository.NewCouchbaseTokenBlacklistRepository()
+	oneTimeTokenRepo := repository.NewCouchbaseOneTimeTokenRepository()
+	apiTokenRepo := repository.NewCouchbaseAPITokenRepository()
+	exportJobRepo := repository.NewCouchbaseExportJobRepository()
+	workspaceRepo := repository.NewCouchbaseWorkspaceRepository()
+	folderRepo := repository.NewCouchbaseFolderRepository()
+	attachmentRepo := repository.NewCouchbaseAttachmentRepository()
+	webhookRepo := repository.NewCouchbaseWebhookRepository()
+
+	// Initialize mailer (file-based outbox unless MAILER=smtp)
+	mailer, err := mail.NewMailerFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	// Initialize attachment storage (local directory unless BLOB_STORE=s3)
+	blobStore, err := storage.NewBlobStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize blob store: %v", err)
+	}
 
 	// Initialize service layer
-	userService := services.NewUserService(userRepo, blacklistRepo)
+	userService := services.NewUserService(userRepo, blacklistRepo, oneTimeTokenRepo, mailer)
 	textService := services.NewTextService(textRepo)
 	docService := services.NewDocumentService(docRepo, userRepo)
+	docService.SetVerificationPolicy(services.LoadVerificationPolicy())
+	docService.SetWorkspaceRepository(workspaceRepo)
+	docService.SetFolderRepository(folderRepo)
+
+	// Document content is validated against the editor schema on every write; DOCUMENT_MAX_CONTENT_KB caps its size
+	contentLimits := document.DefaultLimits
+	contentLimits.MaxContentBytes = getEnvInt("DOCUMENT_MAX_CONTENT_KB", contentLimits.MaxContentBytes>>10) << 10
+	docService.SetContentLimits(contentLimits)
+
+	// Attachments are capped per file and per document; purging a document removes its attachments
+	attachmentService := services.NewAttachmentService(attachmentRepo, docRepo, blobStore, docService)
+	attachmentService.SetSizeLimits(
+		int64(getEnvInt("ATTACHMENT_MAX_SIZE_MB", 10))<<20,
+		int64(getEnvInt("ATTACHMENT_MAX_DOCUMENT_MB", 100))<<20,
+	)
+	docService.SetAttachmentCleaner(attachmentService)
+
+	// Document changes are published on the event bus; the webhook dispatcher delivers them to subscribed URLs
+	eventBus := events.NewBus()
+	docService.SetEventBus(eventBus)
+	webhookService := services.NewWebhookService(webhookRepo, docRepo, docService)
+	webhookService.SetAllowPrivateNetworks(os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS") == "true")
+	eventBus.Subscribe(webhookService.HandleEvent)
+	go webhookService.RunDispatcher()
+
+	// Deleted documents stay in the trash for TRASH_RETENTION_DAYS before the sweeper purges them
+	docService.SetTrashRetention(time.Duration(getEnvInt("TRASH_RETENTION_DAYS", 30)) * 24 * time.Hour)
+	go docService.RunTrashSweeper()
+
+	// Full-text search index, kept up to date by the document service and rebuilt on startup
+	searchIndex := search.NewMemoryIndex()
+	docService.SetSearchIndex(searchIndex)
+	searchService := services.NewSearchService(searchIndex, docService)
+	go func() {
+		count, err := searchService.RebuildIndex(context.Background())
+		if err != nil {
+			log.Printf("Failed to build search index: %v", err)
+			return
+		}
+		log.Printf("Search index built (%d documents)", count)
+	}()
+
+	folderService := services.NewFolderService(folderRepo, docRepo, userRepo, docService)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, userRepo, docRepo)
+	workspaceService.SetFolderRepository(folderRepo)
+	workspaceService.SetDocumentService(docService)
+	workspaceService.SetEventBus(eventBus)
+	apiTokenService := services.NewAPITokenService(apiTokenRepo, userRepo)
+	exportService := services.NewExportService(exportJobRepo, userRepo, docRepo, textRepo, apiTokenRepo)
+
+	// Account deletion releases the user's documents and folders, leaves their workspaces and removes their access tokens
+	userService.SetDocumentService(docService)
+	userService.SetFolderService(folderService)
+	userService.SetWorkspaceService(workspaceService)
+	userService.SetAPITokenRepository(apiTokenRepo)
+	userService.SetEventBus(eventBus)
+
+	// Brute-force protection for login; counters live in Couchbase so all replicas share them
+	var loginAttemptStore repository.LoginAttemptStore = repository.NewCouchbaseLoginAttemptStore()
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		loginAttemptStore = repository.NewMemoryLoginAttemptStore()
+	}
+	userService.SetLoginGuard(services.NewLoginGuard(loginAttemptStore, repository.NewCouchbaseLoginAuditRepository()))
 
 	// Set blacklist repository in middleware for token validation
 	middleware.SetBlacklistRepository(blacklistRepo)
 
+	// Accept personal access tokens alongside JWTs
+	middleware.SetAPITokenAuthenticator(apiTokenService)
+
+	// Only trust X-Forwarded-For when running behind a reverse proxy
+	middleware.SetTrustProxyHeaders(os.Getenv("TRUST_PROXY_HEADERS") == "true")
+	middleware.SetTrustedProxyHops(getEnvInt("TRUSTED_PROXY_HOPS", 1))
+
 	// Initialize WebSocket hub and start it
 	hub := websocket.NewHub()
 	go hub.Run()
 	log.Println("WebSocket hub started")
 
+	// Live sessions follow changes made through the API: updates are broadcast to the document's room,
+	// and deleted documents, removed collaborators and deleted accounts are disconnected
+	eventBus.Subscribe(hub.HandleEvent)
+
 	// Initialize handlers
 	userHandler := handlers.NewUserHandler(userService)
+	tokenHandler := handlers.NewAPITokenHandler(apiTokenService)
+	exportHandler := handlers.NewExportHandler(exportService)
 	textHandler := handlers.NewTextHandler(textService)
 	docHandler := handlers.NewDocumentHandler(docService)
+	folderHandler := handlers.NewFolderHandler(folderService)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
 	wsHandler := handlers.NewWebSocketHandler(hub, docService, userRepo)
+	wsHandler.SetConnectionLimiter(middleware.NewConnectionLimiter(getEnvInt("WS_MAX_CONNECTIONS_PER_USER", 10)))
+
+	// Initialize OpenID Connect single sign-on (optional)
+	var oidcHandler *handlers.OIDCHandler
+	if oidcConfig := auth.LoadOIDCConfig(); oidcConfig != nil {
+		oidcHandler = handlers.NewOIDCHandler(auth.NewOIDCProvider(oidcConfig), userService)
+		log.Printf("OIDC single sign-on enabled (issuer: %s)", oidcConfig.IssuerURL)
+	}
 
 	// Setup routes
-	routes.SetupRoutes(userHandler, textHandler, docHandler, wsHandler)
+	routes.SetupRoutes(userHandler, oidcHandler, tokenHandler, exportHandler, textHandler, docHandler, folderHandler, workspaceHandler, searchHandler, attachmentHandler, webhookHandler, wsHandler)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -72,3 +188,12 @@ func main() {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
+
+// getEnvInt reads an integer environment variable, falling back to a default
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
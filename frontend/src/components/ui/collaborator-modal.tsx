@@ -1,4 +1,4 @@
-import { useState } from 'react';
+import { useEffect, useState } from 'react';
 import { Button } from '@/components/ui/button';
 import {
     Dialog,
@@ -9,8 +9,9 @@ import {
     DialogTitle,
 } from '@/components/ui/dialog';
 import { Input } from '@/components/ui/input';
-import { useMutation, useQueryClient } from '@tanstack/react-query';
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
 import { addCollaborator } from '@/services/documents';
+import { searchUsers } from '@/services/users';
 import { Loader2 } from 'lucide-react';
 
 interface CollaboratorModalProps {
@@ -22,8 +23,23 @@ interface CollaboratorModalProps {
 export function CollaboratorModal({ open, onOpenChange, documentId }: CollaboratorModalProps) {
     const [email, setEmail] = useState('');
     const [error, setError] = useState('');
+    const [debouncedQuery, setDebouncedQuery] = useState('');
+    const [showSuggestions, setShowSuggestions] = useState(false);
     const queryClient = useQueryClient();
 
+    // Debounce the search so we don't query on every keystroke
+    useEffect(() => {
+        const timer = setTimeout(() => setDebouncedQuery(email.trim()), 250);
+        return () => clearTimeout(timer);
+    }, [email]);
+
+    const { data: suggestions = [] } = useQuery({
+        queryKey: ['userSearch', debouncedQuery],
+        queryFn: () => searchUsers(debouncedQuery),
+        enabled: open && debouncedQuery.length >= 2,
+        staleTime: 30_000,
+    });
+
     const mutation = useMutation({
         mutationFn: (email: string) => addCollaborator(documentId, email),
         onSuccess: () => {
@@ -51,17 +67,45 @@ export function CollaboratorModal({ open, onOpenChange, documentId }: Collaborat
                 <DialogHeader>
                     <DialogTitle>Add Collaborator</DialogTitle>
                     <DialogDescription className="text-zinc-400">
-                        Enter the email address of the user you want to share this document with.
+                        Search for the user you want to share this document with, or enter their email address.
                     </DialogDescription>
                 </DialogHeader>
                 <form onSubmit={handleSubmit} className="space-y-4">
                     <div className="space-y-2">
-                        <Input
-                            placeholder="user@example.com"
-                            value={email}
-                            onChange={(e) => setEmail(e.target.value)}
-                            className="bg-zinc-800 border-zinc-700 text-white placeholder:text-zinc-500"
-                        />
+                        <div className="relative">
+                            <Input
+                                placeholder="Username or user@example.com"
+                                value={email}
+                                onChange={(e) => {
+                                    setEmail(e.target.value);
+                                    setShowSuggestions(true);
+                                }}
+                                onBlur={() => setShowSuggestions(false)}
+                                autoComplete="off"
+                                className="bg-zinc-800 border-zinc-700 text-white placeholder:text-zinc-500"
+                            />
+                            {showSuggestions && suggestions.length > 0 && (
+                                <ul className="absolute z-10 mt-1 w-full rounded-md border border-zinc-700 bg-zinc-800 py-1 shadow-lg">
+                                    {suggestions.map((user) => (
+                                        <li key={user.id}>
+                                            <button
+                                                type="button"
+                                                // Prevent the input blur from hiding the list before the click lands
+                                                onMouseDown={(e) => e.preventDefault()}
+                                                onClick={() => {
+                                                    setEmail(user.email);
+                                                    setShowSuggestions(false);
+                                                }}
+                                                className="flex w-full flex-col items-start px-3 py-2 text-left hover:bg-zinc-700"
+                                            >
+                                                <span className="text-sm text-white">{user.display_name || user.username}</span>
+                                                <span className="text-xs text-zinc-400">{user.email}</span>
+                                            </button>
+                                        </li>
+                                    ))}
+                                </ul>
+                            )}
+                        </div>
                         {error && <p className="text-red-400 text-sm">{error}</p>}
                     </div>
                     <DialogFooter>
@@ -9,13 +9,15 @@ interface Collaborator {
 }
 
 interface WebSocketMessage {
-  type: 'JOIN' | 'LEAVE' | 'PRESENCE';
+  type: 'JOIN' | 'LEAVE' | 'PRESENCE' | 'DOCUMENT_CLOSED' | 'DOCUMENT_UPDATED';
   document_id: string;
   user: {
     user_id: string;
     username: string;
     email: string;
   };
+  reason?: string; // Set on DOCUMENT_CLOSED, e.g. 'deleted' or 'access_revoked'
+  title?: string; // Set on DOCUMENT_UPDATED
   timestamp: string;
 }
 
@@ -28,6 +30,7 @@ export function useDocumentWebSocket({ documentId, enabled }: UseDocumentWebSock
   const { user } = useAuth();
   const [collaborators, setCollaborators] = useState<Collaborator[]>([]);
   const [isConnected, setIsConnected] = useState(false);
+  const [closedReason, setClosedReason] = useState<string | null>(null);
   const wsRef = useRef<WebSocket | null>(null);
   const reconnectTimeoutRef = useRef<NodeJS.Timeout | null>(null);
   const reconnectAttemptsRef = useRef(0);
@@ -118,6 +121,10 @@ export function useDocumentWebSocket({ documentId, enabled }: UseDocumentWebSock
             setCollaborators((prev) =>
               prev.filter((c) => c.userId !== message.user.user_id)
             );
+          } else if (message.type === 'DOCUMENT_CLOSED') {
+            // The server disconnects us next; don't try to reconnect
+            reconnectAttemptsRef.current = 999;
+            setClosedReason(message.reason || 'closed');
           }
         } catch (error) {
           console.error('Failed to parse WebSocket message:', error);
@@ -168,5 +175,5 @@ export function useDocumentWebSocket({ documentId, enabled }: UseDocumentWebSock
     }));
   }, [collaborators, user]);
 
-  return { collaborators: allCollaborators, isConnected };
+  return { collaborators: allCollaborators, isConnected, closedReason };
 }
@@ -238,7 +238,7 @@ const Dashboard = () => {
                     <DialogHeader>
                         <DialogTitle>Delete Confirmation</DialogTitle>
                         <DialogDescription className="text-zinc-400">
-                            Are you sure you want to delete <span className="font-bold text-white">{documentToDelete?.title}</span>? This action cannot be undone.
+                            Are you sure you want to delete <span className="font-bold text-white">{documentToDelete?.title}</span>? It will be moved to the trash, where you can restore it for 30 days.
                         </DialogDescription>
                     </DialogHeader>
                     <DialogFooter>
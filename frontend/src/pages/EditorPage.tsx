@@ -30,11 +30,22 @@ const EditorPage = () => {
     });
 
     // WebSocket for real-time collaboration (presence)
-    const { collaborators, isConnected } = useDocumentWebSocket({
+    const { collaborators, isConnected, closedReason } = useDocumentWebSocket({
         documentId: id!,
         enabled: !!id && !!document,
     });
 
+    // Leave the editor when someone deletes the document or revokes our access
+    useEffect(() => {
+        if (closedReason === 'deleted') {
+            alert('This document was moved to the trash.');
+            navigate('/dashboard');
+        } else if (closedReason === 'access_revoked') {
+            alert('You no longer have access to this document.');
+            navigate('/dashboard');
+        }
+    }, [closedReason, navigate]);
+
     // Parse JWT token once to get user info
     const [tokenUser, setTokenUser] = useState<{ userID: string; username: string; email: string } | null>(null);
 
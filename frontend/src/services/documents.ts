@@ -6,13 +6,46 @@ export interface Document {
   content: string;
   owner_id: string;
   collaborator_ids: string[];
+  tags: string[];
+  is_template: boolean;
   created_at: string;
   updated_at: string;
 }
 
+export interface DocumentSummary {
+  id: string;
+  title: string;
+  owner_id: string;
+  workspace_id?: string;
+  folder_id?: string;
+  tags?: string[];
+  is_template?: boolean;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface DocumentPage {
+  documents: DocumentSummary[];
+  total: number;
+  next_cursor?: string;
+}
+
+export interface ListDocumentsParams {
+  workspace_id?: string;
+  ownership?: 'owned' | 'shared';
+  title?: string;
+  tag?: string;
+  updated_since?: string;
+  sort?: 'updated_at' | 'created_at' | 'title';
+  order?: 'asc' | 'desc';
+  cursor?: string;
+  limit?: number;
+}
+
 export interface CreateDocumentRequest {
   title: string;
   content: string;
+  template_id?: string; // Title may be empty to use the template's
 }
 
 export interface AddCollaboratorRequest {
@@ -24,11 +57,23 @@ export const createDocument = async (data: CreateDocumentRequest) => {
   return response.data;
 };
 
-export const getDocuments = async () => {
-  const response = await api.get<Document[]>('/documents');
+export const listDocuments = async (params: ListDocumentsParams = {}) => {
+  const response = await api.get<DocumentPage>('/documents', { params });
   return response.data;
 };
 
+// getDocuments returns all documents, most recently edited first, following the page cursors
+export const getDocuments = async () => {
+  const documents: DocumentSummary[] = [];
+  let cursor: string | undefined;
+  do {
+    const page = await listDocuments({ limit: 100, cursor });
+    documents.push(...page.documents);
+    cursor = page.next_cursor;
+  } while (cursor);
+  return documents;
+};
+
 export const getDocument = async (id: string) => {
   const response = await api.get<Document>(`/documents/${id}`);
   return response.data;
@@ -47,3 +92,104 @@ export const addCollaborator = async (id: string, email: string) => {
   const response = await api.post<Document>(`/documents/${id}/collaborators`, { email });
   return response.data;
 };
+
+export interface TagCount {
+  tag: string;
+  count: number;
+}
+
+export const setTags = async (id: string, tags: string[]) => {
+  const response = await api.put<Document>(`/documents/${id}/tags`, { tags });
+  return response.data;
+};
+
+export const getTags = async () => {
+  const response = await api.get<TagCount[]>('/tags');
+  return response.data;
+};
+
+export const renameTag = async (from: string, to: string) => {
+  const response = await api.post<{ tag: string; documents: number }>('/tags/rename', { from, to });
+  return response.data;
+};
+
+export const mergeTags = async (from: string[], to: string) => {
+  const response = await api.post<{ tag: string; documents: number }>('/tags/merge', { from, to });
+  return response.data;
+};
+
+export const getFavorites = async () => {
+  const response = await api.get<DocumentSummary[]>('/documents/favorites');
+  return response.data;
+};
+
+export const addFavorite = async (id: string) => {
+  await api.post(`/documents/${id}/favorite`);
+};
+
+export const removeFavorite = async (id: string) => {
+  await api.delete(`/documents/${id}/favorite`);
+};
+
+export const reorderFavorites = async (documentIds: string[]) => {
+  const response = await api.put<DocumentSummary[]>('/documents/favorites', { document_ids: documentIds });
+  return response.data;
+};
+
+export interface TrashedDocument extends DocumentSummary {
+  deleted_at: string;
+  deleted_by: string;
+  purge_at: string;
+}
+
+export const getTrash = async (workspaceId?: string) => {
+  const response = await api.get<TrashedDocument[]>('/trash', { params: { workspace_id: workspaceId } });
+  return response.data;
+};
+
+export const restoreDocument = async (id: string) => {
+  const response = await api.post<Document>(`/documents/${id}/restore`);
+  return response.data;
+};
+
+export const purgeDocument = async (id: string) => {
+  await api.delete(`/trash/${id}`);
+};
+
+export const duplicateDocument = async (id: string, title?: string) => {
+  const response = await api.post<Document>(`/documents/${id}/duplicate`, { title });
+  return response.data;
+};
+
+export const setTemplate = async (id: string, isTemplate: boolean) => {
+  const response = await api.put<Document>(`/documents/${id}/template`, { is_template: isTemplate });
+  return response.data;
+};
+
+export const getTemplates = async () => {
+  const response = await api.get<DocumentSummary[]>('/templates');
+  return response.data;
+};
+
+export interface ImportDocumentOptions {
+  title?: string;
+  workspace_id?: string;
+  folder_id?: string;
+}
+
+export type ExportFormat = 'md' | 'html' | 'txt' | 'pdf' | 'docx';
+
+export const exportDocument = async (id: string, format: ExportFormat = 'md') => {
+  const response = await api.get<Blob>(`/documents/${id}/export`, { params: { format }, responseType: 'blob' });
+  return response.data;
+};
+
+export const importDocument = async (file: File, options: ImportDocumentOptions = {}) => {
+  const form = new FormData();
+  form.append('file', file);
+  Object.entries(options).forEach(([key, value]) => {
+    if (value) form.append(key, value);
+  });
+  const response = await api.post<Document>('/documents/import', form);
+  return response.data;
+};
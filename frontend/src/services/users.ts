@@ -0,0 +1,16 @@
+import api from '@/lib/api';
+
+export interface UserSearchResult {
+  id: string;
+  username: string;
+  email: string;
+  display_name?: string;
+  avatar_url?: string;
+}
+
+export const searchUsers = async (query: string, limit = 8) => {
+  const response = await api.get<UserSearchResult[]>('/users/search', {
+    params: { q: query, limit },
+  });
+  return response.data;
+};
@@ -13,6 +13,15 @@ var (
 	jwtSecret []byte
 )
 
+// TokenTTL is the lifetime of access tokens issued by GenerateToken
+const TokenTTL = 24 * time.Hour
+
+func init() {
+	// Token times keep microseconds, so a token issued right after its user's sessions were revoked
+	// is not mistaken for one issued before the revocation in the same second
+	jwt.TimePrecision = time.Microsecond
+}
+
 // Claims represents JWT claims
 type Claims struct {
 	UserID   string `json:"user_id"`
@@ -36,7 +45,7 @@ func InitJWT() error {
 // GenerateToken generates a JWT token for a user
 func GenerateToken(userID, username, email string) (string, error) {
 	// Token expires in 24 hours
-	expirationTime := time.Now().Add(24 * time.Hour)
+	expirationTime := time.Now().Add(TokenTTL)
 
 	claims := &Claims{
 		UserID:   userID,
@@ -84,6 +93,12 @@ func ValidateToken(tokenString string, blacklistChecker ...TokenBlacklistChecker
 		return nil, errors.New("invalid token")
 	}
 
+	// Purpose tokens (see GeneratePurposeToken) carry an audience and must never
+	// be accepted as access tokens
+	if len(claims.Audience) > 0 {
+		return nil, errors.New("token is not an access token")
+	}
+
 	// Check blacklist if checker is provided
 	if len(blacklistChecker) > 0 && blacklistChecker[0] != nil {
 		isBlacklisted, err := blacklistChecker[0](tokenString)
@@ -98,6 +113,58 @@ func ValidateToken(tokenString string, blacklistChecker ...TokenBlacklistChecker
 	return claims, nil
 }
 
+// PurposeClaims represents claims of a short-lived token minted for a single purpose
+// (e.g. carrying OIDC login state). The purpose is stored as the token audience.
+type PurposeClaims struct {
+	Data map[string]string `json:"data,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GeneratePurposeToken generates a signed token that is only valid for the given purpose
+func GeneratePurposeToken(purpose, subject string, data map[string]string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &PurposeClaims{
+		Data: data,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "collaborative-editor",
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{purpose},
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(jwtSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// ValidatePurposeToken validates a token generated by GeneratePurposeToken for the given purpose
+func ValidatePurposeToken(tokenString, purpose string) (*PurposeClaims, error) {
+	claims := &PurposeClaims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return jwtSecret, nil
+	}, jwt.WithAudience(purpose))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
 // GetJWTSecret returns the JWT secret (for testing purposes)
 func GetJWTSecret() []byte {
 	return jwtSecret
@@ -88,6 +88,60 @@ func Connect() error {
 		return fmt.Errorf("failed to setup documents scope and collection: %w", err)
 	}
 
+	// Ensure auth scope and one-time tokens collection exist
+	if err := ensureScopeAndCollection("auth", "one_time_tokens"); err != nil {
+		return fmt.Errorf("failed to setup auth scope and collection: %w", err)
+	}
+
+	// Ensure login attempt counters and audit collections exist
+	if err := ensureScopeAndCollection("auth", "login_attempts"); err != nil {
+		return fmt.Errorf("failed to setup login attempts collection: %w", err)
+	}
+	if err := ensureScopeAndCollection("auth", "login_audit"); err != nil {
+		return fmt.Errorf("failed to setup login audit collection: %w", err)
+	}
+
+	// Ensure personal access tokens collection exists
+	if err := ensureScopeAndCollection("auth", "api_tokens"); err != nil {
+		return fmt.Errorf("failed to setup API tokens collection: %w", err)
+	}
+
+	// Ensure exports scope and jobs collection exist
+	if err := ensureScopeAndCollection("exports", "jobs"); err != nil {
+		return fmt.Errorf("failed to setup exports scope and collection: %w", err)
+	}
+
+	// Ensure workspaces scope and collection exist
+	if err := ensureScopeAndCollection("workspaces", "workspaces"); err != nil {
+		return fmt.Errorf("failed to setup workspaces scope and collection: %w", err)
+	}
+
+	// Ensure folders collection exists
+	if err := ensureScopeAndCollection("documents", "folders"); err != nil {
+		return fmt.Errorf("failed to setup folders collection: %w", err)
+	}
+
+	// Ensure favorites collection exists
+	if err := ensureScopeAndCollection("documents", "favorites"); err != nil {
+		return fmt.Errorf("failed to setup favorites collection: %w", err)
+	}
+
+	// Ensure attachments collection exists
+	if err := ensureScopeAndCollection("documents", "attachments"); err != nil {
+		return fmt.Errorf("failed to setup attachments collection: %w", err)
+	}
+
+	// Ensure webhooks and webhook delivery log collections exist
+	if err := ensureScopeAndCollection("documents", "webhooks"); err != nil {
+		return fmt.Errorf("failed to setup webhooks collection: %w", err)
+	}
+	if err := ensureScopeAndCollection("documents", "webhook_deliveries"); err != nil {
+		return fmt.Errorf("failed to setup webhook deliveries collection: %w", err)
+	}
+
+	// Ensure secondary indexes used by queries exist
+	ensureIndexes()
+
 	log.Printf("Successfully connected to Couchbase bucket: %s", bucketName)
 	return nil
 }
@@ -184,6 +238,41 @@ func ensureScopeAndCollection(scopeNameToCheck, collectionNameToCheck string) er
 	return nil
 }
 
+// ensureIndexes creates the secondary indexes used by queries if they do not exist
+// Failures are logged rather than fatal, since queries still work (more slowly) with a primary index
+func ensureIndexes() {
+	indexes := []struct {
+		scope     string
+		statement string
+	}{
+		{scopeName, "CREATE INDEX IF NOT EXISTS `idx_users_username_lower` ON `%s`.`user`.`users`(LOWER(username))"},
+		{scopeName, "CREATE INDEX IF NOT EXISTS `idx_users_email_lower` ON `%s`.`user`.`users`(LOWER(email))"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_documents_workspace` ON `%s`.`documents`.`documents`(workspace_id) WHERE workspace_id IS VALUED"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_documents_folder` ON `%s`.`documents`.`documents`(folder_id) WHERE folder_id IS VALUED"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_documents_tags` ON `%s`.`documents`.`documents`(DISTINCT ARRAY t FOR t IN tags END) WHERE tags IS VALUED"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_documents_deleted` ON `%s`.`documents`.`documents`(deleted_at) WHERE deleted_at IS VALUED"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_documents_template` ON `%s`.`documents`.`documents`(owner_id, workspace_id) WHERE is_template = true"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_folders_parent` ON `%s`.`documents`.`folders`(parent_id)"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_folders_owner` ON `%s`.`documents`.`folders`(owner_id)"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_attachments_document` ON `%s`.`documents`.`attachments`(document_id, created_at)"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_webhooks_document` ON `%s`.`documents`.`webhooks`(document_id, created_at) WHERE document_id IS VALUED"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_webhooks_workspace` ON `%s`.`documents`.`webhooks`(workspace_id, created_at) WHERE workspace_id IS VALUED"},
+		{"documents", "CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_webhook` ON `%s`.`documents`.`webhook_deliveries`(webhook_id, created_at DESC)"},
+	}
+
+	for _, index := range indexes {
+		statement := fmt.Sprintf(index.statement, bucketName)
+		result, err := bucket.Scope(index.scope).Query(statement, &gocb.QueryOptions{
+			Timeout: 30 * time.Second,
+		})
+		if err != nil {
+			log.Printf("Failed to create index (%s): %v", statement, err)
+			continue
+		}
+		result.Close()
+	}
+}
+
 // isScopeExistsError checks if the error indicates scope already exists
 func isScopeExistsError(err error) bool {
 	if err == nil {
@@ -283,6 +372,87 @@ func GetDocumentsCollection() *gocb.Collection {
 	return scope.Collection("documents")
 }
 
+// GetFoldersCollection returns the folders collection from the documents scope
+func GetFoldersCollection() *gocb.Collection {
+	scope := bucket.Scope("documents")
+	return scope.Collection("folders")
+}
+
+// GetFavoritesCollection returns the favorites collection from the documents scope
+func GetFavoritesCollection() *gocb.Collection {
+	scope := bucket.Scope("documents")
+	return scope.Collection("favorites")
+}
+
+// GetAttachmentsCollection returns the attachments collection from the documents scope
+func GetAttachmentsCollection() *gocb.Collection {
+	scope := bucket.Scope("documents")
+	return scope.Collection("attachments")
+}
+
+// GetWebhooksCollection returns the webhooks collection from the documents scope
+func GetWebhooksCollection() *gocb.Collection {
+	scope := bucket.Scope("documents")
+	return scope.Collection("webhooks")
+}
+
+// GetWebhookDeliveriesCollection returns the webhook delivery log collection from the documents scope
+func GetWebhookDeliveriesCollection() *gocb.Collection {
+	scope := bucket.Scope("documents")
+	return scope.Collection("webhook_deliveries")
+}
+
+// GetAuthScope returns the auth scope
+func GetAuthScope() *gocb.Scope {
+	return bucket.Scope("auth")
+}
+
+// GetOneTimeTokensCollection returns the one-time tokens collection from the auth scope
+func GetOneTimeTokensCollection() *gocb.Collection {
+	scope := bucket.Scope("auth")
+	return scope.Collection("one_time_tokens")
+}
+
+// GetLoginAttemptsCollection returns the login attempts collection from the auth scope
+func GetLoginAttemptsCollection() *gocb.Collection {
+	scope := bucket.Scope("auth")
+	return scope.Collection("login_attempts")
+}
+
+// GetLoginAuditCollection returns the login audit collection from the auth scope
+func GetLoginAuditCollection() *gocb.Collection {
+	scope := bucket.Scope("auth")
+	return scope.Collection("login_audit")
+}
+
+// GetAPITokensCollection returns the personal access tokens collection from the auth scope
+func GetAPITokensCollection() *gocb.Collection {
+	scope := bucket.Scope("auth")
+	return scope.Collection("api_tokens")
+}
+
+// GetExportsScope returns the exports scope
+func GetExportsScope() *gocb.Scope {
+	return bucket.Scope("exports")
+}
+
+// GetExportJobsCollection returns the jobs collection from the exports scope
+func GetExportJobsCollection() *gocb.Collection {
+	scope := bucket.Scope("exports")
+	return scope.Collection("jobs")
+}
+
+// GetWorkspacesScope returns the workspaces scope
+func GetWorkspacesScope() *gocb.Scope {
+	return bucket.Scope("workspaces")
+}
+
+// GetWorkspacesCollection returns the workspaces collection from the workspaces scope
+func GetWorkspacesCollection() *gocb.Collection {
+	scope := bucket.Scope("workspaces")
+	return scope.Collection("workspaces")
+}
+
 // GetBucketName returns the bucket name
 func GetBucketName() string {
 	return bucketName
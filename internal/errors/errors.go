@@ -7,9 +7,10 @@ import (
 
 // AppError represents an application error with HTTP status code
 type AppError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Err     error  `json:"-"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"` // Structured information about the error returned to the client
+	Err     error       `json:"-"`
 }
 
 func (e *AppError) Error() string {
@@ -32,14 +33,22 @@ func NewAppError(code int, message string, err error) *AppError {
 	}
 }
 
+// WithDetails returns a copy of the error carrying details for the client
+func (e *AppError) WithDetails(details interface{}) *AppError {
+	withDetails := *e
+	withDetails.Details = details
+	return &withDetails
+}
+
 // Predefined errors
 var (
-	ErrInvalidInput   = NewAppError(http.StatusBadRequest, "Invalid input", nil)
-	ErrNotFound       = NewAppError(http.StatusNotFound, "Resource not found", nil)
-	ErrConflict       = NewAppError(http.StatusConflict, "Resource already exists", nil)
-	ErrInternalServer = NewAppError(http.StatusInternalServerError, "Internal server error", nil)
-	ErrUnauthorized   = NewAppError(http.StatusUnauthorized, "Unauthorized", nil)
-	ErrForbidden      = NewAppError(http.StatusForbidden, "Forbidden", nil)
+	ErrInvalidInput    = NewAppError(http.StatusBadRequest, "Invalid input", nil)
+	ErrNotFound        = NewAppError(http.StatusNotFound, "Resource not found", nil)
+	ErrConflict        = NewAppError(http.StatusConflict, "Resource already exists", nil)
+	ErrInternalServer  = NewAppError(http.StatusInternalServerError, "Internal server error", nil)
+	ErrUnauthorized    = NewAppError(http.StatusUnauthorized, "Unauthorized", nil)
+	ErrForbidden       = NewAppError(http.StatusForbidden, "Forbidden", nil)
+	ErrTooManyRequests = NewAppError(http.StatusTooManyRequests, "Too many requests", nil)
 )
 
 // WrapError wraps an error with an AppError
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Handler receives the events published on a bus
+// Handlers run on the publisher's goroutine, so anything slow must be handed off to another goroutine
+type Handler func(Event)
+
+// Bus delivers the domain events published by services to every subscriber, in process
+// Publishing never fails: a subscriber that panics is logged and skipped
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewBus creates a new event bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a handler for every event published after it
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish stamps an event with an ID and time if it has none and passes it to the subscribers in order
+func (b *Bus) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		dispatch(handler, event)
+	}
+}
+
+// dispatch calls one handler, recovering from a panic so the other subscribers still run
+func dispatch(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Event handler panicked on %s (%s): %v", event.Type, event.ID, r)
+		}
+	}()
+	handler(event)
+}
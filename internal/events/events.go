@@ -0,0 +1,60 @@
+package events
+
+import "time"
+
+// Types of the events published on the bus
+const (
+	DocumentCreated     = "document.created"
+	DocumentUpdated     = "document.updated"  // Title or content changed
+	DocumentDeleted     = "document.deleted"  // Moved to the trash
+	DocumentRestored    = "document.restored" // Moved back out of the trash
+	DocumentPurged      = "document.purged"   // Permanently deleted
+	CollaboratorAdded   = "collaborator.added"
+	CollaboratorRemoved = "collaborator.removed"
+	UserDeleted         = "user.deleted" // The account was deleted; DocumentID is empty
+	// A member's workspace role changed or they were removed, including by deleting the workspace;
+	// DocumentID is empty
+	WorkspaceMemberChanged = "workspace.member_changed"
+)
+
+// Event is a change published by a service once it has been stored
+type Event struct {
+	ID          string
+	Type        string
+	ActorID     string // The user who made the change; empty for changes made by the server itself
+	DocumentID  string
+	WorkspaceID string      // The document's workspace, if it has one
+	Data        interface{} // DocumentData, CollaboratorData, UserData or WorkspaceMemberData, depending on the type
+	OccurredAt  time.Time
+}
+
+// DocumentData describes the document an event is about, without its content
+type DocumentData struct {
+	Title     string    `json:"title"`
+	OwnerID   string    `json:"owner_id"`
+	FolderID  string    `json:"folder_id,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CollaboratorData describes a collaborator added to or removed from a document
+type CollaboratorData struct {
+	Title          string `json:"title"`
+	CollaboratorID string `json:"collaborator_id"`
+	// AccessRevoked is set on removal when the user cannot reach the document another way,
+	// such as through a shared folder or a workspace
+	AccessRevoked bool `json:"access_revoked,omitempty"`
+}
+
+// UserData describes a user an event is about
+type UserData struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// WorkspaceMemberData describes a change to a workspace member
+type WorkspaceMemberData struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role,omitempty"` // The new role; empty when the user was removed
+	// RevokedDocumentIDs are the workspace's documents the user can no longer read
+	RevokedDocumentIDs []string `json:"revoked_document_ids,omitempty"`
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/middleware"
+	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
+)
+
+// APITokenHandler handles HTTP requests for personal access tokens
+type APITokenHandler struct {
+	tokenService *services.APITokenService
+}
+
+// NewAPITokenHandler creates a new API token handler
+func NewAPITokenHandler(tokenService *services.APITokenService) *APITokenHandler {
+	return &APITokenHandler{
+		tokenService: tokenService,
+	}
+}
+
+// CreateToken handles creating a personal access token
+func (h *APITokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	var req services.CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.tokenService.CreateToken(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, response)
+}
+
+// ListTokens handles listing the personal access tokens of the user
+func (h *APITokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	tokens, err := h.tokenService.ListTokens(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+// RevokeToken handles revoking a personal access token
+func (h *APITokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	tokenID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(r.Context(), userID, tokenID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/middleware"
+	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
+)
+
+// AttachmentHandler handles HTTP requests for document attachments
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(attachmentService *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// UploadAttachment handles uploading an image or file to a document
+// The file is sent as the "file" field of a multipart form
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	// Leave room for the multipart framing around the file
+	maxSize := h.attachmentService.MaxFileSize()
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize+64<<10)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			respondWithError(w, errors.NewAppError(http.StatusRequestEntityTooLarge, fmt.Sprintf("File is larger than %d bytes", maxSize), nil))
+			return
+		}
+		respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "A file is required in the file field", err))
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.UploadAttachment(r.Context(), userID, docID, header.Filename, file, header.Size)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, attachment)
+}
+
+// ListAttachments handles listing the attachments of a document
+func (h *AttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	attachments, err := h.attachmentService.ListAttachments(r.Context(), userID, docID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, attachments)
+}
+
+// DownloadAttachment handles downloading an attachment
+// Images are served inline so the editor can display them; other files are always downloaded
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	docID := r.PathValue("id")
+	attachmentID := r.PathValue("attachmentId")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	attachment, data, err := h.attachmentService.OpenAttachment(r.Context(), userID, docID, attachmentID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+	defer data.Close()
+
+	disposition := "attachment"
+	if attachment.IsImage() {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename*=UTF-8''%s", disposition, url.PathEscape(attachment.FileName)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, data); err != nil {
+		log.Printf("Failed to send attachment %s: %v", attachment.ID, err)
+	}
+}
+
+// DeleteAttachment handles removing an attachment from a document
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+	attachmentID := r.PathValue("attachmentId")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.attachmentService.DeleteAttachment(r.Context(), userID, docID, attachmentID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{
+		"message": "Attachment deleted successfully",
+	})
+}
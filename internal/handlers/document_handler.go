@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"collaborative-editor/internal/errors"
 	"collaborative-editor/internal/middleware"
 	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
 )
 
 // DocumentHandler handles HTTP requests for document operations
@@ -23,6 +29,10 @@ func NewDocumentHandler(docService *services.DocumentService) *DocumentHandler {
 
 // CreateDocument handles creating a new document
 func (h *DocumentHandler) CreateDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
 	var req services.CreateDocumentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
@@ -46,6 +56,10 @@ func (h *DocumentHandler) CreateDocument(w http.ResponseWriter, r *http.Request)
 
 // GetDocument handles retrieving a document
 func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
 	docID := r.PathValue("id")
 
 	userID := middleware.GetUserID(r.Context())
@@ -63,8 +77,35 @@ func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusOK, doc)
 }
 
+// GetDocumentOutline handles retrieving a document's headings and word count
+func (h *DocumentHandler) GetDocumentOutline(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	outline, err := h.docService.GetDocumentOutline(r.Context(), userID, docID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, outline)
+}
+
 // UpdateDocument handles updating a document
 func (h *DocumentHandler) UpdateDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
 	docID := r.PathValue("id")
 
 	var req services.CreateDocumentRequest
@@ -88,8 +129,12 @@ func (h *DocumentHandler) UpdateDocument(w http.ResponseWriter, r *http.Request)
 	respondWithJSON(w, http.StatusOK, doc)
 }
 
-// DeleteDocument handles deleting a document
+// DeleteDocument handles moving a document to the trash
 func (h *DocumentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
 	docID := r.PathValue("id")
 
 	userID := middleware.GetUserID(r.Context())
@@ -108,6 +153,10 @@ func (h *DocumentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request)
 
 // AddCollaborator handles adding a collaborator
 func (h *DocumentHandler) AddCollaborator(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
 	docID := r.PathValue("id")
 
 	var req services.AddCollaboratorRequest
@@ -131,15 +180,131 @@ func (h *DocumentHandler) AddCollaborator(w http.ResponseWriter, r *http.Request
 	respondWithJSON(w, http.StatusOK, doc)
 }
 
+// RemoveCollaborator handles removing a collaborator from a document
+func (h *DocumentHandler) RemoveCollaborator(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+	collaboratorID := r.PathValue("userId")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.docService.RemoveCollaborator(r.Context(), userID, docID, collaboratorID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MoveDocument handles moving a document into or out of a workspace
+func (h *DocumentHandler) MoveDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	var req services.MoveDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	doc, err := h.docService.MoveDocument(r.Context(), userID, docID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, doc)
+}
+
+// MoveDocumentToFolder handles moving a document into a folder
+func (h *DocumentHandler) MoveDocumentToFolder(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	var req services.MoveToFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	doc, err := h.docService.MoveDocumentToFolder(r.Context(), userID, docID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, doc)
+}
+
 // ListDocuments handles listing documents for a user
+// Query parameters: workspace_id, ownership (owned|shared), title, tag, updated_since (RFC 3339),
+// sort (updated_at|created_at|title), order (asc|desc), cursor and limit
 func (h *DocumentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
 	userID := middleware.GetUserID(r.Context())
 	if userID == "" {
 		respondWithError(w, errors.ErrUnauthorized)
 		return
 	}
 
-	docs, err := h.docService.ListDocuments(r.Context(), userID)
+	query := r.URL.Query()
+	req := services.ListDocumentsRequest{
+		WorkspaceID:   query.Get("workspace_id"),
+		Ownership:     query.Get("ownership"),
+		TitleContains: query.Get("title"),
+		Tag:           query.Get("tag"),
+		Sort:          query.Get("sort"),
+		Order:         query.Get("order"),
+		Cursor:        query.Get("cursor"),
+	}
+
+	if since := query.Get("updated_since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "updated_since must be an RFC 3339 time", nil))
+			return
+		}
+		req.UpdatedSince = t
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "limit must be a positive number", nil))
+			return
+		}
+		req.Limit = n
+	}
+
+	docs, err := h.docService.ListDocuments(r.Context(), userID, &req)
 	if err != nil {
 		respondWithError(w, err)
 		return
@@ -147,3 +312,431 @@ func (h *DocumentHandler) ListDocuments(w http.ResponseWriter, r *http.Request)
 
 	respondWithJSON(w, http.StatusOK, docs)
 }
+
+// SetTags handles replacing the tags of a document
+func (h *DocumentHandler) SetTags(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	var req services.SetTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	doc, err := h.docService.SetTags(r.Context(), userID, docID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, doc)
+}
+
+// ListTags handles listing the tags on the user's documents
+func (h *DocumentHandler) ListTags(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	tags, err := h.docService.ListTags(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tags)
+}
+
+// RenameTag handles renaming a tag on all of the user's documents
+func (h *DocumentHandler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	var req services.RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.docService.RenameTag(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// MergeTags handles merging several tags into one on all of the user's documents
+func (h *DocumentHandler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	var req services.MergeTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.docService.MergeTags(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// ListFavorites handles listing the user's favorite documents
+func (h *DocumentHandler) ListFavorites(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	favorites, err := h.docService.ListFavorites(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, favorites)
+}
+
+// ReorderFavorites handles changing the pinned order of the user's favorites
+func (h *DocumentHandler) ReorderFavorites(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	var req services.ReorderFavoritesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	favorites, err := h.docService.ReorderFavorites(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, favorites)
+}
+
+// AddFavorite handles adding a document to the user's favorites
+func (h *DocumentHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.docService.AddFavorite(r.Context(), userID, docID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveFavorite handles removing a document from the user's favorites
+func (h *DocumentHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.docService.RemoveFavorite(r.Context(), userID, docID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListTrash handles listing the documents in the user's trash, or a workspace's with ?workspace_id=
+func (h *DocumentHandler) ListTrash(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	trash, err := h.docService.ListTrash(r.Context(), userID, r.URL.Query().Get("workspace_id"))
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, trash)
+}
+
+// RestoreDocument handles moving a document out of the trash
+func (h *DocumentHandler) RestoreDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	doc, err := h.docService.RestoreDocument(r.Context(), userID, docID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, doc)
+}
+
+// PurgeDocument handles permanently deleting a document from the trash
+func (h *DocumentHandler) PurgeDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.docService.PurgeDocument(r.Context(), userID, docID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DuplicateDocument handles copying a document; the body is optional
+func (h *DocumentHandler) DuplicateDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	var req services.DuplicateDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	doc, err := h.docService.DuplicateDocument(r.Context(), userID, docID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, doc)
+}
+
+// SetTemplate handles marking or unmarking a document as a template
+func (h *DocumentHandler) SetTemplate(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	var req services.SetTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	doc, err := h.docService.SetTemplate(r.Context(), userID, docID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, doc)
+}
+
+// ListTemplates handles listing the templates available to the user
+func (h *DocumentHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	templates, err := h.docService.ListTemplates(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, templates)
+}
+
+// ExportDocument handles downloading a document in another format, chosen by the format query parameter
+func (h *DocumentHandler) ExportDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	docID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = services.ExportFormatMarkdown
+	}
+
+	exported, err := h.docService.ExportDocument(r.Context(), userID, docID, format)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", exported.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exported.FileName))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(exported.Data)
+}
+
+// ImportDocument handles creating a document from a Markdown or DOCX file
+// The file is sent as the "file" field of a multipart form, with optional title, workspace_id and folder_id
+// fields, or as the raw request body with those as query parameters
+func (h *DocumentHandler) ImportDocument(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	// Leave room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxImportSize+64<<10)
+
+	var (
+		fileName string
+		data     []byte
+		err      error
+	)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, formErr := r.FormFile("file")
+		if formErr != nil {
+			respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "A Markdown file is required in the file field", formErr))
+			return
+		}
+		defer file.Close()
+		fileName = header.Filename
+		data, err = io.ReadAll(file)
+	} else {
+		fileName = r.URL.Query().Get("filename")
+		data, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "Failed to read the uploaded file", err))
+		return
+	}
+
+	req := services.ImportDocumentRequest{
+		Title:       r.FormValue("title"),
+		WorkspaceID: r.FormValue("workspace_id"),
+		FolderID:    r.FormValue("folder_id"),
+	}
+
+	doc, err := h.docService.ImportDocument(r.Context(), userID, fileName, data, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, doc)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/middleware"
+	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
+)
+
+// ExportHandler handles HTTP requests for personal data exports
+type ExportHandler struct {
+	exportService *services.ExportService
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exportService *services.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// RequestExport handles starting a personal data export
+func (h *ExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	job, err := h.exportService.RequestExport(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, job)
+}
+
+// GetExport handles retrieving the status of an export
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	jobID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	job, err := h.exportService.GetJob(r.Context(), userID, jobID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, job)
+}
+
+// DownloadExport handles downloading a finished export
+// The signed token in the link authorizes the download, so it works without an Authorization header
+func (h *ExportHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("id")
+
+	path, fileName, err := h.exportService.OpenDownload(r.Context(), jobID, r.URL.Query().Get("token"))
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeFile(w, r, path)
+}
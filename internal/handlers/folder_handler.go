@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/middleware"
+	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
+)
+
+// FolderHandler handles HTTP requests for folder operations
+type FolderHandler struct {
+	folderService *services.FolderService
+}
+
+// NewFolderHandler creates a new folder handler
+func NewFolderHandler(folderService *services.FolderService) *FolderHandler {
+	return &FolderHandler{
+		folderService: folderService,
+	}
+}
+
+// CreateFolder handles creating a new folder
+func (h *FolderHandler) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	var req services.CreateFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	folder, err := h.folderService.CreateFolder(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, folder)
+}
+
+// ListRoot handles listing the top level of the user's folder tree
+// ?workspace_id= lists the top level of a workspace instead
+func (h *FolderHandler) ListRoot(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	contents, err := h.folderService.ListRoot(r.Context(), userID, r.URL.Query().Get("workspace_id"))
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, contents)
+}
+
+// GetFolder handles retrieving a folder with its children and breadcrumbs
+func (h *FolderHandler) GetFolder(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	folderID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	contents, err := h.folderService.GetFolder(r.Context(), userID, folderID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, contents)
+}
+
+// RenameFolder handles renaming a folder
+func (h *FolderHandler) RenameFolder(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	folderID := r.PathValue("id")
+
+	var req services.RenameFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	folder, err := h.folderService.RenameFolder(r.Context(), userID, folderID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, folder)
+}
+
+// MoveFolder handles moving a folder into another folder
+func (h *FolderHandler) MoveFolder(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	folderID := r.PathValue("id")
+
+	var req services.MoveToFolderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	folder, err := h.folderService.MoveFolder(r.Context(), userID, folderID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, folder)
+}
+
+// DeleteFolder handles deleting a folder
+func (h *FolderHandler) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	folderID := r.PathValue("id")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.folderService.DeleteFolder(r.Context(), userID, folderID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddCollaborator handles sharing a folder with a user
+func (h *FolderHandler) AddCollaborator(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	folderID := r.PathValue("id")
+
+	var req services.AddCollaboratorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	folder, err := h.folderService.AddCollaborator(r.Context(), userID, folderID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, folder)
+}
+
+// RemoveCollaborator handles unsharing a folder with a user
+func (h *FolderHandler) RemoveCollaborator(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
+	folderID := r.PathValue("id")
+	collaboratorID := r.PathValue("userId")
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	if err := h.folderService.RemoveCollaborator(r.Context(), userID, folderID, collaboratorID); err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
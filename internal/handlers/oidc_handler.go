@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"collaborative-editor/internal/auth"
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/services"
+)
+
+const (
+	oidcStateCookie  = "oidc_state"
+	oidcStatePurpose = "oidc_state"
+	oidcStateTTL     = 10 * time.Minute
+)
+
+// OIDCHandler handles OpenID Connect single sign-on
+type OIDCHandler struct {
+	provider        *auth.OIDCProvider
+	userService     *services.UserService
+	successRedirect string
+}
+
+// NewOIDCHandler creates a new OIDC handler
+// If OIDC_SUCCESS_REDIRECT_URL is set, the callback redirects there with the app token
+// (or the MFA token when 2FA is enabled) in the URL fragment; otherwise it responds with the login JSON
+func NewOIDCHandler(provider *auth.OIDCProvider, userService *services.UserService) *OIDCHandler {
+	return &OIDCHandler{
+		provider:        provider,
+		userService:     userService,
+		successRedirect: os.Getenv("OIDC_SUCCESS_REDIRECT_URL"),
+	}
+}
+
+// Login starts the authorization code + PKCE flow
+func (h *OIDCHandler) Login(w http.ResponseWriter, r *http.Request) {
+	state, err := auth.RandomToken(16)
+	if err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInternalServer, err))
+		return
+	}
+	nonce, err := auth.RandomToken(16)
+	if err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInternalServer, err))
+		return
+	}
+	verifier, challenge, err := auth.GeneratePKCE()
+	if err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInternalServer, err))
+		return
+	}
+
+	authURL, err := h.provider.AuthCodeURL(r.Context(), state, nonce, challenge)
+	if err != nil {
+		respondWithError(w, errors.NewAppError(http.StatusBadGateway, "Identity provider unavailable", err))
+		return
+	}
+
+	// Login state is kept client-side in a signed, short-lived cookie
+	stateToken, err := auth.GeneratePurposeToken(oidcStatePurpose, "", map[string]string{
+		"state":    state,
+		"nonce":    nonce,
+		"verifier": verifier,
+	}, oidcStateTTL)
+	if err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInternalServer, err))
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Path:     "/auth/oidc",
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback completes the flow and issues the normal app JWT
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		respondWithError(w, errors.NewAppError(
+			http.StatusUnauthorized,
+			"Identity provider returned an error: "+providerErr,
+			nil,
+		))
+		return
+	}
+
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "Missing login state", nil))
+		return
+	}
+
+	// The state cookie is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Path:     "/auth/oidc",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+
+	claims, err := auth.ValidatePurposeToken(cookie.Value, oidcStatePurpose)
+	if err != nil {
+		respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "Invalid or expired login state", err))
+		return
+	}
+
+	if query.Get("state") == "" || query.Get("state") != claims.Data["state"] {
+		respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "Login state mismatch", nil))
+		return
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "Authorization code is required", nil))
+		return
+	}
+
+	identity, err := h.provider.Exchange(r.Context(), code, claims.Data["verifier"], claims.Data["nonce"])
+	if err != nil {
+		respondWithError(w, errors.NewAppError(errors.ErrUnauthorized.Code, "Failed to verify identity", err))
+		return
+	}
+
+	response, err := h.userService.LoginWithOIDC(r.Context(), identity)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	if h.successRedirect != "" {
+		fragment := url.Values{}
+		if response.MFARequired {
+			fragment.Set("mfa_token", response.MFAToken)
+		} else {
+			fragment.Set("token", response.Token)
+		}
+		http.Redirect(w, r, h.successRedirect+"#"+fragment.Encode(), http.StatusFound)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
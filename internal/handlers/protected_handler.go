@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"net/http"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/middleware"
 )
 
 // ProtectedHandler handles protected routes that require authentication
@@ -10,3 +13,19 @@ func ProtectedHandler(w http.ResponseWriter, r *http.Request) {
 		"message": "Welcome to the protected route",
 	})
 }
+
+// requireScope checks that the request's credentials carry a scope
+// Session tokens from /login carry every scope; personal access tokens only their granted scopes
+// Responds with 403 and returns false if the scope is missing
+func requireScope(w http.ResponseWriter, r *http.Request, scope string) bool {
+	if middleware.HasScope(r.Context(), scope) {
+		return true
+	}
+
+	respondWithError(w, errors.NewAppError(
+		errors.ErrForbidden.Code,
+		"Access token is missing the required scope: "+scope,
+		nil,
+	))
+	return false
+}
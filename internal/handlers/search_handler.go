@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/middleware"
+	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
+)
+
+// SearchHandler handles HTTP requests for full-text search
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+// NewSearchHandler creates a new search handler
+func NewSearchHandler(searchService *services.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search handles searching the documents the user can access
+// Query parameters: q (the query) and limit
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "limit must be a positive number", nil))
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.searchService.Search(r.Context(), userID, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, results)
+}
@@ -7,6 +7,7 @@ import (
 	"collaborative-editor/internal/errors"
 	"collaborative-editor/internal/middleware"
 	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
 )
 
 // TextHandler handles HTTP requests for text operations
@@ -23,6 +24,10 @@ func NewTextHandler(textService *services.TextService) *TextHandler {
 
 // SaveText handles requests to save text
 func (h *TextHandler) SaveText(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsWrite) {
+		return
+	}
+
 	if r.Method != http.MethodPost {
 		respondWithError(w, errors.NewAppError(
 			http.StatusMethodNotAllowed,
@@ -57,6 +62,10 @@ func (h *TextHandler) SaveText(w http.ResponseWriter, r *http.Request) {
 
 // GetText handles requests to get text
 func (h *TextHandler) GetText(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		respondWithError(w, errors.NewAppError(
 			http.StatusMethodNotAllowed,
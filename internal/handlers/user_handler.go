@@ -4,11 +4,13 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"collaborative-editor/internal/errors"
 	"collaborative-editor/internal/middleware"
 	"collaborative-editor/internal/services"
+	"collaborative-editor/pkg/apitoken"
 )
 
 // UserHandler handles HTTP requests for user operations
@@ -67,6 +69,7 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
 		return
 	}
+	req.IP = middleware.ClientIP(r)
 
 	// Call service
 	response, err := h.userService.Login(r.Context(), &req)
@@ -96,11 +99,16 @@ func respondWithError(w http.ResponseWriter, err error) {
 
 	log.Printf("Error: %v", err)
 
+	body := map[string]interface{}{
+		"error": appErr.Message,
+	}
+	if appErr.Details != nil {
+		body["details"] = appErr.Details
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(appErr.Code)
-	json.NewEncoder(w).Encode(map[string]string{
-		"error": appErr.Message,
-	})
+	json.NewEncoder(w).Encode(body)
 }
 
 // GetUserHandler handles requests to get user information
@@ -122,16 +130,21 @@ func (h *UserHandler) GetUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Println("User ID: ", user)
 
-	respondWithJSON(w, http.StatusOK, map[string]string{
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
 		"userID":   user.ID,
 		"username": user.Username,
 		"email":    user.Email,
+		"verified": user.Verified,
 	})
 }
 
 // LogoutHandler handles user logout
 // This endpoint requires authentication (protected by AuthMiddleware)
 func (h *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		respondWithError(w, errors.NewAppError(
 			http.StatusMethodNotAllowed,
@@ -185,3 +198,321 @@ func (h *UserHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 
 	respondWithJSON(w, http.StatusOK, response)
 }
+
+// ChangePassword handles changing the password of the logged-in user
+func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	var req services.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.ChangePassword(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// RequestPasswordReset handles requests to email a password reset link
+func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var req services.RequestPasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	response, err := h.userService.RequestPasswordReset(r.Context(), &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// ResetPassword handles setting a new password with a reset token
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req services.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	response, err := h.userService.ResetPassword(r.Context(), &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// VerifyEmail handles email verification links
+func (h *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	response, err := h.userService.VerifyEmail(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// ResendVerificationEmail handles requests to resend the verification email
+func (h *UserHandler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.ResendVerificationEmail(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// LoginMFA handles the second step of a login with two-factor authentication
+func (h *UserHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req services.LoginMFARequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+	req.IP = middleware.ClientIP(r)
+
+	response, err := h.userService.LoginMFA(r.Context(), &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// EnrollTwoFactor handles starting two-factor enrollment
+func (h *UserHandler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.EnrollTwoFactor(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// VerifyTwoFactor handles confirming two-factor enrollment
+func (h *UserHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	var req services.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.VerifyTwoFactor(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// DisableTwoFactor handles disabling two-factor authentication
+func (h *UserHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	var req services.DisableTwoFactorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+	req.IP = middleware.ClientIP(r)
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.DisableTwoFactor(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// RegenerateRecoveryCodes handles replacing two-factor recovery codes
+func (h *UserHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	var req services.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+	req.IP = middleware.ClientIP(r)
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.RegenerateRecoveryCodes(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// GetProfile handles requests to get the profile of the logged-in user
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.GetProfile(r.Context(), userID)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// UpdateProfile handles partial updates of the profile of the logged-in user
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	var req services.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.UpdateProfile(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// DeleteAccount handles deleting the account of the logged-in user
+func (h *UserHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeAdmin) {
+		return
+	}
+
+	var req services.DeleteAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, errors.WrapError(errors.ErrInvalidInput, err))
+		return
+	}
+	req.IP = middleware.ClientIP(r)
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	response, err := h.userService.DeleteAccount(r.Context(), userID, &req)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
+
+// SearchUsers handles searching users by username or email prefix
+func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+	if !requireScope(w, r, apitoken.ScopeDocsRead) {
+		return
+	}
+
+	userID := middleware.GetUserID(r.Context())
+	if userID == "" {
+		respondWithError(w, errors.ErrUnauthorized)
+		return
+	}
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			respondWithError(w, errors.NewAppError(errors.ErrInvalidInput.Code, "limit must be a number", nil))
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.userService.SearchUsers(r.Context(), userID, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		respondWithError(w, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, results)
+}
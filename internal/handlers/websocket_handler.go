@@ -27,9 +27,10 @@ var upgrader = websocket.Upgrader{
 
 // WebSocketHandler handles WebSocket connections
 type WebSocketHandler struct {
-	hub        *ws.Hub
-	docService *services.DocumentService
-	userRepo   repository.UserRepository
+	hub         *ws.Hub
+	docService  *services.DocumentService
+	userRepo    repository.UserRepository
+	connLimiter *middleware.ConnectionLimiter
 }
 
 // NewWebSocketHandler creates a new WebSocket handler
@@ -41,6 +42,11 @@ func NewWebSocketHandler(hub *ws.Hub, docService *services.DocumentService, user
 	}
 }
 
+// SetConnectionLimiter caps the number of concurrent connections per user
+func (h *WebSocketHandler) SetConnectionLimiter(limiter *middleware.ConnectionLimiter) {
+	h.connLimiter = limiter
+}
+
 // HandleWebSocket upgrades HTTP connection to WebSocket
 func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	// Extract document ID from path
@@ -90,9 +96,21 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Enforce the per-user concurrent connection cap
+	release := func() {}
+	if h.connLimiter != nil {
+		if !h.connLimiter.Acquire(user.ID) {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "Too many open connections", http.StatusTooManyRequests)
+			return
+		}
+		release = func() { h.connLimiter.Release(user.ID) }
+	}
+
 	// Upgrade HTTP connection to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
+		release()
 		log.Printf("Failed to upgrade connection: %v", err)
 		return
 	}
@@ -113,8 +131,12 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 	h.hub.Register <- client
 
 	// Start read and write pumps in goroutines
+	// The connection slot is freed once the read pump exits (connection closed)
 	go client.WritePump()
-	go client.ReadPump()
+	go func() {
+		client.ReadPump()
+		release()
+	}()
 
 	log.Printf("WebSocket connection established for user %s (%s) on document %s",
 		user.Username, user.ID, documentID)
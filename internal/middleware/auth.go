@@ -10,8 +10,8 @@ import (
 	"collaborative-editor/internal/auth"
 	"collaborative-editor/internal/errors"
 	"collaborative-editor/internal/repository"
-
-	"github.com/golang-jwt/jwt/v5"
+	"collaborative-editor/pkg/apitoken"
+	"collaborative-editor/pkg/user"
 )
 
 type contextKey string
@@ -20,16 +20,29 @@ const (
 	userIDKey   contextKey = "userID"
 	usernameKey contextKey = "username"
 	emailKey    contextKey = "email"
+	scopesKey   contextKey = "scopes"
 )
 
 var blacklistRepo repository.TokenBlacklistRepository
 
+// APITokenAuthenticator resolves personal access tokens to their record and owner
+type APITokenAuthenticator interface {
+	AuthenticateAPIToken(ctx context.Context, token string) (*apitoken.APIToken, *user.User, error)
+}
+
+var apiTokenAuthenticator APITokenAuthenticator
+
+// SetAPITokenAuthenticator enables personal access tokens in the middleware
+func SetAPITokenAuthenticator(authenticator APITokenAuthenticator) {
+	apiTokenAuthenticator = authenticator
+}
+
 // SetBlacklistRepository sets the token blacklist repository for the middleware
 func SetBlacklistRepository(repo repository.TokenBlacklistRepository) {
 	blacklistRepo = repo
 }
 
-// AuthMiddleware validates JWT tokens for protected routes
+// AuthMiddleware validates JWT tokens (or personal access tokens) for protected routes
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Get token from Authorization header
@@ -56,6 +69,28 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		tokenString := parts[1]
 
+		// Personal access tokens are told apart from JWTs by their prefix
+		if strings.HasPrefix(tokenString, apitoken.Prefix) {
+			t, u, err := authenticateAPIToken(r.Context(), tokenString)
+			if err != nil {
+				respondWithError(w, errors.NewAppError(
+					errors.ErrUnauthorized.Code,
+					"Invalid or expired token",
+					err,
+				))
+				return
+			}
+
+			ctx := r.Context()
+			ctx = withUserID(ctx, u.ID)
+			ctx = withUsername(ctx, u.Username)
+			ctx = withEmail(ctx, u.Email)
+			ctx = withScopes(ctx, t.Scopes)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Create blacklist checker if repository is available
 		var blacklistChecker auth.TokenBlacklistChecker
 		if blacklistRepo != nil {
@@ -75,6 +110,21 @@ func AuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Reject tokens revoked by a password change or reset
+		revoked, err := isRevoked(r.Context(), claims)
+		if err != nil {
+			respondWithError(w, errors.WrapError(errors.ErrInternalServer, err))
+			return
+		}
+		if revoked {
+			respondWithError(w, errors.NewAppError(
+				errors.ErrUnauthorized.Code,
+				"Invalid or expired token",
+				nil,
+			))
+			return
+		}
+
 		// Add user information to request context
 		ctx := r.Context()
 		ctx = withUserID(ctx, claims.UserID)
@@ -99,34 +149,76 @@ func withEmail(ctx context.Context, email string) context.Context {
 	return context.WithValue(ctx, emailKey, email)
 }
 
+func withScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, scopesKey, scopes)
+}
+
+// authenticateAPIToken validates a personal access token
+func authenticateAPIToken(ctx context.Context, tokenString string) (*apitoken.APIToken, *user.User, error) {
+	if apiTokenAuthenticator == nil {
+		return nil, nil, fmt.Errorf("personal access tokens are not enabled")
+	}
+	return apiTokenAuthenticator.AuthenticateAPIToken(ctx, tokenString)
+}
+
 // ValidateToken validates a JWT token and returns the user ID
 // This is a standalone function for use in WebSocket handlers
+// Personal access tokens are accepted if they carry the docs:write scope, since sockets can edit
 func ValidateToken(tokenString string) (string, error) {
-	secret := auth.GetJWTSecret()
-	if secret == nil {
+	if strings.HasPrefix(tokenString, apitoken.Prefix) {
+		t, u, err := authenticateAPIToken(context.Background(), tokenString)
+		if err != nil {
+			return "", err
+		}
+		if !apitoken.HasScope(t.Scopes, apitoken.ScopeDocsWrite) {
+			return "", fmt.Errorf("token is missing the %s scope", apitoken.ScopeDocsWrite)
+		}
+		return u.ID, nil
+	}
+
+	if auth.GetJWTSecret() == nil {
 		return "", fmt.Errorf("JWT secret not set")
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
+	var blacklistChecker auth.TokenBlacklistChecker
+	if blacklistRepo != nil {
+		blacklistChecker = func(token string) (bool, error) {
+			return blacklistRepo.IsTokenBlacklisted(context.Background(), token)
 		}
-		return secret, nil
-	})
+	}
 
+	claims, err := auth.ValidateToken(tokenString, blacklistChecker)
 	if err != nil {
 		return "", err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, ok := claims["user_id"].(string)
-		if !ok {
-			return "", fmt.Errorf("invalid user_id in token")
-		}
-		return userID, nil
+	revoked, err := isRevoked(context.Background(), claims)
+	if err != nil {
+		return "", err
+	}
+	if revoked {
+		return "", fmt.Errorf("token has been revoked")
 	}
 
-	return "", fmt.Errorf("invalid token")
+	if claims.UserID == "" {
+		return "", fmt.Errorf("invalid user_id in token")
+	}
+
+	return claims.UserID, nil
+}
+
+// isRevoked checks whether the user's tokens were revoked at or after the time this one was issued
+func isRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	if blacklistRepo == nil || claims.IssuedAt == nil {
+		return false, nil
+	}
+
+	revokedAt, err := blacklistRepo.GetUserTokensRevokedAt(ctx, claims.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	return !claims.IssuedAt.Time.After(revokedAt), nil
 }
 
 // GetUserID retrieves user ID from context
@@ -153,6 +245,25 @@ func GetEmail(ctx context.Context) string {
 	return ""
 }
 
+// GetScopes retrieves the scopes of the personal access token used for the request
+// Returns nil for session tokens, which are not restricted
+func GetScopes(ctx context.Context) []string {
+	if scopes, ok := ctx.Value(scopesKey).([]string); ok {
+		return scopes
+	}
+	return nil
+}
+
+// HasScope reports whether the request's credentials allow the scope
+// Session tokens from /login allow every scope
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ctx.Value(scopesKey).([]string)
+	if !ok {
+		return true
+	}
+	return apitoken.HasScope(scopes, scope)
+}
+
 // CORSMiddleware allows all origins, methods, and headers
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// trustProxyHeaders controls whether X-Forwarded-For / X-Real-IP are trusted
+var trustProxyHeaders bool
+
+// trustedProxyHops is how many reverse proxies in front of the server append to X-Forwarded-For
+var trustedProxyHops = 1
+
+// SetTrustProxyHeaders enables reading the client IP from proxy headers
+// Only enable this when the server runs behind a reverse proxy that sets them,
+// otherwise clients can spoof their IP
+func SetTrustProxyHeaders(trust bool) {
+	trustProxyHeaders = trust
+}
+
+// SetTrustedProxyHops sets how many trusted proxies append to X-Forwarded-For (default 1)
+func SetTrustedProxyHops(hops int) {
+	if hops < 1 {
+		hops = 1
+	}
+	trustedProxyHops = hops
+}
+
+// ClientIP returns the IP address of the client that made the request
+func ClientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			// Proxies append the address they received the request from, so only the last
+			// trustedProxyHops entries are trustworthy; anything before them is set by the client
+			addrs := strings.Split(forwarded, ",")
+			i := len(addrs) - trustedProxyHops
+			if i < 0 {
+				i = 0
+			}
+			return strings.TrimSpace(addrs[i])
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
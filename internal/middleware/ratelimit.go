@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"collaborative-editor/internal/errors"
+)
+
+// RateLimitPolicy configures a token bucket: clients may send Burst requests at once
+// and then Requests per Period on average
+type RateLimitPolicy struct {
+	Name     string // Identifies the policy; routes sharing a policy share one budget
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// tokenBucket holds the state of one client's bucket
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter is a token-bucket rate limiter keyed by user ID or client IP
+type RateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewRateLimiter creates a rate limiter for a policy
+// Idle buckets are cleaned up periodically in the background
+func NewRateLimiter(policy RateLimitPolicy) *RateLimiter {
+	burst := policy.Burst
+	if burst <= 0 {
+		burst = policy.Requests
+	}
+
+	l := &RateLimiter{
+		rate:    float64(policy.Requests) / policy.Period.Seconds(),
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+
+	go l.cleanupLoop()
+	return l
+}
+
+// Allow takes a token from the key's bucket
+// If none is available, it returns false and how long until the next token
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, lastSeen: now}
+		l.buckets[key] = b
+	}
+
+	// Refill according to the time elapsed since the last request
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.rate)
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// Middleware limits requests per authenticated user, or per client IP for anonymous requests
+// Place it after AuthMiddleware so the user ID is available
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := "ip:" + ClientIP(r)
+		if userID := GetUserID(r.Context()); userID != "" {
+			key = "user:" + userID
+		}
+
+		allowed, wait := l.Allow(key)
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			respondWithError(w, errors.NewAppError(
+				errors.ErrTooManyRequests.Code,
+				"Too many requests, please slow down",
+				nil,
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cleanupLoop removes buckets that have been idle long enough to be full again
+func (l *RateLimiter) cleanupLoop() {
+	idle := time.Duration(l.burst/l.rate*float64(time.Second)) + time.Minute
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-idle)
+
+		l.mu.Lock()
+		for key, b := range l.buckets {
+			if b.lastSeen.Before(cutoff) {
+				delete(l.buckets, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// ConnectionLimiter caps the number of concurrent connections per key (e.g. user ID)
+type ConnectionLimiter struct {
+	max int
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// NewConnectionLimiter creates a connection limiter allowing max connections per key
+func NewConnectionLimiter(max int) *ConnectionLimiter {
+	return &ConnectionLimiter{
+		max:    max,
+		counts: make(map[string]int),
+	}
+}
+
+// Acquire reserves a connection slot for the key, returning false if the cap is reached
+// Every successful Acquire must be followed by a Release
+func (l *ConnectionLimiter) Acquire(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.counts[key] >= l.max {
+		return false
+	}
+	l.counts[key]++
+	return true
+}
+
+// Release frees a connection slot for the key
+func (l *ConnectionLimiter) Release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.counts[key]--
+	if l.counts[key] <= 0 {
+		delete(l.counts, key)
+	}
+}
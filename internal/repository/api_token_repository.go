@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"collaborative-editor/pkg/apitoken"
+)
+
+// APITokenRepository defines the interface for personal access token operations
+type APITokenRepository interface {
+	// Create stores a token record
+	// The repository will hash the token internally for storage
+	Create(ctx context.Context, token string, t *apitoken.APIToken) error
+
+	// GetByToken looks up a token record by the raw token
+	GetByToken(ctx context.Context, token string) (*apitoken.APIToken, error)
+
+	// ListByUserID returns all tokens of a user, newest first
+	ListByUserID(ctx context.Context, userID string) ([]*apitoken.APIToken, error)
+
+	// Delete removes a token of a user by its ID
+	Delete(ctx context.Context, userID, tokenID string) error
+
+	// DeleteByUserID removes all tokens of a user
+	DeleteByUserID(ctx context.Context, userID string) error
+
+	// TouchLastUsed records when a token was last used
+	TouchLastUsed(ctx context.Context, token string, at time.Time) error
+}
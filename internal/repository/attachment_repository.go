@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"collaborative-editor/pkg/attachment"
+)
+
+// AttachmentRepository defines the interface for attachment metadata operations
+// The files themselves are kept in a storage.BlobStore
+type AttachmentRepository interface {
+	Create(ctx context.Context, a *attachment.Attachment) error
+	GetByID(ctx context.Context, id string) (*attachment.Attachment, error)
+	Delete(ctx context.Context, id string) error
+	// ListByDocumentID returns the attachments of a document, oldest first
+	ListByDocumentID(ctx context.Context, documentID string) ([]*attachment.Attachment, error)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"collaborative-editor/internal/db"
+	"collaborative-editor/pkg/apitoken"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// CouchbaseAPITokenRepository implements APITokenRepository using Couchbase
+// Tokens are keyed by their hash so authentication is a single key lookup
+type CouchbaseAPITokenRepository struct{}
+
+// NewCouchbaseAPITokenRepository creates a new Couchbase API token repository
+func NewCouchbaseAPITokenRepository() *CouchbaseAPITokenRepository {
+	return &CouchbaseAPITokenRepository{}
+}
+
+// Create stores a token record under the hash of the token
+// Tokens with an expiration use Couchbase document expiration (TTL) to be deleted automatically
+func (r *CouchbaseAPITokenRepository) Create(ctx context.Context, token string, t *apitoken.APIToken) error {
+	collection := db.GetAPITokensCollection()
+
+	tokenHash := hashToken(token)
+	documentID := fmt.Sprintf("pat:%s", tokenHash)
+
+	opts := &gocb.InsertOptions{
+		Context: ctx,
+	}
+	if t.ExpiresAt != nil {
+		ttlDuration := time.Until(*t.ExpiresAt)
+		if ttlDuration <= 0 {
+			return fmt.Errorf("token expiration must be in the future")
+		}
+		opts.Expiry = ttlDuration
+	}
+
+	if _, err := collection.Insert(documentID, t.ToDocument(tokenHash), opts); err != nil {
+		return fmt.Errorf("failed to store API token: %w", err)
+	}
+
+	return nil
+}
+
+// GetByToken retrieves a token record by the raw token
+func (r *CouchbaseAPITokenRepository) GetByToken(ctx context.Context, token string) (*apitoken.APIToken, error) {
+	collection := db.GetAPITokensCollection()
+
+	documentID := fmt.Sprintf("pat:%s", hashToken(token))
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("API token not found")
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	var doc apitoken.APITokenDocument
+	if err := result.Content(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode API token: %w", err)
+	}
+
+	return apitoken.FromDocument(&doc), nil
+}
+
+// ListByUserID retrieves all tokens of a user
+func (r *CouchbaseAPITokenRepository) ListByUserID(ctx context.Context, userID string) ([]*apitoken.APIToken, error) {
+	query := fmt.Sprintf(
+		"SELECT t.* FROM `%s`.`auth`.`api_tokens` t WHERE t.user_id = $1 ORDER BY t.created_at DESC",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetAuthScope()
+	queryResult, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API tokens: %w", err)
+	}
+	defer queryResult.Close()
+
+	tokens := []*apitoken.APIToken{}
+	for queryResult.Next() {
+		var doc apitoken.APITokenDocument
+		if err := queryResult.Row(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse API token: %w", err)
+		}
+		tokens = append(tokens, apitoken.FromDocument(&doc))
+	}
+
+	if err := queryResult.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// Delete removes a token of a user by its ID
+func (r *CouchbaseAPITokenRepository) Delete(ctx context.Context, userID, tokenID string) error {
+	query := fmt.Sprintf(
+		"DELETE FROM `%s`.`auth`.`api_tokens` t WHERE t.id = $1 AND t.user_id = $2 RETURNING t.id",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetAuthScope()
+	queryResult, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{tokenID, userID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete API token: %w", err)
+	}
+	defer queryResult.Close()
+
+	if !queryResult.Next() {
+		return fmt.Errorf("API token not found")
+	}
+
+	return nil
+}
+
+// DeleteByUserID removes all tokens of a user
+func (r *CouchbaseAPITokenRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := fmt.Sprintf(
+		"DELETE FROM `%s`.`auth`.`api_tokens` t WHERE t.user_id = $1",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetAuthScope()
+	result, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete API tokens: %w", err)
+	}
+	defer result.Close()
+
+	return nil
+}
+
+// TouchLastUsed updates the last used time of a token without rewriting the whole document
+// The document expiry is preserved
+func (r *CouchbaseAPITokenRepository) TouchLastUsed(ctx context.Context, token string, at time.Time) error {
+	collection := db.GetAPITokensCollection()
+
+	documentID := fmt.Sprintf("pat:%s", hashToken(token))
+
+	_, err := collection.MutateIn(documentID, []gocb.MutateInSpec{
+		gocb.UpsertSpec("last_used_at", at, nil),
+	}, &gocb.MutateInOptions{
+		Context:        ctx,
+		PreserveExpiry: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update API token: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"collaborative-editor/internal/db"
+	"collaborative-editor/pkg/attachment"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// CouchbaseAttachmentRepository implements AttachmentRepository using Couchbase
+type CouchbaseAttachmentRepository struct{}
+
+// NewCouchbaseAttachmentRepository creates a new Couchbase attachment repository
+func NewCouchbaseAttachmentRepository() *CouchbaseAttachmentRepository {
+	return &CouchbaseAttachmentRepository{}
+}
+
+// Create stores a new attachment in Couchbase
+func (r *CouchbaseAttachmentRepository) Create(ctx context.Context, a *attachment.Attachment) error {
+	collection := db.GetAttachmentsCollection()
+	documentID := fmt.Sprintf("attachment:%s", a.ID)
+
+	_, err := collection.Insert(documentID, a.ToDocument(), &gocb.InsertOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an attachment by its ID
+func (r *CouchbaseAttachmentRepository) GetByID(ctx context.Context, id string) (*attachment.Attachment, error) {
+	collection := db.GetAttachmentsCollection()
+	documentID := fmt.Sprintf("attachment:%s", id)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("attachment not found")
+		}
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	var doc attachment.AttachmentDocument
+	if err := result.Content(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode attachment: %w", err)
+	}
+
+	return attachment.FromDocument(&doc), nil
+}
+
+// Delete removes an attachment
+func (r *CouchbaseAttachmentRepository) Delete(ctx context.Context, id string) error {
+	collection := db.GetAttachmentsCollection()
+	documentID := fmt.Sprintf("attachment:%s", id)
+
+	_, err := collection.Remove(documentID, &gocb.RemoveOptions{
+		Context: ctx,
+	})
+	if err != nil && !errors.Is(err, gocb.ErrDocumentNotFound) {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	return nil
+}
+
+// ListByDocumentID retrieves the attachments of a document, oldest first
+func (r *CouchbaseAttachmentRepository) ListByDocumentID(ctx context.Context, documentID string) ([]*attachment.Attachment, error) {
+	query := fmt.Sprintf(
+		"SELECT a.* FROM `%s`.`documents`.`attachments` a WHERE a.document_id = $1 ORDER BY a.created_at",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{documentID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []*attachment.Attachment{}
+	for rows.Next() {
+		var doc attachment.AttachmentDocument
+		if err := rows.Row(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse attachment row: %w", err)
+		}
+		attachments = append(attachments, attachment.FromDocument(&doc))
+	}
+
+	return attachments, nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"collaborative-editor/internal/db"
 	"collaborative-editor/pkg/document"
@@ -56,7 +59,40 @@ func (r *CouchbaseDocumentRepository) GetByID(ctx context.Context, id string) (*
 		return nil, fmt.Errorf("failed to decode document: %w", err)
 	}
 
-	return document.FromDocument(&docDoc), nil
+	doc := document.FromDocument(&docDoc)
+	if doc.IsTrashed() {
+		return nil, fmt.Errorf("document not found")
+	}
+
+	return doc, nil
+}
+
+// GetTrashedByID retrieves a document from the trash by its ID
+func (r *CouchbaseDocumentRepository) GetTrashedByID(ctx context.Context, id string) (*document.Document, error) {
+	collection := db.GetDocumentsCollection()
+	documentID := fmt.Sprintf("doc:%s", id)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("document not found")
+		}
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	var docDoc document.DocumentDocument
+	if err := result.Content(&docDoc); err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	doc := document.FromDocument(&docDoc)
+	if !doc.IsTrashed() {
+		return nil, fmt.Errorf("document not found")
+	}
+
+	return doc, nil
 }
 
 // Update updates an existing document
@@ -76,7 +112,7 @@ func (r *CouchbaseDocumentRepository) Update(ctx context.Context, doc *document.
 	return nil
 }
 
-// Delete removes a document
+// Delete permanently removes a document
 func (r *CouchbaseDocumentRepository) Delete(ctx context.Context, id string) error {
 	collection := db.GetDocumentsCollection()
 	documentID := fmt.Sprintf("doc:%s", id)
@@ -91,10 +127,13 @@ func (r *CouchbaseDocumentRepository) Delete(ctx context.Context, id string) err
 	return nil
 }
 
+// trashFilter excludes documents in the trash from queries
+const trashFilter = "d.deleted_at IS NOT VALUED"
+
 // ListByUserID retrieves all documents where the user is an owner or collaborator
 func (r *CouchbaseDocumentRepository) ListByUserID(ctx context.Context, userID string) ([]*document.Document, error) {
 	query := fmt.Sprintf(
-		"SELECT d.* FROM `%s`.`documents`.`documents` d WHERE d.owner_id = $1 OR ARRAY_CONTAINS(d.collaborator_ids, $1)",
+		"SELECT d.* FROM `%s`.`documents`.`documents` d WHERE (d.owner_id = $1 OR ARRAY_CONTAINS(d.collaborator_ids, $1)) AND "+trashFilter,
 		db.GetBucketName(),
 	)
 
@@ -116,6 +155,457 @@ func (r *CouchbaseDocumentRepository) ListByUserID(ctx context.Context, userID s
 		}
 		documents = append(documents, document.FromDocument(&docDoc))
 	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// ListByWorkspaceID retrieves all documents owned by a workspace
+func (r *CouchbaseDocumentRepository) ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*document.Document, error) {
+	query := fmt.Sprintf(
+		"SELECT d.* FROM `%s`.`documents`.`documents` d WHERE d.workspace_id = $1 AND "+trashFilter,
+		db.GetBucketName(),
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{workspaceID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*document.Document
+	for rows.Next() {
+		var docDoc document.DocumentDocument
+		if err := rows.Row(&docDoc); err != nil {
+			return nil, fmt.Errorf("failed to parse document row: %w", err)
+		}
+		documents = append(documents, document.FromDocument(&docDoc))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// ListByFolderID retrieves all documents in a folder
+func (r *CouchbaseDocumentRepository) ListByFolderID(ctx context.Context, folderID string) ([]*document.Document, error) {
+	query := fmt.Sprintf(
+		"SELECT d.* FROM `%s`.`documents`.`documents` d WHERE d.folder_id = $1 AND "+trashFilter+" ORDER BY d.title",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{folderID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*document.Document
+	for rows.Next() {
+		var docDoc document.DocumentDocument
+		if err := rows.Row(&docDoc); err != nil {
+			return nil, fmt.Errorf("failed to parse document row: %w", err)
+		}
+		documents = append(documents, document.FromDocument(&docDoc))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// ListAll retrieves every document
+func (r *CouchbaseDocumentRepository) ListAll(ctx context.Context) ([]*document.Document, error) {
+	query := fmt.Sprintf(
+		"SELECT d.* FROM `%s`.`documents`.`documents` d WHERE "+trashFilter,
+		db.GetBucketName(),
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*document.Document
+	for rows.Next() {
+		var docDoc document.DocumentDocument
+		if err := rows.Row(&docDoc); err != nil {
+			return nil, fmt.Errorf("failed to parse document row: %w", err)
+		}
+		documents = append(documents, document.FromDocument(&docDoc))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+
+	return documents, nil
+}
+
+// ListTrash retrieves the trashed documents a user owns or deleted, or those of a workspace if workspaceID is set,
+// most recently deleted first
+func (r *CouchbaseDocumentRepository) ListTrash(ctx context.Context, userID, workspaceID string) ([]*document.Document, error) {
+	where, param := "(d.owner_id = $1 OR d.deleted_by = $1)", userID
+	if workspaceID != "" {
+		where, param = "d.workspace_id = $1", workspaceID
+	}
+
+	query := fmt.Sprintf(
+		"SELECT d.* FROM `%s`.`documents`.`documents` d WHERE %s AND d.deleted_at IS VALUED ORDER BY STR_TO_MILLIS(d.deleted_at) DESC",
+		db.GetBucketName(), where,
+	)
+	return r.queryDocuments(ctx, query, param)
+}
+
+// ListTrashedBefore retrieves the documents moved to the trash before a cutoff
+func (r *CouchbaseDocumentRepository) ListTrashedBefore(ctx context.Context, cutoff time.Time) ([]*document.Document, error) {
+	query := fmt.Sprintf(
+		"SELECT d.* FROM `%s`.`documents`.`documents` d WHERE d.deleted_at IS VALUED AND STR_TO_MILLIS(d.deleted_at) < $1",
+		db.GetBucketName(),
+	)
+	return r.queryDocuments(ctx, query, cutoff.UnixMilli())
+}
+
+// queryDocuments runs a query selecting whole documents
+func (r *CouchbaseDocumentRepository) queryDocuments(ctx context.Context, query string, params ...interface{}) ([]*document.Document, error) {
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: params,
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	var documents []*document.Document
+	for rows.Next() {
+		var docDoc document.DocumentDocument
+		if err := rows.Row(&docDoc); err != nil {
+			return nil, fmt.Errorf("failed to parse document row: %w", err)
+		}
+		documents = append(documents, document.FromDocument(&docDoc))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
 
 	return documents, nil
 }
+
+// documentSummaryFields selects the fields of a document.DocumentSummary
+const documentSummaryFields = "d.id, d.title, d.owner_id, d.workspace_id, d.folder_id, d.tags, d.is_template, d.created_at, d.updated_at"
+
+// documentSortExpressions maps sort keys to N1QL expressions
+// Times are compared as Unix milliseconds, since RFC 3339 strings with trimmed fractions do not sort correctly
+var documentSortExpressions = map[string]string{
+	DocumentSortUpdatedAt: "STR_TO_MILLIS(d.updated_at)",
+	DocumentSortCreatedAt: "STR_TO_MILLIS(d.created_at)",
+	DocumentSortTitle:     "LOWER(d.title)",
+}
+
+// ListPage retrieves one page of documents without their content, using keyset pagination
+func (r *CouchbaseDocumentRepository) ListPage(ctx context.Context, opts DocumentListOptions) (*DocumentPage, error) {
+	sortExpr, ok := documentSortExpressions[opts.SortBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort key %q", opts.SortBy)
+	}
+
+	var conditions []string
+	var params []interface{}
+	param := func(value interface{}) string {
+		params = append(params, value)
+		return fmt.Sprintf("$%d", len(params))
+	}
+
+	if opts.WorkspaceID != "" {
+		conditions = append(conditions, "d.workspace_id = "+param(opts.WorkspaceID))
+	}
+	if opts.UserID != "" {
+		userParam := param(opts.UserID)
+		switch opts.Ownership {
+		case DocumentOwnershipOwned:
+			conditions = append(conditions, "d.owner_id = "+userParam)
+		case DocumentOwnershipShared:
+			conditions = append(conditions, "d.owner_id != "+userParam)
+			if opts.WorkspaceID == "" {
+				conditions = append(conditions, "ARRAY_CONTAINS(d.collaborator_ids, "+userParam+")")
+			}
+		default:
+			if opts.WorkspaceID == "" {
+				conditions = append(conditions, fmt.Sprintf("(d.owner_id = %s OR ARRAY_CONTAINS(d.collaborator_ids, %s))", userParam, userParam))
+			}
+		}
+	}
+	if opts.Tag != "" {
+		conditions = append(conditions, "ARRAY_CONTAINS(d.tags, "+param(opts.Tag)+")")
+	}
+	if opts.TitleContains != "" {
+		conditions = append(conditions, "CONTAINS(LOWER(d.title), "+param(strings.ToLower(opts.TitleContains))+")")
+	}
+	if !opts.UpdatedSince.IsZero() {
+		conditions = append(conditions, "STR_TO_MILLIS(d.updated_at) >= "+param(opts.UpdatedSince.UnixMilli()))
+	}
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("a user or workspace is required")
+	}
+	conditions = append(conditions, trashFilter)
+
+	from := fmt.Sprintf("FROM `%s`.`documents`.`documents` d WHERE %s", db.GetBucketName(), strings.Join(conditions, " AND "))
+	scope := db.GetDocumentsScope()
+
+	total, err := r.count(ctx, scope, from, slices.Clone(params))
+	if err != nil {
+		return nil, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if opts.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	after := ""
+	if opts.After != nil {
+		value, id := param(opts.After.SortValue), param(opts.After.ID)
+		after = fmt.Sprintf(" AND (%s %s %s OR (%s = %s AND d.id %s %s))", sortExpr, comparison, value, sortExpr, value, comparison, id)
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s, %s AS sort_value %s%s ORDER BY %s %s, d.id %s LIMIT %d",
+		documentSummaryFields,
+		sortExpr, from, after, sortExpr, direction, direction, opts.Limit+1,
+	)
+
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: params,
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+	defer rows.Close()
+
+	type pageRow struct {
+		document.DocumentSummary
+		SortValue interface{} `json:"sort_value"`
+	}
+
+	page := &DocumentPage{Documents: []*document.DocumentSummary{}, Total: total}
+	var last *pageRow
+	for rows.Next() {
+		var row pageRow
+		if err := rows.Row(&row); err != nil {
+			return nil, fmt.Errorf("failed to parse document row: %w", err)
+		}
+		if len(page.Documents) == opts.Limit {
+			// The extra row only tells us there is another page
+			page.Next = &DocumentCursor{SortValue: last.SortValue, ID: last.ID}
+			break
+		}
+		page.Documents = append(page.Documents, &row.DocumentSummary)
+		last = &row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query documents: %w", err)
+	}
+
+	return page, nil
+}
+
+// count returns the number of documents matching a FROM ... WHERE clause
+func (r *CouchbaseDocumentRepository) count(ctx context.Context, scope *gocb.Scope, from string, params []interface{}) (int, error) {
+	rows, err := scope.Query("SELECT RAW COUNT(*) "+from, &gocb.QueryOptions{
+		PositionalParameters: params,
+		Context:              ctx,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count documents: %w", err)
+	}
+	defer rows.Close()
+
+	var total int
+	if err := rows.One(&total); err != nil {
+		return 0, fmt.Errorf("failed to parse document count: %w", err)
+	}
+	return total, nil
+}
+
+// ListTemplates retrieves the templates a user owns or collaborates on and those of the given workspaces, by title
+func (r *CouchbaseDocumentRepository) ListTemplates(ctx context.Context, userID string, workspaceIDs []string) ([]*document.DocumentSummary, error) {
+	query := fmt.Sprintf(
+		"SELECT %s FROM `%s`.`documents`.`documents` d "+
+			"WHERE d.is_template = true AND %s "+
+			"AND (d.owner_id = $1 OR ARRAY_CONTAINS(d.collaborator_ids, $1) OR d.workspace_id IN $2) "+
+			"ORDER BY LOWER(d.title), d.id",
+		documentSummaryFields, db.GetBucketName(), trashFilter,
+	)
+
+	if workspaceIDs == nil {
+		workspaceIDs = []string{}
+	}
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID, workspaceIDs},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*document.DocumentSummary{}
+	for rows.Next() {
+		var summary document.DocumentSummary
+		if err := rows.Row(&summary); err != nil {
+			return nil, fmt.Errorf("failed to parse template row: %w", err)
+		}
+		templates = append(templates, &summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// ListTags retrieves the tags on the documents a user owns or collaborates on, with counts
+func (r *CouchbaseDocumentRepository) ListTags(ctx context.Context, userID string) ([]TagCount, error) {
+	query := fmt.Sprintf(
+		"SELECT t AS tag, COUNT(*) AS count FROM `%s`.`documents`.`documents` d UNNEST d.tags t "+
+			"WHERE (d.owner_id = $1 OR ARRAY_CONTAINS(d.collaborator_ids, $1)) AND "+trashFilter+" GROUP BY t ORDER BY t",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tag TagCount
+		if err := rows.Row(&tag); err != nil {
+			return nil, fmt.Errorf("failed to parse tag row: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// RenameTags replaces tags on the documents a user owns or collaborates on in a single UPDATE
+func (r *CouchbaseDocumentRepository) RenameTags(ctx context.Context, userID string, from []string, to string) (int, error) {
+	query := fmt.Sprintf(
+		"UPDATE `%s`.`documents`.`documents` d "+
+			"SET d.tags = ARRAY_DISTINCT(ARRAY (CASE WHEN t IN $2 THEN $3 ELSE t END) FOR t IN d.tags END) "+
+			"WHERE (d.owner_id = $1 OR ARRAY_CONTAINS(d.collaborator_ids, $1)) AND ANY t IN d.tags SATISFIES t IN $2 END AND "+trashFilter+" "+
+			"RETURNING RAW d.id",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID, from, to},
+		Context:              ctx,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to rename tags: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to rename tags: %w", err)
+	}
+
+	return count, nil
+}
+
+// favoritesDocument stores a user's favorite documents
+type favoritesDocument struct {
+	UserID      string    `json:"user_id"`
+	DocumentIDs []string  `json:"document_ids"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GetFavorites retrieves a user's favorite document IDs in pinned order
+func (r *CouchbaseDocumentRepository) GetFavorites(ctx context.Context, userID string) ([]string, error) {
+	collection := db.GetFavoritesCollection()
+	documentID := fmt.Sprintf("favorites:%s", userID)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+
+	var doc favoritesDocument
+	if err := result.Content(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode favorites: %w", err)
+	}
+	if doc.DocumentIDs == nil {
+		return []string{}, nil
+	}
+
+	return doc.DocumentIDs, nil
+}
+
+// SetFavorites replaces a user's favorite document IDs
+func (r *CouchbaseDocumentRepository) SetFavorites(ctx context.Context, userID string, docIDs []string) error {
+	collection := db.GetFavoritesCollection()
+	documentID := fmt.Sprintf("favorites:%s", userID)
+
+	if len(docIDs) == 0 {
+		_, err := collection.Remove(documentID, &gocb.RemoveOptions{
+			Context: ctx,
+		})
+		if err != nil && !errors.Is(err, gocb.ErrDocumentNotFound) {
+			return fmt.Errorf("failed to delete favorites: %w", err)
+		}
+		return nil
+	}
+
+	_, err := collection.Upsert(documentID, favoritesDocument{
+		UserID:      userID,
+		DocumentIDs: docIDs,
+		UpdatedAt:   time.Now(),
+	}, &gocb.UpsertOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save favorites: %w", err)
+	}
+
+	return nil
+}
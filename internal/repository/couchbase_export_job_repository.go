@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"collaborative-editor/internal/db"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// CouchbaseExportJobRepository implements ExportJobRepository using Couchbase
+type CouchbaseExportJobRepository struct{}
+
+// NewCouchbaseExportJobRepository creates a new Couchbase export job repository
+func NewCouchbaseExportJobRepository() *CouchbaseExportJobRepository {
+	return &CouchbaseExportJobRepository{}
+}
+
+// Create stores a new job
+// Uses Couchbase document expiration (TTL) to delete the job when its download expires
+func (r *CouchbaseExportJobRepository) Create(ctx context.Context, job *ExportJob) error {
+	collection := db.GetExportJobsCollection()
+	documentID := fmt.Sprintf("export:%s", job.ID)
+
+	ttlDuration := time.Until(job.ExpiresAt)
+	if ttlDuration <= 0 {
+		return fmt.Errorf("job expiration must be in the future")
+	}
+
+	_, err := collection.Insert(documentID, job, &gocb.InsertOptions{
+		Context: ctx,
+		Expiry:  ttlDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert export job: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a job by its ID
+func (r *CouchbaseExportJobRepository) GetByID(ctx context.Context, jobID string) (*ExportJob, error) {
+	collection := db.GetExportJobsCollection()
+	documentID := fmt.Sprintf("export:%s", jobID)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("export job not found")
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+
+	var job ExportJob
+	if err := result.Content(&job); err != nil {
+		return nil, fmt.Errorf("failed to decode export job: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Update stores changes to a job, keeping its expiration
+func (r *CouchbaseExportJobRepository) Update(ctx context.Context, job *ExportJob) error {
+	collection := db.GetExportJobsCollection()
+	documentID := fmt.Sprintf("export:%s", job.ID)
+
+	_, err := collection.Replace(documentID, job, &gocb.ReplaceOptions{
+		Context:        ctx,
+		PreserveExpiry: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+
+	return nil
+}
+
+// FindActiveByUserID returns the most recent pending or running job of a user created after since
+func (r *CouchbaseExportJobRepository) FindActiveByUserID(ctx context.Context, userID string, since time.Time) (*ExportJob, error) {
+	query := fmt.Sprintf(
+		"SELECT j.* FROM `%s`.`exports`.`jobs` j WHERE j.user_id = $1 AND j.status IN [$2, $3] AND j.created_at > $4 ORDER BY j.created_at DESC LIMIT 1",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetExportsScope()
+	queryResult, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID, ExportStatusPending, ExportStatusRunning, since},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query export jobs: %w", err)
+	}
+	defer queryResult.Close()
+
+	if queryResult.Next() {
+		var job ExportJob
+		if err := queryResult.Row(&job); err != nil {
+			return nil, fmt.Errorf("failed to parse export job: %w", err)
+		}
+		return &job, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"collaborative-editor/internal/db"
+	"collaborative-editor/pkg/folder"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// CouchbaseFolderRepository implements FolderRepository using Couchbase
+type CouchbaseFolderRepository struct{}
+
+// NewCouchbaseFolderRepository creates a new Couchbase folder repository
+func NewCouchbaseFolderRepository() *CouchbaseFolderRepository {
+	return &CouchbaseFolderRepository{}
+}
+
+// Create stores a new folder in Couchbase
+func (r *CouchbaseFolderRepository) Create(ctx context.Context, f *folder.Folder) error {
+	collection := db.GetFoldersCollection()
+	documentID := fmt.Sprintf("folder:%s", f.ID)
+
+	_, err := collection.Insert(documentID, f.ToDocument(), &gocb.InsertOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert folder: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a folder by its ID
+func (r *CouchbaseFolderRepository) GetByID(ctx context.Context, id string) (*folder.Folder, error) {
+	collection := db.GetFoldersCollection()
+	documentID := fmt.Sprintf("folder:%s", id)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("folder not found")
+		}
+		return nil, fmt.Errorf("failed to get folder: %w", err)
+	}
+
+	var doc folder.FolderDocument
+	if err := result.Content(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode folder: %w", err)
+	}
+
+	return folder.FromDocument(&doc), nil
+}
+
+// Update updates an existing folder
+func (r *CouchbaseFolderRepository) Update(ctx context.Context, f *folder.Folder) error {
+	collection := db.GetFoldersCollection()
+	documentID := fmt.Sprintf("folder:%s", f.ID)
+
+	_, err := collection.Replace(documentID, f.ToDocument(), &gocb.ReplaceOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update folder: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a folder
+func (r *CouchbaseFolderRepository) Delete(ctx context.Context, id string) error {
+	collection := db.GetFoldersCollection()
+	documentID := fmt.Sprintf("folder:%s", id)
+
+	_, err := collection.Remove(documentID, &gocb.RemoveOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete folder: %w", err)
+	}
+
+	return nil
+}
+
+// ListByParentID retrieves the subfolders of a folder
+func (r *CouchbaseFolderRepository) ListByParentID(ctx context.Context, parentID string) ([]*folder.Folder, error) {
+	return r.query(ctx, "f.parent_id = $1", parentID)
+}
+
+// ListRootByUserID retrieves the user's top-level personal folders and the folders shared with them
+func (r *CouchbaseFolderRepository) ListRootByUserID(ctx context.Context, userID string) ([]*folder.Folder, error) {
+	return r.query(ctx, "(f.owner_id = $1 AND f.parent_id IS NOT VALUED AND f.workspace_id IS NOT VALUED) OR ARRAY_CONTAINS(f.collaborator_ids, $1)", userID)
+}
+
+// ListRootByWorkspaceID retrieves the top-level folders of a workspace
+func (r *CouchbaseFolderRepository) ListRootByWorkspaceID(ctx context.Context, workspaceID string) ([]*folder.Folder, error) {
+	return r.query(ctx, "f.workspace_id = $1 AND f.parent_id IS NOT VALUED", workspaceID)
+}
+
+// ListByUserID retrieves every folder the user owns or is a collaborator on
+func (r *CouchbaseFolderRepository) ListByUserID(ctx context.Context, userID string) ([]*folder.Folder, error) {
+	return r.query(ctx, "f.owner_id = $1 OR ARRAY_CONTAINS(f.collaborator_ids, $1)", userID)
+}
+
+// ListByWorkspaceID retrieves every folder of a workspace
+func (r *CouchbaseFolderRepository) ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*folder.Folder, error) {
+	return r.query(ctx, "f.workspace_id = $1", workspaceID)
+}
+
+// query runs a folder query with a single positional parameter, ordered by name
+func (r *CouchbaseFolderRepository) query(ctx context.Context, where string, param string) ([]*folder.Folder, error) {
+	query := fmt.Sprintf(
+		"SELECT f.* FROM `%s`.`documents`.`folders` f WHERE %s ORDER BY f.name",
+		db.GetBucketName(), where,
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{param},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query folders: %w", err)
+	}
+	defer rows.Close()
+
+	folders := []*folder.Folder{}
+	for rows.Next() {
+		var doc folder.FolderDocument
+		if err := rows.Row(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse folder row: %w", err)
+		}
+		folders = append(folders, folder.FromDocument(&doc))
+	}
+
+	return folders, nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"collaborative-editor/internal/db"
+
+	"github.com/couchbase/gocb/v2"
+	"github.com/google/uuid"
+)
+
+// maxCASRetries bounds optimistic-locking retries on contended counters
+const maxCASRetries = 10
+
+// CouchbaseLoginAttemptStore implements LoginAttemptStore using Couchbase
+// Counters are shared by all server replicas
+type CouchbaseLoginAttemptStore struct{}
+
+// NewCouchbaseLoginAttemptStore creates a new Couchbase login attempt store
+func NewCouchbaseLoginAttemptStore() *CouchbaseLoginAttemptStore {
+	return &CouchbaseLoginAttemptStore{}
+}
+
+// attemptDocumentID builds the document key for a counter key
+// Keys contain user input, so they are hashed to keep document IDs short and safe
+func attemptDocumentID(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return fmt.Sprintf("attempts:%s", hex.EncodeToString(hash[:]))
+}
+
+// Get returns the attempts recorded for a key
+func (s *CouchbaseLoginAttemptStore) Get(ctx context.Context, key string) (*LoginAttempts, error) {
+	collection := db.GetLoginAttemptsCollection()
+
+	result, err := collection.Get(attemptDocumentID(key), &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return &LoginAttempts{Key: key}, nil
+		}
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+
+	var attempts LoginAttempts
+	if err := result.Content(&attempts); err != nil {
+		return nil, fmt.Errorf("failed to decode login attempts: %w", err)
+	}
+
+	return &attempts, nil
+}
+
+// RecordFailure increments the failure counter of a key
+// Uses CAS so concurrent failures on different replicas are all counted
+func (s *CouchbaseLoginAttemptStore) RecordFailure(ctx context.Context, key string, ttl time.Duration) (*LoginAttempts, error) {
+	collection := db.GetLoginAttemptsCollection()
+	documentID := attemptDocumentID(key)
+
+	for i := 0; i < maxCASRetries; i++ {
+		result, err := collection.Get(documentID, &gocb.GetOptions{
+			Context: ctx,
+		})
+		if err != nil && !errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("failed to get login attempts: %w", err)
+		}
+
+		if err != nil {
+			// First failure for this key
+			attempts := &LoginAttempts{Key: key, Failures: 1, LastFailureAt: time.Now()}
+			_, err := collection.Insert(documentID, attempts, &gocb.InsertOptions{
+				Context: ctx,
+				Expiry:  ttl,
+			})
+			if errors.Is(err, gocb.ErrDocumentExists) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to record login failure: %w", err)
+			}
+			return attempts, nil
+		}
+
+		var attempts LoginAttempts
+		if err := result.Content(&attempts); err != nil {
+			return nil, fmt.Errorf("failed to decode login attempts: %w", err)
+		}
+		attempts.Failures++
+		attempts.LastFailureAt = time.Now()
+
+		_, err = collection.Replace(documentID, &attempts, &gocb.ReplaceOptions{
+			Context: ctx,
+			Cas:     result.Cas(),
+			Expiry:  ttl,
+		})
+		if errors.Is(err, gocb.ErrCasMismatch) || errors.Is(err, gocb.ErrDocumentNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to record login failure: %w", err)
+		}
+		return &attempts, nil
+	}
+
+	return nil, fmt.Errorf("failed to record login failure: too much contention")
+}
+
+// Reset clears the counter of a key
+func (s *CouchbaseLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	collection := db.GetLoginAttemptsCollection()
+
+	_, err := collection.Remove(attemptDocumentID(key), &gocb.RemoveOptions{
+		Context: ctx,
+	})
+	if err != nil && !errors.Is(err, gocb.ErrDocumentNotFound) {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+
+	return nil
+}
+
+// loginAuditTTL is how long login audit records are kept
+const loginAuditTTL = 90 * 24 * time.Hour
+
+// CouchbaseLoginAuditRepository implements LoginAuditRepository using Couchbase
+type CouchbaseLoginAuditRepository struct{}
+
+// NewCouchbaseLoginAuditRepository creates a new Couchbase login audit repository
+func NewCouchbaseLoginAuditRepository() *CouchbaseLoginAuditRepository {
+	return &CouchbaseLoginAuditRepository{}
+}
+
+// Record stores an audit record; records expire after 90 days
+func (r *CouchbaseLoginAuditRepository) Record(ctx context.Context, entry *LoginAuditEntry) error {
+	collection := db.GetLoginAuditCollection()
+
+	if entry.ID == "" {
+		entry.ID = uuid.New().String()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := collection.Insert(fmt.Sprintf("audit:%s", entry.ID), entry, &gocb.InsertOptions{
+		Context: ctx,
+		Expiry:  loginAuditTTL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record login audit entry: %w", err)
+	}
+
+	return nil
+}
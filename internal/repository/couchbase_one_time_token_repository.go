@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"collaborative-editor/internal/db"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// CouchbaseOneTimeTokenRepository implements OneTimeTokenRepository using Couchbase
+type CouchbaseOneTimeTokenRepository struct{}
+
+// NewCouchbaseOneTimeTokenRepository creates a new Couchbase one-time token repository
+func NewCouchbaseOneTimeTokenRepository() *CouchbaseOneTimeTokenRepository {
+	return &CouchbaseOneTimeTokenRepository{}
+}
+
+// Create stores a hashed token
+// Uses Couchbase document expiration (TTL) to automatically delete expired tokens
+func (r *CouchbaseOneTimeTokenRepository) Create(ctx context.Context, token, purpose, userID string, expiresAt time.Time) error {
+	collection := db.GetOneTimeTokensCollection()
+
+	tokenHash := hashToken(token)
+	documentID := fmt.Sprintf("ott:%s", tokenHash)
+
+	ttlDuration := time.Until(expiresAt)
+	if ttlDuration <= 0 {
+		return fmt.Errorf("token expiration must be in the future")
+	}
+
+	_, err := collection.Insert(documentID, OneTimeToken{
+		TokenHash: tokenHash,
+		Purpose:   purpose,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}, &gocb.InsertOptions{
+		Context: ctx,
+		Expiry:  ttlDuration,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store token: %w", err)
+	}
+
+	return nil
+}
+
+// Consume retrieves and removes a token
+// Removal is atomic, so concurrent attempts to use the same token cannot both succeed
+func (r *CouchbaseOneTimeTokenRepository) Consume(ctx context.Context, token, purpose string) (*OneTimeToken, error) {
+	collection := db.GetOneTimeTokensCollection()
+
+	documentID := fmt.Sprintf("ott:%s", hashToken(token))
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("token not found")
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	var t OneTimeToken
+	if err := result.Content(&t); err != nil {
+		return nil, fmt.Errorf("failed to decode token: %w", err)
+	}
+
+	if t.Purpose != purpose {
+		return nil, fmt.Errorf("token not found")
+	}
+
+	_, err = collection.Remove(documentID, &gocb.RemoveOptions{
+		Context: ctx,
+		Cas:     result.Cas(),
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) || errors.Is(err, gocb.ErrCasMismatch) {
+			return nil, fmt.Errorf("token not found")
+		}
+		return nil, fmt.Errorf("failed to consume token: %w", err)
+	}
+
+	if time.Now().After(t.ExpiresAt) {
+		return nil, fmt.Errorf("token expired")
+	}
+
+	return &t, nil
+}
+
+// DeleteByUserID removes all tokens of a purpose issued to a user
+func (r *CouchbaseOneTimeTokenRepository) DeleteByUserID(ctx context.Context, userID, purpose string) error {
+	query := fmt.Sprintf(
+		"DELETE FROM `%s`.`auth`.`one_time_tokens` t WHERE t.user_id = $1 AND t.purpose = $2",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetAuthScope()
+	result, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID, purpose},
+		Context:              ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete tokens: %w", err)
+	}
+	defer result.Close()
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"collaborative-editor/internal/auth"
 	"collaborative-editor/internal/db"
 
 	"github.com/couchbase/gocb/v2"
@@ -20,6 +21,12 @@ type BlacklistedToken struct {
 	BlacklistedAt time.Time `json:"blacklisted_at"`
 }
 
+// UserTokenRevocation represents a revocation of all tokens issued to a user before a point in time
+type UserTokenRevocation struct {
+	UserID    string    `json:"user_id"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
 // CouchbaseTokenBlacklistRepository implements TokenBlacklistRepository using Couchbase
 type CouchbaseTokenBlacklistRepository struct{}
 
@@ -149,3 +156,46 @@ func (r *CouchbaseTokenBlacklistRepository) RemoveExpiredTokens(ctx context.Cont
 
 	return nil
 }
+
+// RevokeUserTokens records that all tokens of a user issued at or before the given time are revoked
+// The record expires once every token it could affect has expired on its own
+func (r *CouchbaseTokenBlacklistRepository) RevokeUserTokens(ctx context.Context, userID string, before time.Time) error {
+	collection := db.GetBlacklistCollection()
+	documentID := fmt.Sprintf("revoked_user:%s", userID)
+
+	_, err := collection.Upsert(documentID, UserTokenRevocation{
+		UserID:    userID,
+		RevokedAt: before,
+	}, &gocb.UpsertOptions{
+		Context: ctx,
+		Expiry:  auth.TokenTTL + time.Hour,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserTokensRevokedAt returns the revocation cutoff for a user's tokens
+func (r *CouchbaseTokenBlacklistRepository) GetUserTokensRevokedAt(ctx context.Context, userID string) (time.Time, error) {
+	collection := db.GetBlacklistCollection()
+	documentID := fmt.Sprintf("revoked_user:%s", userID)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to get user token revocation: %w", err)
+	}
+
+	var revocation UserTokenRevocation
+	if err := result.Content(&revocation); err != nil {
+		return time.Time{}, fmt.Errorf("failed to decode user token revocation: %w", err)
+	}
+
+	return revocation.RevokedAt, nil
+}
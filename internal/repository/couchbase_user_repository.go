@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"collaborative-editor/internal/db"
 	"collaborative-editor/pkg/user"
@@ -179,3 +180,52 @@ func (r *CouchbaseUserRepository) Delete(ctx context.Context, userID string) err
 
 	return nil
 }
+
+// Search retrieves discoverable users by username or email prefix
+// Backed by the LOWER(username) and LOWER(email) indexes created on startup
+func (r *CouchbaseUserRepository) Search(ctx context.Context, prefix, excludeUserID string, limit int) ([]*user.User, error) {
+	query := fmt.Sprintf(
+		"SELECT u.* FROM `%s`.`%s`.`%s` u "+
+			"WHERE (LOWER(u.username) LIKE $1 OR LOWER(u.email) LIKE $1) AND u.id != $2 "+
+			"AND (u.preferences.discoverable IS NOT VALUED OR u.preferences.discoverable = true) "+
+			"ORDER BY LOWER(u.username) LIMIT $3",
+		db.GetBucketName(),
+		db.GetScopeName(),
+		db.GetCollectionName(),
+	)
+
+	scope := db.GetScope()
+	queryResult, err := scope.Query(
+		query,
+		&gocb.QueryOptions{
+			PositionalParameters: []interface{}{likePrefix(strings.ToLower(prefix)), excludeUserID, limit},
+			Context:              ctx,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search users: %w", err)
+	}
+	defer queryResult.Close()
+
+	users := []*user.User{}
+	for queryResult.Next() {
+		var doc user.UserDocument
+		if err := queryResult.Row(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse query result: %w", err)
+		}
+		users = append(users, user.FromDocument(&doc))
+	}
+
+	if err := queryResult.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, nil
+}
+
+// likePrefix builds a LIKE pattern matching values that start with prefix
+// Wildcards in the prefix are escaped so they match literally
+func likePrefix(prefix string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(prefix) + "%"
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"collaborative-editor/internal/db"
+	"collaborative-editor/pkg/webhook"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// webhookDeliveryTTL is how long delivery attempts are kept in the log
+const webhookDeliveryTTL = 30 * 24 * time.Hour
+
+// CouchbaseWebhookRepository implements WebhookRepository using Couchbase
+type CouchbaseWebhookRepository struct{}
+
+// NewCouchbaseWebhookRepository creates a new Couchbase webhook repository
+func NewCouchbaseWebhookRepository() *CouchbaseWebhookRepository {
+	return &CouchbaseWebhookRepository{}
+}
+
+// Create stores a new webhook in Couchbase
+func (r *CouchbaseWebhookRepository) Create(ctx context.Context, w *webhook.Webhook) error {
+	collection := db.GetWebhooksCollection()
+	documentID := fmt.Sprintf("webhook:%s", w.ID)
+
+	_, err := collection.Insert(documentID, w.ToDocument(), &gocb.InsertOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a webhook by its ID
+func (r *CouchbaseWebhookRepository) GetByID(ctx context.Context, id string) (*webhook.Webhook, error) {
+	collection := db.GetWebhooksCollection()
+	documentID := fmt.Sprintf("webhook:%s", id)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	var doc webhook.WebhookDocument
+	if err := result.Content(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+
+	return webhook.FromDocument(&doc), nil
+}
+
+// Update updates an existing webhook
+func (r *CouchbaseWebhookRepository) Update(ctx context.Context, w *webhook.Webhook) error {
+	collection := db.GetWebhooksCollection()
+	documentID := fmt.Sprintf("webhook:%s", w.ID)
+
+	_, err := collection.Replace(documentID, w.ToDocument(), &gocb.ReplaceOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a webhook; its delivery log expires on its own
+func (r *CouchbaseWebhookRepository) Delete(ctx context.Context, id string) error {
+	collection := db.GetWebhooksCollection()
+	documentID := fmt.Sprintf("webhook:%s", id)
+
+	_, err := collection.Remove(documentID, &gocb.RemoveOptions{
+		Context: ctx,
+	})
+	if err != nil && !errors.Is(err, gocb.ErrDocumentNotFound) {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// ListByDocumentID retrieves the webhooks subscribed to a document
+func (r *CouchbaseWebhookRepository) ListByDocumentID(ctx context.Context, documentID string) ([]*webhook.Webhook, error) {
+	return r.list(ctx, "document_id", documentID)
+}
+
+// ListByWorkspaceID retrieves the webhooks subscribed to a workspace
+func (r *CouchbaseWebhookRepository) ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*webhook.Webhook, error) {
+	return r.list(ctx, "workspace_id", workspaceID)
+}
+
+// list retrieves the webhooks whose field matches a value, oldest first
+func (r *CouchbaseWebhookRepository) list(ctx context.Context, field, value string) ([]*webhook.Webhook, error) {
+	query := fmt.Sprintf(
+		"SELECT w.* FROM `%s`.`documents`.`webhooks` w WHERE w.%s = $1 ORDER BY w.created_at",
+		db.GetBucketName(), field,
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{value},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []*webhook.Webhook{}
+	for rows.Next() {
+		var doc webhook.WebhookDocument
+		if err := rows.Row(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse webhook row: %w", err)
+		}
+		webhooks = append(webhooks, webhook.FromDocument(&doc))
+	}
+
+	return webhooks, nil
+}
+
+// RecordDelivery stores a delivery attempt; attempts expire after 30 days
+func (r *CouchbaseWebhookRepository) RecordDelivery(ctx context.Context, d *webhook.Delivery) error {
+	collection := db.GetWebhookDeliveriesCollection()
+
+	_, err := collection.Insert(fmt.Sprintf("delivery:%s", d.ID), d, &gocb.InsertOptions{
+		Context: ctx,
+		Expiry:  webhookDeliveryTTL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the latest delivery attempts of a webhook, newest first
+func (r *CouchbaseWebhookRepository) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*webhook.Delivery, error) {
+	query := fmt.Sprintf(
+		"SELECT d.* FROM `%s`.`documents`.`webhook_deliveries` d WHERE d.webhook_id = $1 ORDER BY d.created_at DESC LIMIT $2",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetDocumentsScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{webhookID, limit},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*webhook.Delivery{}
+	for rows.Next() {
+		var d webhook.Delivery
+		if err := rows.Row(&d); err != nil {
+			return nil, fmt.Errorf("failed to parse webhook delivery row: %w", err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"collaborative-editor/internal/db"
+	"collaborative-editor/pkg/workspace"
+
+	"github.com/couchbase/gocb/v2"
+)
+
+// CouchbaseWorkspaceRepository implements WorkspaceRepository using Couchbase
+type CouchbaseWorkspaceRepository struct{}
+
+// NewCouchbaseWorkspaceRepository creates a new Couchbase workspace repository
+func NewCouchbaseWorkspaceRepository() *CouchbaseWorkspaceRepository {
+	return &CouchbaseWorkspaceRepository{}
+}
+
+// Create stores a new workspace in Couchbase
+func (r *CouchbaseWorkspaceRepository) Create(ctx context.Context, w *workspace.Workspace) error {
+	collection := db.GetWorkspacesCollection()
+	documentID := fmt.Sprintf("workspace:%s", w.ID)
+
+	_, err := collection.Insert(documentID, w.ToDocument(), &gocb.InsertOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert workspace: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a workspace by its ID
+func (r *CouchbaseWorkspaceRepository) GetByID(ctx context.Context, id string) (*workspace.Workspace, error) {
+	collection := db.GetWorkspacesCollection()
+	documentID := fmt.Sprintf("workspace:%s", id)
+
+	result, err := collection.Get(documentID, &gocb.GetOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		if errors.Is(err, gocb.ErrDocumentNotFound) {
+			return nil, fmt.Errorf("workspace not found")
+		}
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	var doc workspace.WorkspaceDocument
+	if err := result.Content(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode workspace: %w", err)
+	}
+
+	return workspace.FromDocument(&doc), nil
+}
+
+// Update updates an existing workspace
+func (r *CouchbaseWorkspaceRepository) Update(ctx context.Context, w *workspace.Workspace) error {
+	collection := db.GetWorkspacesCollection()
+	documentID := fmt.Sprintf("workspace:%s", w.ID)
+
+	_, err := collection.Replace(documentID, w.ToDocument(), &gocb.ReplaceOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update workspace: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a workspace
+func (r *CouchbaseWorkspaceRepository) Delete(ctx context.Context, id string) error {
+	collection := db.GetWorkspacesCollection()
+	documentID := fmt.Sprintf("workspace:%s", id)
+
+	_, err := collection.Remove(documentID, &gocb.RemoveOptions{
+		Context: ctx,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace: %w", err)
+	}
+
+	return nil
+}
+
+// ListByUserID retrieves all workspaces the user is a member of
+func (r *CouchbaseWorkspaceRepository) ListByUserID(ctx context.Context, userID string) ([]*workspace.Workspace, error) {
+	query := fmt.Sprintf(
+		"SELECT w.* FROM `%s`.`workspaces`.`workspaces` w WHERE ANY m IN w.members SATISFIES m.user_id = $1 END ORDER BY w.name",
+		db.GetBucketName(),
+	)
+
+	scope := db.GetWorkspacesScope()
+	rows, err := scope.Query(query, &gocb.QueryOptions{
+		PositionalParameters: []interface{}{userID},
+		Context:              ctx,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	workspaces := []*workspace.Workspace{}
+	for rows.Next() {
+		var doc workspace.WorkspaceDocument
+		if err := rows.Row(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse workspace row: %w", err)
+		}
+		workspaces = append(workspaces, workspace.FromDocument(&doc))
+	}
+
+	return workspaces, nil
+}
@@ -2,15 +2,95 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"collaborative-editor/pkg/document"
 )
 
+// Sort keys for paginated document listings
+const (
+	DocumentSortUpdatedAt = "updated_at"
+	DocumentSortCreatedAt = "created_at"
+	DocumentSortTitle     = "title"
+)
+
+// Ownership filters for paginated document listings, relative to DocumentListOptions.UserID
+const (
+	DocumentOwnershipAll    = ""
+	DocumentOwnershipOwned  = "owned"
+	DocumentOwnershipShared = "shared"
+)
+
+// DocumentCursor marks the position after which the next page starts
+type DocumentCursor struct {
+	SortValue interface{} `json:"v"`  // Sort key of the last document: lowercased title, or time in Unix milliseconds
+	ID        string      `json:"id"` // ID of the last document, to break ties
+}
+
+// DocumentListOptions configures a paginated document listing
+type DocumentListOptions struct {
+	UserID        string    // Lists documents owned by or shared with this user
+	WorkspaceID   string    // If set, lists the documents of this workspace instead
+	Ownership     string    // One of the DocumentOwnership constants
+	TitleContains string    // Case-insensitive title filter
+	Tag           string    // Only documents with this tag, if set
+	UpdatedSince  time.Time // Only documents updated at or after this time, if set
+	SortBy        string    // One of the DocumentSort constants
+	Descending    bool
+	After         *DocumentCursor // Start after this position, if set
+	Limit         int
+}
+
+// TagCount is a tag and the number of documents using it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// DocumentPage is one page of a document listing
+type DocumentPage struct {
+	Documents []*document.DocumentSummary
+	Total     int             // Number of documents matching the filters, across all pages
+	Next      *DocumentCursor // Position of the next page, nil on the last page
+}
+
 // DocumentRepository defines the interface for document storage operations
+// Documents in the trash are reported as not found by GetByID and left out of listings
 type DocumentRepository interface {
 	Create(ctx context.Context, doc *document.Document) error
 	GetByID(ctx context.Context, id string) (*document.Document, error)
 	Update(ctx context.Context, doc *document.Document) error
+	// Delete permanently removes a document; documents are moved to the trash by setting DeletedAt
 	Delete(ctx context.Context, id string) error
 	ListByUserID(ctx context.Context, userID string) ([]*document.Document, error)
+	ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*document.Document, error)
+	ListByFolderID(ctx context.Context, folderID string) ([]*document.Document, error)
+	// ListAll returns every document, for rebuilding derived data such as the search index
+	ListAll(ctx context.Context) ([]*document.Document, error)
+	// ListPage returns one page of documents, without their content
+	ListPage(ctx context.Context, opts DocumentListOptions) (*DocumentPage, error)
+
+	// GetTrashedByID retrieves a document from the trash
+	GetTrashedByID(ctx context.Context, id string) (*document.Document, error)
+	// ListTrash returns the trashed documents a user owns or deleted, or those of a workspace
+	// if workspaceID is set, most recently deleted first
+	ListTrash(ctx context.Context, userID, workspaceID string) ([]*document.Document, error)
+	// ListTrashedBefore returns the documents moved to the trash before a cutoff, for purging
+	ListTrashedBefore(ctx context.Context, cutoff time.Time) ([]*document.Document, error)
+
+	// ListTemplates returns the templates a user owns or collaborates on and those of the given workspaces,
+	// without their content, sorted by title
+	ListTemplates(ctx context.Context, userID string, workspaceIDs []string) ([]*document.DocumentSummary, error)
+
+	// ListTags returns the tags on the documents a user owns or collaborates on, with counts
+	ListTags(ctx context.Context, userID string) ([]TagCount, error)
+	// RenameTags replaces each of the from tags with to on the documents a user owns or collaborates on,
+	// merging them when a document has several, and returns the number of documents changed
+	// Documents in the trash are left alone
+	RenameTags(ctx context.Context, userID string, from []string, to string) (int, error)
+
+	// GetFavorites returns a user's favorite document IDs in pinned order
+	GetFavorites(ctx context.Context, userID string) ([]string, error)
+	// SetFavorites replaces a user's favorite document IDs; an empty list removes them
+	SetFavorites(ctx context.Context, userID string, docIDs []string) error
 }
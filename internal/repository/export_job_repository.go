@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Export job statuses
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// ExportJob tracks an asynchronous personal data export
+type ExportJob struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	FileName    string     `json:"file_name,omitempty"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// ExportJobRepository defines the interface for export job operations
+type ExportJobRepository interface {
+	// Create stores a new job; it is deleted automatically once it expires
+	Create(ctx context.Context, job *ExportJob) error
+
+	// GetByID retrieves a job
+	GetByID(ctx context.Context, jobID string) (*ExportJob, error)
+
+	// Update stores changes to a job
+	Update(ctx context.Context, job *ExportJob) error
+
+	// FindActiveByUserID returns a pending or running job of the user created after since, if any
+	FindActiveByUserID(ctx context.Context, userID string, since time.Time) (*ExportJob, error)
+}
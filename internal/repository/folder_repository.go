@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"collaborative-editor/pkg/folder"
+)
+
+// FolderRepository defines the interface for folder storage operations
+type FolderRepository interface {
+	Create(ctx context.Context, f *folder.Folder) error
+	GetByID(ctx context.Context, id string) (*folder.Folder, error)
+	Update(ctx context.Context, f *folder.Folder) error
+	Delete(ctx context.Context, id string) error
+	// ListByParentID returns the subfolders of a folder
+	ListByParentID(ctx context.Context, parentID string) ([]*folder.Folder, error)
+	// ListRootByUserID returns the user's top-level personal folders and the folders shared with them
+	ListRootByUserID(ctx context.Context, userID string) ([]*folder.Folder, error)
+	// ListRootByWorkspaceID returns the top-level folders of a workspace
+	ListRootByWorkspaceID(ctx context.Context, workspaceID string) ([]*folder.Folder, error)
+	// ListByUserID returns every folder the user owns or is a collaborator on
+	ListByUserID(ctx context.Context, userID string) ([]*folder.Folder, error)
+	// ListByWorkspaceID returns every folder of a workspace
+	ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*folder.Folder, error)
+}
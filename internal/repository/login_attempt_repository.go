@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// LoginAttempts represents the failed login attempts recorded for a key (an account or an IP)
+type LoginAttempts struct {
+	Key           string    `json:"key"`
+	Failures      int       `json:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at"`
+}
+
+// LoginAttemptStore defines the interface for failed login counters
+// Implementations must be safe for concurrent use; the Couchbase implementation
+// shares counters across server replicas
+type LoginAttemptStore interface {
+	// Get returns the attempts recorded for a key, or a zero value if there are none
+	Get(ctx context.Context, key string) (*LoginAttempts, error)
+
+	// RecordFailure atomically increments the failure counter of a key
+	// The counter is forgotten after ttl without further failures
+	RecordFailure(ctx context.Context, key string, ttl time.Duration) (*LoginAttempts, error)
+
+	// Reset clears the counter of a key
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginAuditEntry represents an audit record of a failed login attempt
+type LoginAuditEntry struct {
+	ID         string    `json:"id"`
+	Identifier string    `json:"identifier"` // Email or username as entered
+	UserID     string    `json:"user_id,omitempty"`
+	IP         string    `json:"ip"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// LoginAuditRepository defines the interface for storing login audit records
+type LoginAuditRepository interface {
+	Record(ctx context.Context, entry *LoginAuditEntry) error
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryLoginAttempt is an in-memory counter with its expiry
+type memoryLoginAttempt struct {
+	attempts  LoginAttempts
+	expiresAt time.Time
+}
+
+// MemoryLoginAttemptStore implements LoginAttemptStore in process memory
+// Counters are not shared between replicas; use it for single-instance deployments
+type MemoryLoginAttemptStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryLoginAttempt
+}
+
+// NewMemoryLoginAttemptStore creates a new in-memory login attempt store
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		entries: make(map[string]*memoryLoginAttempt),
+	}
+}
+
+// Get returns the attempts recorded for a key
+func (s *MemoryLoginAttemptStore) Get(ctx context.Context, key string) (*LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		delete(s.entries, key)
+		return &LoginAttempts{Key: key}, nil
+	}
+
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+// RecordFailure increments the failure counter of a key
+func (s *MemoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, ttl time.Duration) (*LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.removeExpired(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryLoginAttempt{attempts: LoginAttempts{Key: key}}
+		s.entries[key] = entry
+	}
+	entry.attempts.Failures++
+	entry.attempts.LastFailureAt = now
+	entry.expiresAt = now.Add(ttl)
+
+	attempts := entry.attempts
+	return &attempts, nil
+}
+
+// Reset clears the counter of a key
+func (s *MemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// removeExpired drops expired counters so the map does not grow without bound
+func (s *MemoryLoginAttemptStore) removeExpired(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
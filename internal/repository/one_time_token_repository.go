@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// Purposes of one-time tokens
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// OneTimeToken represents a single-use, time-limited token (e.g. password reset)
+// Only the SHA256 hash of the token is ever stored
+type OneTimeToken struct {
+	TokenHash string    `json:"token_hash"`
+	Purpose   string    `json:"purpose"`
+	UserID    string    `json:"user_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OneTimeTokenRepository defines the interface for one-time token operations
+type OneTimeTokenRepository interface {
+	// Create stores a token for the given user and purpose
+	// The repository will hash the token internally for storage
+	Create(ctx context.Context, token, purpose, userID string, expiresAt time.Time) error
+
+	// Consume looks up a token and deletes it so it cannot be used again
+	// Returns an error if the token does not exist, has expired or was issued for another purpose
+	Consume(ctx context.Context, token, purpose string) (*OneTimeToken, error)
+
+	// DeleteByUserID removes all outstanding tokens of a purpose for a user
+	DeleteByUserID(ctx context.Context, userID, purpose string) error
+}
@@ -17,4 +17,11 @@ type TokenBlacklistRepository interface {
 
 	// RemoveExpiredTokens removes expired tokens from the blacklist (cleanup operation)
 	RemoveExpiredTokens(ctx context.Context) error
+
+	// RevokeUserTokens revokes every token of a user issued at or before the given time
+	RevokeUserTokens(ctx context.Context, userID string, before time.Time) error
+
+	// GetUserTokensRevokedAt returns the time up to which the user's tokens are revoked
+	// Returns the zero time if the user's tokens have never been revoked
+	GetUserTokensRevokedAt(ctx context.Context, userID string) (time.Time, error)
 }
@@ -14,4 +14,8 @@ type UserRepository interface {
 	GetByUsername(ctx context.Context, username string) (*user.User, error)
 	Update(ctx context.Context, u *user.User) error
 	Delete(ctx context.Context, userID string) error
+
+	// Search returns discoverable users whose username or email starts with prefix (case-insensitive),
+	// excluding excludeUserID, ordered by username
+	Search(ctx context.Context, prefix, excludeUserID string, limit int) ([]*user.User, error)
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"collaborative-editor/pkg/webhook"
+)
+
+// WebhookRepository defines the interface for webhook subscription and delivery log operations
+type WebhookRepository interface {
+	Create(ctx context.Context, w *webhook.Webhook) error
+	GetByID(ctx context.Context, id string) (*webhook.Webhook, error)
+	Update(ctx context.Context, w *webhook.Webhook) error
+	Delete(ctx context.Context, id string) error
+	// ListByDocumentID returns the webhooks subscribed to a document, oldest first
+	ListByDocumentID(ctx context.Context, documentID string) ([]*webhook.Webhook, error)
+	// ListByWorkspaceID returns the webhooks subscribed to a workspace, oldest first
+	ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*webhook.Webhook, error)
+
+	// RecordDelivery stores a delivery attempt; attempts expire after 30 days
+	RecordDelivery(ctx context.Context, d *webhook.Delivery) error
+	// ListDeliveries returns the latest delivery attempts of a webhook, newest first
+	ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*webhook.Delivery, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"collaborative-editor/pkg/workspace"
+)
+
+// WorkspaceRepository defines the interface for workspace storage operations
+type WorkspaceRepository interface {
+	Create(ctx context.Context, w *workspace.Workspace) error
+	GetByID(ctx context.Context, id string) (*workspace.Workspace, error)
+	Update(ctx context.Context, w *workspace.Workspace) error
+	Delete(ctx context.Context, id string) error
+	// ListByUserID returns all workspaces the user is a member of
+	ListByUserID(ctx context.Context, userID string) ([]*workspace.Workspace, error)
+}
@@ -2,22 +2,47 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"collaborative-editor/internal/handlers"
 	"collaborative-editor/internal/middleware"
 )
 
+// Rate limit policies, keyed by user ID (or client IP when anonymous)
+// All routes with the same policy draw from one budget, so spreading requests across them does not raise the limit
+var (
+	// signupPolicy limits account creation per IP
+	signupPolicy = middleware.RateLimitPolicy{Name: "signup", Requests: 5, Period: time.Hour, Burst: 3}
+	// loginPolicy limits credential and token checks
+	loginPolicy = middleware.RateLimitPolicy{Name: "login", Requests: 20, Period: time.Minute, Burst: 10}
+	// emailPolicy limits endpoints that send email
+	emailPolicy = middleware.RateLimitPolicy{Name: "email", Requests: 5, Period: time.Hour, Burst: 3}
+	// sensitivePolicy limits account security changes
+	sensitivePolicy = middleware.RateLimitPolicy{Name: "sensitive", Requests: 10, Period: time.Minute, Burst: 5}
+	// createPolicy limits creation of new resources
+	createPolicy = middleware.RateLimitPolicy{Name: "create", Requests: 30, Period: time.Minute, Burst: 10}
+	// writePolicy limits updates; the editor autosaves, so it allows frequent writes
+	writePolicy = middleware.RateLimitPolicy{Name: "write", Requests: 120, Period: time.Minute, Burst: 30}
+	// readPolicy limits reads
+	readPolicy = middleware.RateLimitPolicy{Name: "read", Requests: 300, Period: time.Minute, Burst: 60}
+	// searchPolicy limits search, which is called on every keystroke but must not allow enumerating users
+	searchPolicy = middleware.RateLimitPolicy{Name: "search", Requests: 60, Period: time.Minute, Burst: 20}
+	// wsConnectPolicy limits WebSocket connection attempts
+	wsConnectPolicy = middleware.RateLimitPolicy{Name: "ws_connect", Requests: 30, Period: time.Minute, Burst: 10}
+)
+
 // SetupRoutes configures all application routes
-func SetupRoutes(userHandler *handlers.UserHandler, textHandler *handlers.TextHandler, docHandler *handlers.DocumentHandler, wsHandler *handlers.WebSocketHandler) {
+// oidcHandler may be nil when single sign-on is not configured
+func SetupRoutes(userHandler *handlers.UserHandler, oidcHandler *handlers.OIDCHandler, tokenHandler *handlers.APITokenHandler, exportHandler *handlers.ExportHandler, textHandler *handlers.TextHandler, docHandler *handlers.DocumentHandler, folderHandler *handlers.FolderHandler, workspaceHandler *handlers.WorkspaceHandler, searchHandler *handlers.SearchHandler, attachmentHandler *handlers.AttachmentHandler, webhookHandler *handlers.WebhookHandler, wsHandler *handlers.WebSocketHandler) {
 	// ============================================
 	// Public Routes
 	// ============================================
-	setupPublicRoutes(userHandler)
+	setupPublicRoutes(userHandler, oidcHandler, exportHandler)
 
 	// ============================================
-	// Protected Routes (require JWT authentication)
+	// Protected Routes (require JWT or personal access token authentication)
 	// ============================================
-	setupProtectedRoutes(userHandler, textHandler, docHandler)
+	setupProtectedRoutes(userHandler, tokenHandler, exportHandler, textHandler, docHandler, folderHandler, workspaceHandler, searchHandler, attachmentHandler, webhookHandler)
 
 	// ============================================
 	// WebSocket Routes
@@ -37,42 +62,195 @@ func registerOPTIONS(paths ...string) {
 	}
 }
 
+// limiters holds the one rate limiter of each policy, created when a route first uses it
+var limiters = map[middleware.RateLimitPolicy]*middleware.RateLimiter{}
+
+// limiter returns the rate limiter shared by every route with a policy
+func limiter(policy middleware.RateLimitPolicy) *middleware.RateLimiter {
+	l, ok := limiters[policy]
+	if !ok {
+		l = middleware.NewRateLimiter(policy)
+		limiters[policy] = l
+	}
+	return l
+}
+
+// public wraps a handler with CORS and its policy's per-IP rate limiter
+func public(policy middleware.RateLimitPolicy, handler http.HandlerFunc) http.Handler {
+	return middleware.CORSMiddleware(limiter(policy).Middleware(handler))
+}
+
+// protected wraps a handler with CORS, JWT authentication and its policy's per-user rate limiter
+func protected(policy middleware.RateLimitPolicy, handler http.HandlerFunc) http.Handler {
+	return middleware.CORSMiddleware(middleware.AuthMiddleware(limiter(policy).Middleware(handler)))
+}
+
 // setupPublicRoutes configures public (unauthenticated) routes
-func setupPublicRoutes(userHandler *handlers.UserHandler) {
+func setupPublicRoutes(userHandler *handlers.UserHandler, oidcHandler *handlers.OIDCHandler, exportHandler *handlers.ExportHandler) {
 	// User authentication routes
-	http.Handle("/signup", middleware.CORSMiddleware(http.HandlerFunc(userHandler.Signup)))
-	http.Handle("/login", middleware.CORSMiddleware(http.HandlerFunc(userHandler.Login)))
+	http.Handle("/signup", public(signupPolicy, userHandler.Signup))
+	http.Handle("/login", public(loginPolicy, userHandler.Login))
+
+	// Second login step for accounts with two-factor authentication
+	registerOPTIONS("/login/mfa")
+	http.Handle("POST /login/mfa", public(loginPolicy, userHandler.LoginMFA))
+
+	// Password reset routes
+	registerOPTIONS("/password/reset", "/password/reset/confirm")
+	http.Handle("POST /password/reset", public(emailPolicy, userHandler.RequestPasswordReset))
+	http.Handle("POST /password/reset/confirm", public(loginPolicy, userHandler.ResetPassword))
+
+	// Email verification link
+	http.Handle("GET /verify-email", public(loginPolicy, userHandler.VerifyEmail))
+
+	// Export download link (authorized by the signed token in the link)
+	http.Handle("GET /exports/{id}/download", public(loginPolicy, exportHandler.DownloadExport))
+
+	// OpenID Connect single sign-on routes (browser redirects, no CORS needed)
+	if oidcHandler != nil {
+		http.Handle("GET /auth/oidc/login", limiter(loginPolicy).Middleware(http.HandlerFunc(oidcHandler.Login)))
+		http.Handle("GET /auth/oidc/callback", limiter(loginPolicy).Middleware(http.HandlerFunc(oidcHandler.Callback)))
+	}
 }
 
 // setupProtectedRoutes configures protected (authenticated) routes
-func setupProtectedRoutes(userHandler *handlers.UserHandler, textHandler *handlers.TextHandler, docHandler *handlers.DocumentHandler) {
+func setupProtectedRoutes(userHandler *handlers.UserHandler, tokenHandler *handlers.APITokenHandler, exportHandler *handlers.ExportHandler, textHandler *handlers.TextHandler, docHandler *handlers.DocumentHandler, folderHandler *handlers.FolderHandler, workspaceHandler *handlers.WorkspaceHandler, searchHandler *handlers.SearchHandler, attachmentHandler *handlers.AttachmentHandler, webhookHandler *handlers.WebhookHandler) {
 	// User routes
-	http.Handle("/getUser", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(userHandler.GetUserHandler))))
-	http.Handle("/protected", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(handlers.ProtectedHandler))))
-	http.Handle("/logout", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(userHandler.LogoutHandler))))
+	http.Handle("/getUser", protected(readPolicy, userHandler.GetUserHandler))
+	http.Handle("/protected", protected(readPolicy, handlers.ProtectedHandler))
+	http.Handle("/logout", protected(writePolicy, userHandler.LogoutHandler))
+
+	// Profile and account routes
+	registerOPTIONS("/me")
+	http.Handle("GET /me", protected(readPolicy, userHandler.GetProfile))
+	http.Handle("PATCH /me", protected(sensitivePolicy, userHandler.UpdateProfile))
+	http.Handle("DELETE /me", protected(sensitivePolicy, userHandler.DeleteAccount))
+
+	// User directory for picking collaborators
+	registerOPTIONS("/users/search")
+	http.Handle("GET /users/search", protected(searchPolicy, userHandler.SearchUsers))
+
+	registerOPTIONS("/password/change", "/verify-email/resend")
+	http.Handle("POST /password/change", protected(sensitivePolicy, userHandler.ChangePassword))
+	http.Handle("POST /verify-email/resend", protected(emailPolicy, userHandler.ResendVerificationEmail))
+
+	// Two-factor authentication routes
+	registerOPTIONS("/2fa/enroll", "/2fa/verify", "/2fa/disable", "/2fa/recovery-codes")
+	http.Handle("POST /2fa/enroll", protected(sensitivePolicy, userHandler.EnrollTwoFactor))
+	http.Handle("POST /2fa/verify", protected(sensitivePolicy, userHandler.VerifyTwoFactor))
+	http.Handle("POST /2fa/disable", protected(sensitivePolicy, userHandler.DisableTwoFactor))
+	http.Handle("POST /2fa/recovery-codes", protected(sensitivePolicy, userHandler.RegenerateRecoveryCodes))
+
+	// Personal access token routes
+	registerOPTIONS("/tokens", "/tokens/{id}")
+	http.Handle("POST /tokens", protected(sensitivePolicy, tokenHandler.CreateToken))
+	http.Handle("GET /tokens", protected(readPolicy, tokenHandler.ListTokens))
+	http.Handle("DELETE /tokens/{id}", protected(sensitivePolicy, tokenHandler.RevokeToken))
+
+	// Personal data export routes
+	registerOPTIONS("/exports", "/exports/{id}")
+	http.Handle("POST /exports", protected(emailPolicy, exportHandler.RequestExport))
+	http.Handle("GET /exports/{id}", protected(readPolicy, exportHandler.GetExport))
 
 	// Text routes
-	http.Handle("/saveText", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(textHandler.SaveText))))
-	http.Handle("/getText", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(textHandler.GetText))))
+	http.Handle("/saveText", protected(writePolicy, textHandler.SaveText))
+	http.Handle("/getText", protected(readPolicy, textHandler.GetText))
 
 	// Document routes
 	// Using Go 1.22+ routing patterns for method and path matching
 	// Register OPTIONS handlers for CORS preflight
-	registerOPTIONS("/documents", "/documents/{id}", "/documents/{id}/collaborators")
-
-	http.Handle("POST /documents", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(docHandler.CreateDocument))))
-	http.Handle("GET /documents", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(docHandler.ListDocuments))))
-	http.Handle("GET /documents/{id}", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(docHandler.GetDocument))))
-	http.Handle("PUT /documents/{id}", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(docHandler.UpdateDocument))))
-	http.Handle("DELETE /documents/{id}", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(docHandler.DeleteDocument))))
-	http.Handle("POST /documents/{id}/collaborators", middleware.CORSMiddleware(middleware.AuthMiddleware(http.HandlerFunc(docHandler.AddCollaborator))))
+	registerOPTIONS("/documents", "/documents/{id}", "/documents/{id}/collaborators", "/documents/{id}/collaborators/{userId}", "/documents/{id}/workspace", "/documents/{id}/folder", "/documents/{id}/outline")
+
+	http.Handle("POST /documents", protected(createPolicy, docHandler.CreateDocument))
+	http.Handle("GET /documents", protected(readPolicy, docHandler.ListDocuments))
+	http.Handle("GET /documents/{id}", protected(readPolicy, docHandler.GetDocument))
+	http.Handle("GET /documents/{id}/outline", protected(readPolicy, docHandler.GetDocumentOutline))
+	http.Handle("PUT /documents/{id}", protected(writePolicy, docHandler.UpdateDocument))
+	http.Handle("DELETE /documents/{id}", protected(writePolicy, docHandler.DeleteDocument))
+	http.Handle("POST /documents/{id}/collaborators", protected(writePolicy, docHandler.AddCollaborator))
+	http.Handle("DELETE /documents/{id}/collaborators/{userId}", protected(writePolicy, docHandler.RemoveCollaborator))
+	http.Handle("PUT /documents/{id}/workspace", protected(writePolicy, docHandler.MoveDocument))
+	http.Handle("PUT /documents/{id}/folder", protected(writePolicy, docHandler.MoveDocumentToFolder))
+
+	// Tags and favorites
+	// Favorites only change the user's own list, so they need read access (and the read scope) only
+	registerOPTIONS("/documents/{id}/tags", "/documents/{id}/favorite", "/documents/favorites", "/tags", "/tags/rename", "/tags/merge")
+	http.Handle("PUT /documents/{id}/tags", protected(writePolicy, docHandler.SetTags))
+	http.Handle("GET /documents/favorites", protected(readPolicy, docHandler.ListFavorites))
+	http.Handle("PUT /documents/favorites", protected(writePolicy, docHandler.ReorderFavorites))
+	http.Handle("POST /documents/{id}/favorite", protected(writePolicy, docHandler.AddFavorite))
+	http.Handle("DELETE /documents/{id}/favorite", protected(writePolicy, docHandler.RemoveFavorite))
+	http.Handle("GET /tags", protected(readPolicy, docHandler.ListTags))
+	http.Handle("POST /tags/rename", protected(writePolicy, docHandler.RenameTag))
+	http.Handle("POST /tags/merge", protected(writePolicy, docHandler.MergeTags))
+
+	// Trash
+	registerOPTIONS("/trash", "/trash/{id}", "/documents/{id}/restore")
+	http.Handle("GET /trash", protected(readPolicy, docHandler.ListTrash))
+	http.Handle("DELETE /trash/{id}", protected(writePolicy, docHandler.PurgeDocument))
+	http.Handle("POST /documents/{id}/restore", protected(writePolicy, docHandler.RestoreDocument))
+
+	// Duplication and templates
+	registerOPTIONS("/documents/{id}/duplicate", "/documents/{id}/template", "/templates")
+	http.Handle("POST /documents/{id}/duplicate", protected(createPolicy, docHandler.DuplicateDocument))
+	http.Handle("PUT /documents/{id}/template", protected(writePolicy, docHandler.SetTemplate))
+	http.Handle("GET /templates", protected(readPolicy, docHandler.ListTemplates))
+
+	// Import and export in other formats
+	registerOPTIONS("/documents/{id}/export", "/documents/import")
+	http.Handle("GET /documents/{id}/export", protected(readPolicy, docHandler.ExportDocument))
+	http.Handle("POST /documents/import", protected(createPolicy, docHandler.ImportDocument))
+
+	// Images and files attached to a document
+	registerOPTIONS("/documents/{id}/attachments", "/documents/{id}/attachments/{attachmentId}")
+	http.Handle("POST /documents/{id}/attachments", protected(createPolicy, attachmentHandler.UploadAttachment))
+	http.Handle("GET /documents/{id}/attachments", protected(readPolicy, attachmentHandler.ListAttachments))
+	http.Handle("GET /documents/{id}/attachments/{attachmentId}", protected(readPolicy, attachmentHandler.DownloadAttachment))
+	http.Handle("DELETE /documents/{id}/attachments/{attachmentId}", protected(writePolicy, attachmentHandler.DeleteAttachment))
+
+	// Webhooks on a document or a workspace, with their delivery log
+	registerOPTIONS("/webhooks", "/webhooks/{id}", "/webhooks/{id}/deliveries", "/webhooks/{id}/ping")
+	http.Handle("POST /webhooks", protected(createPolicy, webhookHandler.CreateWebhook))
+	http.Handle("GET /webhooks", protected(readPolicy, webhookHandler.ListWebhooks))
+	http.Handle("GET /webhooks/{id}", protected(readPolicy, webhookHandler.GetWebhook))
+	http.Handle("PATCH /webhooks/{id}", protected(writePolicy, webhookHandler.UpdateWebhook))
+	http.Handle("DELETE /webhooks/{id}", protected(writePolicy, webhookHandler.DeleteWebhook))
+	http.Handle("GET /webhooks/{id}/deliveries", protected(readPolicy, webhookHandler.ListDeliveries))
+	http.Handle("POST /webhooks/{id}/ping", protected(sensitivePolicy, webhookHandler.PingWebhook))
+
+	// Folder routes
+	registerOPTIONS("/folders", "/folders/{id}", "/folders/{id}/parent", "/folders/{id}/collaborators", "/folders/{id}/collaborators/{userId}")
+	http.Handle("POST /folders", protected(createPolicy, folderHandler.CreateFolder))
+	http.Handle("GET /folders", protected(readPolicy, folderHandler.ListRoot))
+	http.Handle("GET /folders/{id}", protected(readPolicy, folderHandler.GetFolder))
+	http.Handle("PATCH /folders/{id}", protected(writePolicy, folderHandler.RenameFolder))
+	http.Handle("DELETE /folders/{id}", protected(writePolicy, folderHandler.DeleteFolder))
+	http.Handle("PUT /folders/{id}/parent", protected(writePolicy, folderHandler.MoveFolder))
+	http.Handle("POST /folders/{id}/collaborators", protected(writePolicy, folderHandler.AddCollaborator))
+	http.Handle("DELETE /folders/{id}/collaborators/{userId}", protected(writePolicy, folderHandler.RemoveCollaborator))
+
+	// Full-text search across the documents the user can access
+	registerOPTIONS("/search")
+	http.Handle("GET /search", protected(searchPolicy, searchHandler.Search))
+
+	// Workspace routes
+	registerOPTIONS("/workspaces", "/workspaces/{id}", "/workspaces/{id}/members", "/workspaces/{id}/members/{userId}")
+	http.Handle("POST /workspaces", protected(createPolicy, workspaceHandler.CreateWorkspace))
+	http.Handle("GET /workspaces", protected(readPolicy, workspaceHandler.ListWorkspaces))
+	http.Handle("GET /workspaces/{id}", protected(readPolicy, workspaceHandler.GetWorkspace))
+	http.Handle("PATCH /workspaces/{id}", protected(writePolicy, workspaceHandler.UpdateWorkspace))
+	http.Handle("DELETE /workspaces/{id}", protected(writePolicy, workspaceHandler.DeleteWorkspace))
+	http.Handle("POST /workspaces/{id}/members", protected(writePolicy, workspaceHandler.AddMember))
+	http.Handle("PATCH /workspaces/{id}/members/{userId}", protected(writePolicy, workspaceHandler.UpdateMember))
+	http.Handle("DELETE /workspaces/{id}/members/{userId}", protected(writePolicy, workspaceHandler.RemoveMember))
 }
 
 // setupWebSocketRoutes configures WebSocket routes
 func setupWebSocketRoutes(wsHandler *handlers.WebSocketHandler) {
 	// WebSocket endpoint for real-time collaboration
 	// Note: WebSocket upgrade doesn't work well with AuthMiddleware, so we handle auth inside the handler
-	http.Handle("GET /ws/documents/{id}", middleware.CORSMiddleware(http.HandlerFunc(wsHandler.HandleWebSocket)))
+	// Concurrent connections per user are capped inside the handler
+	http.Handle("GET /ws/documents/{id}", public(wsConnectPolicy, wsHandler.HandleWebSocket))
 }
 
 // setupSystemRoutes configures system-level routes
@@ -0,0 +1,80 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+const (
+	// snippetLength is the approximate length of a snippet in bytes
+	snippetLength = 200
+	// snippetLeadIn is how much text is shown before the first match
+	snippetLeadIn = 60
+)
+
+// highlight escapes text and wraps the matched tokens in <mark>
+// matches must be ordered by position
+func highlight(text string, tokens []token, matches []match) string {
+	return highlightRange(text, tokens, matches, 0, len(text))
+}
+
+// snippet returns an excerpt of text around the first match, highlighted
+// Without matches it returns the beginning of the text
+func snippet(text string, tokens []token, matches []match) string {
+	if text == "" {
+		return ""
+	}
+
+	from := 0
+	if len(matches) > 0 {
+		from = max(0, tokens[matches[0].start].start-snippetLeadIn)
+	}
+	to := min(len(text), from+snippetLength)
+	if to == len(text) {
+		from = max(0, to-snippetLength)
+	}
+
+	// Snap to word boundaries so words are not cut in half
+	from = runeBoundary(text, from)
+	if from > 0 {
+		if space := strings.IndexAny(text[from:], " \n"); space >= 0 && from+space < to {
+			from += space + 1
+		}
+	}
+	to = runeBoundary(text, to)
+	if to < len(text) {
+		if space := strings.LastIndexAny(text[from:to], " \n"); space > 0 {
+			to = from + space
+		}
+	}
+
+	excerpt := highlightRange(text, tokens, matches, from, to)
+	excerpt = strings.ReplaceAll(excerpt, "\n", " ")
+	if from > 0 {
+		excerpt = "…" + excerpt
+	}
+	if to < len(text) {
+		excerpt += "…"
+	}
+	return excerpt
+}
+
+// highlightRange escapes text[from:to] and wraps the matches inside it in <mark>
+func highlightRange(text string, tokens []token, matches []match, from, to int) string {
+	var b strings.Builder
+	pos := from
+	for _, m := range matches {
+		start := tokens[m.start].start
+		end := tokens[m.start+m.count-1].end
+		if start < pos || end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[start:end]))
+		b.WriteString("</mark>")
+		pos = end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+	return b.String()
+}
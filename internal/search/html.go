@@ -0,0 +1,33 @@
+package search
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	// blockTagRegex matches tags that separate blocks of text, which become line breaks
+	blockTagRegex = regexp.MustCompile(`(?i)<\s*(br|/p|/h[1-6]|/li|/div|/blockquote|/pre|/tr)\b[^>]*>`)
+	// tagRegex matches any other tag
+	tagRegex = regexp.MustCompile(`<[^>]*>`)
+	// spaceRegex matches runs of whitespace within a line
+	spaceRegex = regexp.MustCompile(`[ \t\f\v\r]+`)
+)
+
+// TextFromHTML extracts the plain text of editor HTML for indexing
+func TextFromHTML(content string) string {
+	text := blockTagRegex.ReplaceAllString(content, "\n")
+	text = tagRegex.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = spaceRegex.ReplaceAllString(text, " ")
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
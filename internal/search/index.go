@@ -0,0 +1,99 @@
+package search
+
+import (
+	"strings"
+)
+
+// Document is the searchable text of a document
+type Document struct {
+	ID    string
+	Title string
+	Body  string // Plain text; see TextFromHTML
+}
+
+// Hit is a document matching a query
+type Hit struct {
+	ID             string
+	Score          float64
+	TitleHighlight string // HTML-escaped title with matches wrapped in <mark>
+	Snippet        string // HTML-escaped excerpt of the body around the first match, with matches wrapped in <mark>
+}
+
+// Index is a full-text index of documents
+// Implementations must be safe for concurrent use
+type Index interface {
+	// Index adds a document, replacing any previous version
+	Index(doc Document) error
+	// Remove deletes a document from the index
+	Remove(id string) error
+	// Search returns the documents matching every clause of the query, best first
+	Search(query Query) ([]Hit, error)
+}
+
+// Clause is one part of a query: a single term or a phrase of consecutive terms
+type Clause struct {
+	Terms  []string
+	Prefix bool // The last term matches any term it is a prefix of
+}
+
+// Query is a parsed search query; documents must match all of its clauses
+type Query struct {
+	Clauses []Clause
+}
+
+// IsEmpty reports whether the query has nothing to search for
+func (q Query) IsEmpty() bool {
+	return len(q.Clauses) == 0
+}
+
+// ParseQuery parses a search query
+// Words are ANDed together, "quoted text" matches a phrase, and a trailing * makes a
+// word (or the last word of a phrase) a prefix. Words that split into several terms,
+// like "real-time", are matched as phrases
+func ParseQuery(raw string) Query {
+	var query Query
+	add := func(text string, prefix bool) {
+		terms := []string{}
+		for _, t := range tokenize(text) {
+			terms = append(terms, t.term)
+		}
+		if len(terms) > 0 {
+			query.Clauses = append(query.Clauses, Clause{Terms: terms, Prefix: prefix})
+		}
+	}
+
+	for raw != "" {
+		raw = strings.TrimLeft(raw, " \t\r\n")
+		if raw == "" {
+			break
+		}
+
+		if raw[0] == '"' {
+			end := strings.IndexByte(raw[1:], '"')
+			if end < 0 {
+				// Unterminated quote: treat the rest as a phrase
+				end = len(raw) - 1
+			}
+			phrase := raw[1 : end+1]
+			raw = raw[min(end+2, len(raw)):]
+
+			prefix := strings.HasSuffix(phrase, "*")
+			if !prefix && strings.HasPrefix(raw, "*") {
+				prefix = true
+				raw = raw[1:]
+			}
+			add(strings.TrimSuffix(phrase, "*"), prefix)
+			continue
+		}
+
+		end := strings.IndexAny(raw, " \t\r\n\"")
+		if end < 0 {
+			end = len(raw)
+		}
+		word := raw[:end]
+		raw = raw[end:]
+		add(strings.TrimSuffix(word, "*"), strings.HasSuffix(word, "*"))
+	}
+
+	return query
+}
@@ -0,0 +1,290 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// titleBoost weighs title matches above body matches
+	titleBoost = 3.0
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// field identifies the part of a document a term occurs in
+type field int
+
+const (
+	fieldTitle field = iota
+	fieldBody
+)
+
+// indexedDocument holds the tokens of a document, kept for phrase matching and highlighting
+type indexedDocument struct {
+	title, body   string
+	titleTokens   []token
+	bodyTokens    []token
+	weightedCount float64 // Number of terms, with title terms boosted
+}
+
+// tokens returns the tokens of a field
+func (d *indexedDocument) tokens(f field) []token {
+	if f == fieldTitle {
+		return d.titleTokens
+	}
+	return d.bodyTokens
+}
+
+// positions lists where a term occurs in each field of a document
+type positions [2][]int
+
+// MemoryIndex is an in-memory inverted index
+// It holds every document in memory, which suits up to tens of thousands of documents
+type MemoryIndex struct {
+	mu          sync.RWMutex
+	docs        map[string]*indexedDocument
+	postings    map[string]map[string]*positions // term -> document ID -> positions
+	totalWeight float64                          // Sum of weightedCount, for the average document length
+}
+
+// NewMemoryIndex creates an empty in-memory index
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{
+		docs:     make(map[string]*indexedDocument),
+		postings: make(map[string]map[string]*positions),
+	}
+}
+
+// Index adds a document, replacing any previous version
+func (idx *MemoryIndex) Index(doc Document) error {
+	indexed := &indexedDocument{
+		title:       doc.Title,
+		body:        doc.Body,
+		titleTokens: tokenize(doc.Title),
+		bodyTokens:  tokenize(doc.Body),
+	}
+	indexed.weightedCount = titleBoost*float64(len(indexed.titleTokens)) + float64(len(indexed.bodyTokens))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(doc.ID)
+
+	idx.docs[doc.ID] = indexed
+	idx.totalWeight += indexed.weightedCount
+	for _, f := range []field{fieldTitle, fieldBody} {
+		for i, t := range indexed.tokens(f) {
+			docs, ok := idx.postings[t.term]
+			if !ok {
+				docs = make(map[string]*positions)
+				idx.postings[t.term] = docs
+			}
+			p, ok := docs[doc.ID]
+			if !ok {
+				p = &positions{}
+				docs[doc.ID] = p
+			}
+			p[f] = append(p[f], i)
+		}
+	}
+
+	return nil
+}
+
+// Remove deletes a document from the index
+func (idx *MemoryIndex) Remove(id string) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	return nil
+}
+
+// remove deletes a document; the caller must hold the write lock
+func (idx *MemoryIndex) remove(id string) {
+	indexed, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+
+	for _, f := range []field{fieldTitle, fieldBody} {
+		for _, t := range indexed.tokens(f) {
+			if docs, ok := idx.postings[t.term]; ok {
+				delete(docs, id)
+				if len(docs) == 0 {
+					delete(idx.postings, t.term)
+				}
+			}
+		}
+	}
+
+	idx.totalWeight -= indexed.weightedCount
+	delete(idx.docs, id)
+}
+
+// match is a run of matched tokens in a field
+type match struct {
+	field field
+	start int // Token index
+	count int // Number of tokens
+}
+
+// Search returns the documents matching every clause of the query, ranked with BM25
+func (idx *MemoryIndex) Search(query Query) ([]Hit, error) {
+	if query.IsEmpty() {
+		return []Hit{}, nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	// Match each clause against every document containing its first term,
+	// which also gives each clause's document frequency for ranking
+	clauseMatches := make([]map[string][]match, len(query.Clauses))
+	for i, clause := range query.Clauses {
+		clauseMatches[i] = idx.matchClause(clause)
+		if len(clauseMatches[i]) == 0 {
+			return []Hit{}, nil
+		}
+	}
+
+	total := float64(len(idx.docs))
+	avgWeight := idx.totalWeight / math.Max(total, 1)
+
+	hits := []Hit{}
+	for id := range clauseMatches[0] {
+		var matches []match
+		score := 0.0
+		for i := range query.Clauses {
+			clauseMatch, ok := clauseMatches[i][id]
+			if !ok {
+				matches = nil
+				break
+			}
+			matches = append(matches, clauseMatch...)
+
+			tf := 0.0
+			for _, m := range clauseMatch {
+				if m.field == fieldTitle {
+					tf += titleBoost
+				} else {
+					tf++
+				}
+			}
+			df := float64(len(clauseMatches[i]))
+			idf := math.Log(1 + (total-df+0.5)/(df+0.5))
+			norm := bm25K1 * (1 - bm25B + bm25B*idx.docs[id].weightedCount/math.Max(avgWeight, 1))
+			score += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+		if matches == nil {
+			continue
+		}
+
+		doc := idx.docs[id]
+		hits = append(hits, Hit{
+			ID:             id,
+			Score:          score,
+			TitleHighlight: highlight(doc.title, doc.titleTokens, matchesIn(matches, fieldTitle)),
+			Snippet:        snippet(doc.body, doc.bodyTokens, matchesIn(matches, fieldBody)),
+		})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	return hits, nil
+}
+
+// matchClause finds where a clause matches in each document
+func (idx *MemoryIndex) matchClause(clause Clause) map[string][]match {
+	// Terms the clause's words can match; only the last one may be a prefix
+	alternatives := make([][]string, len(clause.Terms))
+	for i, term := range clause.Terms {
+		if clause.Prefix && i == len(clause.Terms)-1 {
+			alternatives[i] = idx.expandPrefix(term)
+		} else if _, ok := idx.postings[term]; ok {
+			alternatives[i] = []string{term}
+		}
+		if len(alternatives[i]) == 0 {
+			return nil
+		}
+	}
+
+	// Candidate documents contain the first term
+	candidates := map[string]bool{}
+	for _, term := range alternatives[0] {
+		for id := range idx.postings[term] {
+			candidates[id] = true
+		}
+	}
+
+	results := map[string][]match{}
+	for id := range candidates {
+		var matches []match
+		for _, f := range []field{fieldTitle, fieldBody} {
+			// Positions of each word of the clause in this field
+			wordPositions := make([]map[int]bool, len(alternatives))
+			for i, terms := range alternatives {
+				wordPositions[i] = map[int]bool{}
+				for _, term := range terms {
+					if p, ok := idx.postings[term][id]; ok {
+						for _, pos := range p[f] {
+							wordPositions[i][pos] = true
+						}
+					}
+				}
+			}
+
+			for start := range wordPositions[0] {
+				matched := true
+				for i := 1; i < len(wordPositions); i++ {
+					if !wordPositions[i][start+i] {
+						matched = false
+						break
+					}
+				}
+				if matched {
+					matches = append(matches, match{field: f, start: start, count: len(alternatives)})
+				}
+			}
+		}
+		if len(matches) > 0 {
+			results[id] = matches
+		}
+	}
+
+	return results
+}
+
+// expandPrefix returns the indexed terms starting with prefix
+func (idx *MemoryIndex) expandPrefix(prefix string) []string {
+	var terms []string
+	for term := range idx.postings {
+		if strings.HasPrefix(term, prefix) {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// matchesIn returns the matches in a field, ordered by position
+func matchesIn(matches []match, f field) []match {
+	var inField []match
+	for _, m := range matches {
+		if m.field == f {
+			inField = append(inField, m)
+		}
+	}
+	sort.Slice(inField, func(i, j int) bool {
+		return inField[i].start < inField[j].start
+	})
+	return inField
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/events"
+	"collaborative-editor/internal/mail"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/internal/validation"
+	"collaborative-editor/pkg/user"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var localeRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+const (
+	// userSearchMinLength is the shortest query that is searched
+	userSearchMinLength = 2
+	// userSearchDefaultLimit and userSearchMaxLimit bound the number of search results
+	userSearchDefaultLimit = 10
+	userSearchMaxLimit     = 25
+)
+
+// ProfileResponse represents the profile of the logged-in user
+type ProfileResponse struct {
+	ID               string           `json:"id"`
+	Username         string           `json:"username"`
+	Email            string           `json:"email"`
+	Verified         bool             `json:"verified"`
+	DisplayName      string           `json:"display_name"`
+	AvatarURL        string           `json:"avatar_url"`
+	Preferences      user.Preferences `json:"preferences"`
+	TwoFactorEnabled bool             `json:"two_factor_enabled"`
+	CreatedAt        string           `json:"created_at"`
+	UpdatedAt        string           `json:"updated_at"`
+}
+
+// UpdateProfileRequest represents a partial profile update; omitted fields are left unchanged
+type UpdateProfileRequest struct {
+	Username        *string             `json:"username"`
+	Email           *string             `json:"email"`
+	CurrentPassword string              `json:"current_password"` // Required to change the email of an account with a password
+	DisplayName     *string             `json:"display_name"`
+	AvatarURL       *string             `json:"avatar_url"`
+	Preferences     *PreferencesRequest `json:"preferences"`
+}
+
+// PreferencesRequest represents a partial preferences update
+type PreferencesRequest struct {
+	Theme              *string `json:"theme"`
+	Locale             *string `json:"locale"`
+	EmailNotifications *bool   `json:"email_notifications"`
+	Discoverable       *bool   `json:"discoverable"`
+}
+
+// UserSearchResult represents a user found by search, with only the fields needed to pick a collaborator
+type UserSearchResult struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Email       string `json:"email"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+// DeleteAccountRequest represents a request to delete the logged-in user's account
+type DeleteAccountRequest struct {
+	Password        string `json:"password"`          // Required if the account has a password
+	Code            string `json:"code"`              // TOTP or recovery code, required if 2FA is enabled
+	DocumentPolicy  string `json:"document_policy"`   // "delete" or "transfer"
+	TransferToEmail string `json:"transfer_to_email"` // Optional new owner for the transfer policy
+	IP              string `json:"-"`                 // Client IP, set by the handler
+}
+
+// GetProfile returns the profile of a user
+func (s *UserService) GetProfile(ctx context.Context, userID string) (*ProfileResponse, error) {
+	u, err := s.getUserForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return toProfileResponse(u), nil
+}
+
+// UpdateProfile applies a partial update to the profile of a user
+// Changing the email marks the account unverified and sends a new verification link
+func (s *UserService) UpdateProfile(ctx context.Context, userID string, req *UpdateProfileRequest) (*ProfileResponse, error) {
+	u, err := s.getUserForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Username != nil {
+		username := strings.TrimSpace(*req.Username)
+		if username != u.Username {
+			if err := validation.ValidateUsername(username); err != nil {
+				return nil, errors.WrapError(errors.ErrInvalidInput, err)
+			}
+			if existing, err := s.userRepo.GetByUsername(ctx, username); err == nil && existing != nil && existing.ID != u.ID {
+				return nil, errors.NewAppError(errors.ErrConflict.Code, "User with this username already exists", nil)
+			}
+			u.Username = username
+		}
+	}
+
+	previousEmail := u.Email
+	if req.Email != nil {
+		email := strings.TrimSpace(strings.ToLower(*req.Email))
+		if email != u.Email {
+			if err := validation.ValidateEmail(email); err != nil {
+				return nil, errors.WrapError(errors.ErrInvalidInput, err)
+			}
+			if u.PasswordHash != "" {
+				if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(strings.TrimSpace(req.CurrentPassword))); err != nil {
+					return nil, errors.NewAppError(errors.ErrUnauthorized.Code, "Current password is required to change the email", nil)
+				}
+			}
+			if existing, err := s.userRepo.GetByEmail(ctx, email); err == nil && existing != nil && existing.ID != u.ID {
+				return nil, errors.NewAppError(errors.ErrConflict.Code, "User with this email already exists", nil)
+			}
+			u.Email = email
+			u.Verified = false
+		}
+	}
+
+	if req.DisplayName != nil {
+		displayName := strings.TrimSpace(*req.DisplayName)
+		if err := validation.ValidateDisplayName(displayName); err != nil {
+			return nil, errors.WrapError(errors.ErrInvalidInput, err)
+		}
+		u.DisplayName = displayName
+	}
+
+	if req.AvatarURL != nil {
+		avatarURL := strings.TrimSpace(*req.AvatarURL)
+		if err := validation.ValidateAvatarURL(avatarURL); err != nil {
+			return nil, errors.WrapError(errors.ErrInvalidInput, err)
+		}
+		u.AvatarURL = avatarURL
+	}
+
+	if req.Preferences != nil {
+		if err := applyPreferences(&u.Preferences, req.Preferences); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.saveUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	if u.Email != previousEmail {
+		if err := s.sendVerificationEmail(ctx, u); err != nil {
+			log.Printf("Failed to send verification email to user %s: %v", u.ID, err)
+		}
+		s.sendEmailChangedNotice(ctx, u, previousEmail)
+	}
+
+	return toProfileResponse(u), nil
+}
+
+// DeleteAccount deletes a user after confirming their credentials
+// Owned documents and folders are deleted or transferred according to the chosen policy, the user is
+// removed from all shared documents, folders and workspaces, and every session and access token is revoked
+func (s *UserService) DeleteAccount(ctx context.Context, userID string, req *DeleteAccountRequest) (*MessageResponse, error) {
+	if s.docService == nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("document service not configured"))
+	}
+
+	u, err := s.getUserForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(strings.TrimSpace(req.Password))); err != nil {
+			return nil, errors.NewAppError(errors.ErrUnauthorized.Code, "Password is incorrect", nil)
+		}
+	}
+	if u.TOTP.Enabled {
+		if err := s.verifySecondFactor(ctx, u, req.IP, func() bool { return s.checkSecondFactor(u, req.Code) }); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := strings.TrimSpace(strings.ToLower(req.DocumentPolicy))
+	if policy != DocumentPolicyDelete && policy != DocumentPolicyTransfer {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Document policy must be \"delete\" or \"transfer\"", nil)
+	}
+
+	transferToID := ""
+	if email := strings.TrimSpace(strings.ToLower(req.TransferToEmail)); email != "" {
+		if policy != DocumentPolicyTransfer {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "transfer_to_email requires the transfer policy", nil)
+		}
+		newOwner, err := s.userRepo.GetByEmail(ctx, email)
+		if err != nil || newOwner.ID == u.ID {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "User not found with this email", nil)
+		}
+		transferToID = newOwner.ID
+	}
+
+	// Documents go first, since workspace documents are handed to other members of their workspace
+	if err := s.docService.ReleaseUserDocuments(ctx, u.ID, policy, transferToID); err != nil {
+		return nil, err
+	}
+	if s.folders != nil {
+		if err := s.folders.ReleaseUserFolders(ctx, u.ID, policy, transferToID); err != nil {
+			return nil, err
+		}
+	}
+	if s.workspaces != nil {
+		if err := s.workspaces.RemoveUserFromWorkspaces(ctx, u.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.apiTokenRepo != nil {
+		if err := s.apiTokenRepo.DeleteByUserID(ctx, u.ID); err != nil {
+			return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to delete access tokens: %w", err))
+		}
+	}
+	for _, purpose := range []string{repository.TokenPurposePasswordReset, repository.TokenPurposeEmailVerification} {
+		if err := s.tokenRepo.DeleteByUserID(ctx, u.ID, purpose); err != nil {
+			log.Printf("Failed to delete %s tokens of user %s: %v", purpose, u.ID, err)
+		}
+	}
+	if err := s.revokeSessions(ctx, u.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.Delete(ctx, u.ID); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to delete user: %w", err))
+	}
+	if s.eventBus != nil {
+		s.eventBus.Publish(events.Event{
+			Type:    events.UserDeleted,
+			ActorID: u.ID,
+			Data:    events.UserData{UserID: u.ID, Username: u.Username},
+		})
+	}
+
+	return &MessageResponse{
+		Message: "Account deleted",
+	}, nil
+}
+
+// SearchUsers finds discoverable users by username or email prefix, excluding the caller
+// Queries shorter than two characters return no results
+func (s *UserService) SearchUsers(ctx context.Context, userID, query string, limit int) ([]*UserSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if len(query) < userSearchMinLength {
+		return []*UserSearchResult{}, nil
+	}
+	if limit <= 0 {
+		limit = userSearchDefaultLimit
+	}
+	limit = min(limit, userSearchMaxLimit)
+
+	users, err := s.userRepo.Search(ctx, query, userID, limit)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	results := make([]*UserSearchResult, 0, len(users))
+	for _, u := range users {
+		results = append(results, &UserSearchResult{
+			ID:          u.ID,
+			Username:    u.Username,
+			Email:       u.Email,
+			DisplayName: u.DisplayName,
+			AvatarURL:   u.AvatarURL,
+		})
+	}
+
+	return results, nil
+}
+
+// sendEmailChangedNotice tells the previous address that the account email was changed
+func (s *UserService) sendEmailChangedNotice(ctx context.Context, u *user.User, previousEmail string) {
+	err := s.mailer.Send(ctx, &mail.Message{
+		To:      previousEmail,
+		Subject: "Your email address was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nThe email address of your account was changed to %s.\n\nIf you did not make this change, reset your password and contact support.\n",
+			u.Username, u.Email),
+	})
+	if err != nil {
+		log.Printf("Failed to send email change notice to user %s: %v", u.ID, err)
+	}
+}
+
+// applyPreferences validates and applies a partial preferences update
+func applyPreferences(prefs *user.Preferences, req *PreferencesRequest) error {
+	if req.Theme != nil {
+		switch theme := strings.TrimSpace(*req.Theme); theme {
+		case "", "light", "dark", "system":
+			prefs.Theme = theme
+		default:
+			return errors.NewAppError(errors.ErrInvalidInput.Code, "Theme must be \"light\", \"dark\" or \"system\"", nil)
+		}
+	}
+
+	if req.Locale != nil {
+		locale := strings.TrimSpace(*req.Locale)
+		if locale != "" && !localeRegex.MatchString(locale) {
+			return errors.NewAppError(errors.ErrInvalidInput.Code, "Locale must be a language tag such as \"en-US\"", nil)
+		}
+		prefs.Locale = locale
+	}
+
+	if req.EmailNotifications != nil {
+		prefs.EmailNotifications = *req.EmailNotifications
+	}
+
+	if req.Discoverable != nil {
+		discoverable := *req.Discoverable
+		prefs.Discoverable = &discoverable
+	}
+
+	return nil
+}
+
+// toProfileResponse converts a user to a profile response
+func toProfileResponse(u *user.User) *ProfileResponse {
+	// Report the effective discoverability rather than leaving it unset
+	prefs := u.Preferences
+	discoverable := prefs.IsDiscoverable()
+	prefs.Discoverable = &discoverable
+
+	return &ProfileResponse{
+		ID:               u.ID,
+		Username:         u.Username,
+		Email:            u.Email,
+		Verified:         u.Verified,
+		DisplayName:      u.DisplayName,
+		AvatarURL:        u.AvatarURL,
+		Preferences:      prefs,
+		TwoFactorEnabled: u.TOTP.Enabled,
+		CreatedAt:        u.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:        u.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
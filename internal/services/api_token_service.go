@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/auth"
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/pkg/apitoken"
+	"collaborative-editor/pkg/user"
+)
+
+const (
+	// maxAPITokensPerUser caps how many personal access tokens a user may hold
+	maxAPITokensPerUser = 50
+	// maxAPITokenLifetimeDays caps the lifetime of an expiring token
+	maxAPITokenLifetimeDays = 365
+	// apiTokenTouchInterval limits how often the last used time is written
+	apiTokenTouchInterval = time.Minute
+)
+
+// APITokenService handles personal access token business logic
+type APITokenService struct {
+	tokenRepo repository.APITokenRepository
+	userRepo  repository.UserRepository
+}
+
+// NewAPITokenService creates a new API token service
+func NewAPITokenService(tokenRepo repository.APITokenRepository, userRepo repository.UserRepository) *APITokenService {
+	return &APITokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// CreateAPITokenRequest represents a request to create a personal access token
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 means the token never expires
+}
+
+// CreateAPITokenResponse represents a newly created token
+// Token is only ever returned here
+type CreateAPITokenResponse struct {
+	*apitoken.APIToken
+	Token   string `json:"token"`
+	Message string `json:"message"`
+}
+
+// CreateToken creates a personal access token for a user
+func (s *APITokenService) CreateToken(ctx context.Context, userID string, req *CreateAPITokenRequest) (*CreateAPITokenResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Name is required", nil)
+	}
+	if len(name) > 100 {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Name must be at most 100 characters", nil)
+	}
+
+	if len(req.Scopes) == 0 {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "At least one scope is required", nil)
+	}
+	scopes := []string{}
+	for _, scope := range req.Scopes {
+		if !apitoken.IsValidScope(scope) {
+			return nil, errors.NewAppError(
+				errors.ErrInvalidInput.Code,
+				fmt.Sprintf("Unknown scope %q, valid scopes are: %s", scope, strings.Join(apitoken.ValidScopes, ", ")),
+				nil,
+			)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxAPITokenLifetimeDays {
+		return nil, errors.NewAppError(
+			errors.ErrInvalidInput.Code,
+			fmt.Sprintf("expires_in_days must be between 0 and %d", maxAPITokenLifetimeDays),
+			nil,
+		)
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	existing, err := s.tokenRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if len(existing) >= maxAPITokensPerUser {
+		return nil, errors.NewAppError(
+			errors.ErrConflict.Code,
+			fmt.Sprintf("A user can have at most %d tokens, revoke an unused token first", maxAPITokensPerUser),
+			nil,
+		)
+	}
+
+	secret, err := auth.RandomToken(32)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	token := apitoken.Prefix + secret
+
+	t := apitoken.NewAPIToken(userID, name, scopes, token[len(token)-4:], expiresAt)
+	if err := s.tokenRepo.Create(ctx, token, t); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	return &CreateAPITokenResponse{
+		APIToken: t,
+		Token:    token,
+		Message:  "Copy the token now, it will not be shown again",
+	}, nil
+}
+
+// ListTokens returns the personal access tokens of a user
+func (s *APITokenService) ListTokens(ctx context.Context, userID string) ([]*apitoken.APIToken, error) {
+	tokens, err := s.tokenRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes a personal access token of a user
+func (s *APITokenService) RevokeToken(ctx context.Context, userID, tokenID string) error {
+	if err := s.tokenRepo.Delete(ctx, userID, tokenID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.ErrNotFound
+		}
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return nil
+}
+
+// AuthenticateAPIToken resolves a personal access token to its record and owner
+func (s *APITokenService) AuthenticateAPIToken(ctx context.Context, token string) (*apitoken.APIToken, *user.User, error) {
+	t, err := s.tokenRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if t.IsExpired(now) {
+		return nil, nil, fmt.Errorf("API token expired")
+	}
+
+	u, err := s.userRepo.GetByID(ctx, t.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) > apiTokenTouchInterval {
+		if err := s.tokenRepo.TouchLastUsed(ctx, token, now); err != nil {
+			log.Printf("Failed to record API token use: %v", err)
+		}
+	}
+
+	return t, u, nil
+}
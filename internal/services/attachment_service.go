@@ -0,0 +1,326 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/internal/storage"
+	"collaborative-editor/pkg/attachment"
+	"collaborative-editor/pkg/document"
+)
+
+const (
+	// defaultMaxAttachmentSize is the largest file accepted unless configured otherwise
+	defaultMaxAttachmentSize = 10 << 20
+	// defaultMaxDocumentAttachmentsSize bounds the total size of a document's attachments unless configured otherwise
+	defaultMaxDocumentAttachmentsSize = 100 << 20
+	// sniffLength is how much of a file is read to detect its content type
+	sniffLength = 512
+	// maxAttachmentNameLength bounds stored file names, in characters
+	maxAttachmentNameLength = 255
+)
+
+// attachmentTypes are the sniffed content types accepted for upload
+// SVG and HTML are left out because they can carry script
+var attachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain; charset=utf-8",
+}
+
+// zipAttachmentTypes maps the extensions of accepted ZIP-based formats to their content types,
+// since sniffing only tells that a file is a ZIP archive
+var zipAttachmentTypes = map[string]string{
+	".zip":  "application/zip",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// AttachmentService handles images and files uploaded to documents
+// Access follows the document: readers can list and download, editors can upload and delete
+type AttachmentService struct {
+	attachmentRepo         repository.AttachmentRepository
+	docRepo                repository.DocumentRepository
+	blobs                  storage.BlobStore
+	docService             *DocumentService
+	maxFileSize            int64
+	maxDocumentAttachments int64
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(attachmentRepo repository.AttachmentRepository, docRepo repository.DocumentRepository, blobs storage.BlobStore, docService *DocumentService) *AttachmentService {
+	return &AttachmentService{
+		attachmentRepo:         attachmentRepo,
+		docRepo:                docRepo,
+		blobs:                  blobs,
+		docService:             docService,
+		maxFileSize:            defaultMaxAttachmentSize,
+		maxDocumentAttachments: defaultMaxDocumentAttachmentsSize,
+	}
+}
+
+// SetSizeLimits sets the largest file accepted and the most a single document's attachments may add up to
+func (s *AttachmentService) SetSizeLimits(maxFileSize, maxPerDocument int64) {
+	s.maxFileSize = maxFileSize
+	s.maxDocumentAttachments = maxPerDocument
+}
+
+// MaxFileSize returns the largest file accepted by UploadAttachment
+func (s *AttachmentService) MaxFileSize() int64 {
+	return s.maxFileSize
+}
+
+// AttachmentResponse represents an attachment response
+type AttachmentResponse struct {
+	ID          string    `json:"id"`
+	DocumentID  string    `json:"document_id"`
+	UploaderID  string    `json:"uploader_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	CreatedAt   time.Time `json:"created_at"`
+	URL         string    `json:"url"` // Download path, relative to the API
+}
+
+// UploadAttachment stores a file uploaded to a document the user can edit
+// The content type is sniffed from the file itself; anything not on the allowlist is rejected
+func (s *AttachmentService) UploadAttachment(ctx context.Context, userID, docID, fileName string, data io.Reader, size int64) (*AttachmentResponse, error) {
+	doc, err := s.getDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.docService.canEdit(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	if size <= 0 {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "File is empty", nil)
+	}
+	if size > s.maxFileSize {
+		return nil, errors.NewAppError(http.StatusRequestEntityTooLarge, fmt.Sprintf("File is larger than %d bytes", s.maxFileSize), nil)
+	}
+
+	existing, err := s.attachmentRepo.ListByDocumentID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	total := size
+	for _, a := range existing {
+		total += a.SizeBytes
+	}
+	if total > s.maxDocumentAttachments {
+		return nil, errors.NewAppError(http.StatusRequestEntityTooLarge, fmt.Sprintf("Attachments of a document cannot exceed %d bytes in total", s.maxDocumentAttachments), nil)
+	}
+
+	fileName = cleanAttachmentName(fileName)
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(data, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Failed to read the uploaded file", err)
+	}
+	head = head[:n]
+
+	contentType := sniffAttachmentType(head, fileName)
+	if contentType == "" {
+		return nil, errors.NewAppError(http.StatusUnsupportedMediaType, "Only images (PNG, JPEG, GIF, WebP), PDF, plain text and Office or ZIP files can be attached", nil)
+	}
+
+	a := attachment.NewAttachment(docID, userID, fileName, contentType, size)
+	if err := s.blobs.Put(ctx, a.StorageKey, io.MultiReader(bytes.NewReader(head), data), size, contentType); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to store attachment: %w", err))
+	}
+
+	if err := s.attachmentRepo.Create(ctx, a); err != nil {
+		if delErr := s.blobs.Delete(ctx, a.StorageKey); delErr != nil {
+			log.Printf("Failed to remove orphaned attachment blob %s: %v", a.StorageKey, delErr)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to create attachment: %w", err))
+	}
+
+	return toAttachmentResponse(a), nil
+}
+
+// ListAttachments lists the attachments of a document the user can read, oldest first
+func (s *AttachmentService) ListAttachments(ctx context.Context, userID, docID string) ([]*AttachmentResponse, error) {
+	doc, err := s.getDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.docService.hasAccess(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	attachments, err := s.attachmentRepo.ListByDocumentID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	responses := make([]*AttachmentResponse, len(attachments))
+	for i, a := range attachments {
+		responses[i] = toAttachmentResponse(a)
+	}
+	return responses, nil
+}
+
+// OpenAttachment opens an attachment of a document the user can read; the caller must close the reader
+func (s *AttachmentService) OpenAttachment(ctx context.Context, userID, docID, attachmentID string) (*attachment.Attachment, io.ReadCloser, error) {
+	doc, err := s.getDocument(ctx, docID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.docService.hasAccess(ctx, doc, userID) {
+		return nil, nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	a, err := s.getAttachment(ctx, docID, attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := s.blobs.Get(ctx, a.StorageKey)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, nil, errors.NewAppError(errors.ErrNotFound.Code, "Attachment not found", nil)
+		}
+		return nil, nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	return a, data, nil
+}
+
+// DeleteAttachment removes an attachment from a document the user can edit
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, userID, docID, attachmentID string) error {
+	doc, err := s.getDocument(ctx, docID)
+	if err != nil {
+		return err
+	}
+	if !s.docService.canEdit(ctx, doc, userID) {
+		return errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	a, err := s.getAttachment(ctx, docID, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.remove(ctx, a); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return nil
+}
+
+// DeleteDocumentAttachments removes every attachment of a permanently deleted document
+// It keeps going past failures and returns the first one
+func (s *AttachmentService) DeleteDocumentAttachments(ctx context.Context, docID string) error {
+	attachments, err := s.attachmentRepo.ListByDocumentID(ctx, docID)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, a := range attachments {
+		if err := s.remove(ctx, a); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// remove deletes an attachment's file and then its record, so a failure never leaves an unreferenced file
+func (s *AttachmentService) remove(ctx context.Context, a *attachment.Attachment) error {
+	if err := s.blobs.Delete(ctx, a.StorageKey); err != nil {
+		return fmt.Errorf("failed to delete attachment %s: %w", a.ID, err)
+	}
+	if err := s.attachmentRepo.Delete(ctx, a.ID); err != nil {
+		return fmt.Errorf("failed to delete attachment %s: %w", a.ID, err)
+	}
+	return nil
+}
+
+// getDocument loads a document, reporting a missing one as not found
+func (s *AttachmentService) getDocument(ctx context.Context, docID string) (*document.Document, error) {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Document not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return doc, nil
+}
+
+// getAttachment loads an attachment, hiding attachments of other documents
+func (s *AttachmentService) getAttachment(ctx context.Context, docID, attachmentID string) (*attachment.Attachment, error) {
+	a, err := s.attachmentRepo.GetByID(ctx, attachmentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Attachment not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if a.DocumentID != docID {
+		return nil, errors.NewAppError(errors.ErrNotFound.Code, "Attachment not found", nil)
+	}
+	return a, nil
+}
+
+// sniffAttachmentType detects the content type of a file from its first bytes, or returns "" if it is not accepted
+func sniffAttachmentType(head []byte, fileName string) string {
+	contentType := http.DetectContentType(head)
+	if contentType == "application/zip" {
+		return zipAttachmentTypes[strings.ToLower(filepath.Ext(fileName))]
+	}
+	for _, allowed := range attachmentTypes {
+		if contentType == allowed {
+			return contentType
+		}
+	}
+	return ""
+}
+
+// cleanAttachmentName strips any path and control or reserved characters from an uploaded file name
+func cleanAttachmentName(name string) string {
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, name))
+	if runes := []rune(name); len(runes) > maxAttachmentNameLength {
+		name = string(runes[:maxAttachmentNameLength])
+	}
+	if name == "" || name == "." || name == ".." {
+		name = "attachment"
+	}
+	return name
+}
+
+// toAttachmentResponse converts an attachment to its response
+func toAttachmentResponse(a *attachment.Attachment) *AttachmentResponse {
+	return &AttachmentResponse{
+		ID:          a.ID,
+		DocumentID:  a.DocumentID,
+		UploaderID:  a.UploaderID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		SizeBytes:   a.SizeBytes,
+		CreatedAt:   a.CreatedAt,
+		URL:         fmt.Sprintf("/documents/%s/attachments/%s", a.DocumentID, a.ID),
+	}
+}
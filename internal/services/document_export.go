@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/pkg/document"
+)
+
+// MaxImportSize is the largest file accepted by ImportDocument
+const MaxImportSize = 10 << 20
+
+// Export formats
+const (
+	ExportFormatMarkdown = "md"
+	ExportFormatHTML     = "html"
+	ExportFormatText     = "txt"
+	ExportFormatPDF      = "pdf"
+	ExportFormatDOCX     = "docx"
+)
+
+// ExportedDocument is a document rendered in an export format
+type ExportedDocument struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// ImportDocumentRequest represents the optional placement of an imported document
+type ImportDocumentRequest struct {
+	Title       string // Defaults to a DOCX file's title, then the first top-level heading, then the file name
+	WorkspaceID string
+	FolderID    string
+}
+
+// ExportDocument renders a document the user can read in the given format
+func (s *DocumentService) ExportDocument(ctx context.Context, userID, docID, format string) (*ExportedDocument, error) {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.hasAccess(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	tree, err := document.ParseContent(doc.Content)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to parse document content: %w", err))
+	}
+	// Content stored before it was sanitized on write may still hold unsafe links or images
+	document.SanitizeTree(tree)
+
+	switch format {
+	case ExportFormatMarkdown, "markdown":
+		return &ExportedDocument{
+			FileName:    exportFileName(doc.Title, "md"),
+			ContentType: "text/markdown; charset=utf-8",
+			Data:        []byte(document.RenderMarkdown(tree)),
+		}, nil
+	case ExportFormatHTML:
+		return &ExportedDocument{
+			FileName:    exportFileName(doc.Title, "html"),
+			ContentType: "text/html; charset=utf-8",
+			Data:        []byte(document.RenderHTMLPage(tree, s.exportMetadata(ctx, doc))),
+		}, nil
+	case ExportFormatText, "text":
+		return &ExportedDocument{
+			FileName:    exportFileName(doc.Title, "txt"),
+			ContentType: "text/plain; charset=utf-8",
+			Data:        []byte(document.RenderText(tree, s.exportMetadata(ctx, doc))),
+		}, nil
+	case ExportFormatPDF:
+		return &ExportedDocument{
+			FileName:    exportFileName(doc.Title, "pdf"),
+			ContentType: "application/pdf",
+			Data:        document.RenderPDF(tree, s.exportMetadata(ctx, doc)),
+		}, nil
+	case ExportFormatDOCX:
+		data, err := document.RenderDOCX(tree, s.exportMetadata(ctx, doc))
+		if err != nil {
+			return nil, errors.WrapError(errors.ErrInternalServer, err)
+		}
+		return &ExportedDocument{
+			FileName:    exportFileName(doc.Title, "docx"),
+			ContentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			Data:        data,
+		}, nil
+	}
+
+	return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("Unsupported export format %q", format), nil)
+}
+
+// exportMetadata returns the title, author and last-modified time shown in exports
+// The author is the owner's username, left out if the owner cannot be loaded
+func (s *DocumentService) exportMetadata(ctx context.Context, doc *document.Document) document.ExportMetadata {
+	meta := document.ExportMetadata{Title: doc.Title, ModifiedAt: doc.UpdatedAt}
+	if owner, err := s.userRepo.GetByID(ctx, doc.OwnerID); err == nil {
+		meta.Author = owner.Username
+	}
+	return meta
+}
+
+// ImportDocument creates a document from a Markdown or Word (.docx) file, converted to the editor's HTML
+// The format is chosen by the file's extension; files without one are read as Markdown
+func (s *DocumentService) ImportDocument(ctx context.Context, userID, fileName string, data []byte, req *ImportDocumentRequest) (*DocumentResponse, error) {
+	if len(data) > MaxImportSize {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("File is larger than %d bytes", MaxImportSize), nil)
+	}
+
+	var tree *document.Node
+	var title string
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case "", ".md", ".markdown", ".txt":
+		tree = document.ParseMarkdown(string(data))
+	case ".docx":
+		var err error
+		if tree, title, err = document.ParseDOCX(data); err != nil {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Invalid DOCX file", err)
+		}
+	default:
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Only Markdown and DOCX files can be imported", nil)
+	}
+
+	if t := strings.TrimSpace(req.Title); t != "" {
+		title = t
+	}
+	if title == "" {
+		title = firstHeading(tree)
+	}
+	if title == "" {
+		title = strings.TrimSpace(strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)))
+	}
+	if title == "" || title == "." {
+		title = "Imported document"
+	}
+
+	return s.CreateDocument(ctx, userID, &CreateDocumentRequest{
+		Title:       title,
+		Content:     document.RenderHTML(tree),
+		WorkspaceID: req.WorkspaceID,
+		FolderID:    req.FolderID,
+	})
+}
+
+// firstHeading returns the text of a document's first top-level heading, or ""
+func firstHeading(tree *document.Node) string {
+	for _, block := range tree.Content {
+		if block.Type == document.NodeHeading && block.IntAttr("level", 1) == 1 {
+			return strings.TrimSpace(block.TextContent())
+		}
+	}
+	return ""
+}
+
+// exportFileName builds a download file name from a document title
+func exportFileName(title, ext string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(title))
+	if name == "" {
+		name = "document"
+	}
+	return name + "." + ext
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/pkg/document"
+)
+
+// DocumentOutlineResponse represents a document's headings and text statistics
+type DocumentOutlineResponse struct {
+	ID             string                  `json:"id"`
+	WordCount      int                     `json:"word_count"`
+	CharacterCount int                     `json:"character_count"`
+	Outline        []document.OutlineEntry `json:"outline"`
+}
+
+// GetDocumentOutline returns the outline and word count of a document the user can read
+func (s *DocumentService) GetDocumentOutline(ctx context.Context, userID, docID string) (*DocumentOutlineResponse, error) {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.hasAccess(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	tree, err := document.ParseContent(doc.Content)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to parse document content: %w", err))
+	}
+
+	return &DocumentOutlineResponse{
+		ID:             doc.ID,
+		WordCount:      document.WordCount(tree),
+		CharacterCount: document.CharacterCount(tree),
+		Outline:        document.Outline(tree),
+	}, nil
+}
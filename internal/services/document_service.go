@@ -2,33 +2,114 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"log"
+	"os"
 	"slices"
+	"strings"
 	"time"
 
 	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/events"
 	"collaborative-editor/internal/repository"
+	"collaborative-editor/internal/search"
+	"collaborative-editor/internal/validation"
 	"collaborative-editor/pkg/document"
+	"collaborative-editor/pkg/folder"
+	"collaborative-editor/pkg/workspace"
 )
 
+// VerificationPolicy configures which document operations require a verified email
+type VerificationPolicy struct {
+	// RequireToCreateDocuments prevents unverified users from creating documents
+	RequireToCreateDocuments bool
+	// RequireForCollaborators prevents unverified users from being added as collaborators
+	RequireForCollaborators bool
+}
+
+// LoadVerificationPolicy reads the verification policy from environment variables
+func LoadVerificationPolicy() VerificationPolicy {
+	return VerificationPolicy{
+		RequireToCreateDocuments: os.Getenv("REQUIRE_VERIFIED_TO_CREATE_DOCUMENTS") == "true",
+		RequireForCollaborators:  os.Getenv("REQUIRE_VERIFIED_COLLABORATORS") == "true",
+	}
+}
+
 // DocumentService handles document-related business logic
 type DocumentService struct {
-	docRepo  repository.DocumentRepository
-	userRepo repository.UserRepository
+	docRepo            repository.DocumentRepository
+	userRepo           repository.UserRepository
+	workspaceRepo      repository.WorkspaceRepository
+	folderRepo         repository.FolderRepository
+	searchIndex        search.Index
+	attachmentCleaner  AttachmentCleaner
+	eventBus           *events.Bus
+	trashRetention     time.Duration
+	verificationPolicy VerificationPolicy
+	contentLimits      document.Limits
 }
 
 // NewDocumentService creates a new document service
 func NewDocumentService(docRepo repository.DocumentRepository, userRepo repository.UserRepository) *DocumentService {
 	return &DocumentService{
-		docRepo:  docRepo,
-		userRepo: userRepo,
+		docRepo:        docRepo,
+		userRepo:       userRepo,
+		trashRetention: defaultTrashRetention,
+		contentLimits:  document.DefaultLimits,
 	}
 }
 
-// CreateDocumentRequest represents a request to create a document
+// SetVerificationPolicy sets which operations require a verified email
+func (s *DocumentService) SetVerificationPolicy(policy VerificationPolicy) {
+	s.verificationPolicy = policy
+}
+
+// SetContentLimits sets the size, depth and node limits that document content must stay within
+func (s *DocumentService) SetContentLimits(limits document.Limits) {
+	s.contentLimits = limits
+}
+
+// SetWorkspaceRepository enables workspace-owned documents and access through workspace membership
+func (s *DocumentService) SetWorkspaceRepository(repo repository.WorkspaceRepository) {
+	s.workspaceRepo = repo
+}
+
+// SetFolderRepository enables folders and access shared through them
+func (s *DocumentService) SetFolderRepository(repo repository.FolderRepository) {
+	s.folderRepo = repo
+}
+
+// SetSearchIndex sets the full-text index kept up to date as documents change
+func (s *DocumentService) SetSearchIndex(index search.Index) {
+	s.searchIndex = index
+}
+
+// SetEventBus sets the bus that document changes are published on
+func (s *DocumentService) SetEventBus(bus *events.Bus) {
+	s.eventBus = bus
+}
+
+// CreateDocumentRequest represents a request to create or update a document
 type CreateDocumentRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title       string `json:"title"`
+	Content     string `json:"content"`
+	WorkspaceID string `json:"workspace_id,omitempty"` // Only used on create; see MoveDocument
+	FolderID    string `json:"folder_id,omitempty"`    // Only used on create; see MoveDocumentToFolder
+	TemplateID  string `json:"template_id,omitempty"`  // Only used on create; replaces Content, and Title if empty
+}
+
+// MoveDocumentRequest represents a request to move a document into a workspace
+// An empty WorkspaceID makes the document personal again
+type MoveDocumentRequest struct {
+	WorkspaceID string `json:"workspace_id"`
+}
+
+// MoveToFolderRequest represents a request to move a document or folder into a folder
+// An empty FolderID moves it to the top level
+type MoveToFolderRequest struct {
+	FolderID string `json:"folder_id"`
 }
 
 // AddCollaboratorRequest represents a request to add a collaborator
@@ -42,26 +123,86 @@ type DocumentResponse struct {
 	Title           string    `json:"title"`
 	Content         string    `json:"content"`
 	OwnerID         string    `json:"owner_id"`
+	WorkspaceID     string    `json:"workspace_id,omitempty"`
+	FolderID        string    `json:"folder_id,omitempty"`
+	Tags            []string  `json:"tags"`
+	IsTemplate      bool      `json:"is_template"`
 	CollaboratorIDs []string  `json:"collaborator_ids"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
-// CreateDocument creates a new document
+// CreateDocument creates a new document, optionally from a template
 func (s *DocumentService) CreateDocument(ctx context.Context, userID string, req *CreateDocumentRequest) (*DocumentResponse, error) {
-	if req.Title == "" {
+	if req.Title == "" && req.TemplateID == "" {
 		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Title is required", nil)
 	}
 
-	doc := document.NewDocument(req.Title, req.Content, userID)
+	if err := s.requireVerifiedToCreate(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var doc *document.Document
+	if req.TemplateID != "" {
+		var err error
+		if doc, err = s.instantiateTemplate(ctx, userID, req.TemplateID, req.Title); err != nil {
+			return nil, err
+		}
+	} else {
+		doc = document.NewDocument(req.Title, req.Content, userID)
+	}
+
+	content, err := s.sanitizeContent(doc.Content)
+	if err != nil {
+		return nil, err
+	}
+	doc.Content = content
+
+	if req.WorkspaceID != "" {
+		if _, err := s.requireWorkspaceRole(ctx, req.WorkspaceID, userID, workspace.RoleEditor); err != nil {
+			return nil, err
+		}
+		doc.WorkspaceID = req.WorkspaceID
+	}
+
+	if req.FolderID != "" {
+		f, err := s.requireFolderAccess(ctx, req.FolderID, userID, accessEdit)
+		if err != nil {
+			return nil, err
+		}
+		if req.WorkspaceID != "" && req.WorkspaceID != f.WorkspaceID {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Folder belongs to a different workspace", nil)
+		}
+		// Documents created in a workspace folder belong to the workspace
+		doc.FolderID = f.ID
+		doc.WorkspaceID = f.WorkspaceID
+	}
 
 	if err := s.docRepo.Create(ctx, doc); err != nil {
 		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to create document: %w", err))
 	}
+	s.indexDocument(doc)
+	s.publishDocumentEvent(events.DocumentCreated, userID, doc)
 
 	return s.toResponse(doc), nil
 }
 
+// requireVerifiedToCreate checks the user may create documents under the verification policy
+func (s *DocumentService) requireVerifiedToCreate(ctx context.Context, userID string) error {
+	if !s.verificationPolicy.RequireToCreateDocuments {
+		return nil
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if !u.Verified {
+		return errors.NewAppError(errors.ErrForbidden.Code, "Verify your email address to create documents", nil)
+	}
+	return nil
+}
+
 // GetDocument retrieves a document if the user has access
 func (s *DocumentService) GetDocument(ctx context.Context, userID, docID string) (*DocumentResponse, error) {
 	doc, err := s.docRepo.GetByID(ctx, docID)
@@ -69,7 +210,7 @@ func (s *DocumentService) GetDocument(ctx context.Context, userID, docID string)
 		return nil, errors.WrapError(errors.ErrInternalServer, err)
 	}
 
-	if !s.hasAccess(doc, userID) {
+	if !s.hasAccess(ctx, doc, userID) {
 		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
 	}
 
@@ -83,39 +224,64 @@ func (s *DocumentService) UpdateDocument(ctx context.Context, userID, docID stri
 		return nil, errors.WrapError(errors.ErrInternalServer, err)
 	}
 
-	if !s.hasAccess(doc, userID) {
+	if !s.canEdit(ctx, doc, userID) {
 		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
 	}
 
+	content, err := s.sanitizeContent(req.Content)
+	if err != nil {
+		return nil, err
+	}
+
 	if req.Title != "" {
 		doc.Title = req.Title
 	}
 	// Content update - in a real collaborative app, this would be more complex (OT/CRDT)
 	// For now, we just overwrite
-	doc.Content = req.Content
+	doc.Content = content
 	doc.UpdatedAt = time.Now()
 
 	if err := s.docRepo.Update(ctx, doc); err != nil {
 		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document: %w", err))
 	}
+	s.indexDocument(doc)
+	s.publishDocumentEvent(events.DocumentUpdated, userID, doc)
 
 	return s.toResponse(doc), nil
 }
 
-// DeleteDocument deletes a document (only owner)
+// sanitizeContent strips unsafe markup from document content, then checks it against the editor schema
+// and the content limits; problems are returned to the client as the error's details
+func (s *DocumentService) sanitizeContent(content string) (string, error) {
+	content = document.SanitizeContent(content)
+	if _, problems := document.ValidateContent(content, s.contentLimits); problems != nil {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "Invalid document content", problems).WithDetails(problems)
+	}
+	return content, nil
+}
+
+// DeleteDocument moves a document to the trash (only owner)
+// Live collaborators are disconnected by the WebSocket hub when it receives the event.
+// The document can be restored until it is purged; see RestoreDocument
 func (s *DocumentService) DeleteDocument(ctx context.Context, userID, docID string) error {
 	doc, err := s.docRepo.GetByID(ctx, docID)
 	if err != nil {
 		return errors.WrapError(errors.ErrInternalServer, err)
 	}
 
-	if doc.OwnerID != userID {
+	if !s.canManage(ctx, doc, userID) {
 		return errors.NewAppError(errors.ErrForbidden.Code, "Only owner can delete document", nil)
 	}
 
-	if err := s.docRepo.Delete(ctx, docID); err != nil {
+	now := time.Now()
+	doc.DeletedAt = &now
+	doc.DeletedBy = userID
+
+	if err := s.docRepo.Update(ctx, doc); err != nil {
 		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to delete document: %w", err))
 	}
+	s.unindexDocument(docID)
+	s.publishDocumentEvent(events.DocumentDeleted, userID, doc)
 
 	return nil
 }
@@ -131,7 +297,7 @@ func (s *DocumentService) AddCollaborator(ctx context.Context, userID, docID str
 		return nil, errors.WrapError(errors.ErrInternalServer, err)
 	}
 
-	if doc.OwnerID != userID {
+	if !s.canManage(ctx, doc, userID) {
 		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Only owner can add collaborators", nil)
 	}
 
@@ -140,6 +306,10 @@ func (s *DocumentService) AddCollaborator(ctx context.Context, userID, docID str
 		return nil, errors.NewAppError(errors.ErrNotFound.Code, "User not found with this email", nil)
 	}
 
+	if s.verificationPolicy.RequireForCollaborators && !collaborator.Verified {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "User has not verified their email address", nil)
+	}
+
 	if collaborator.ID == doc.OwnerID {
 		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Owner is already a collaborator", nil)
 	}
@@ -157,41 +327,629 @@ func (s *DocumentService) AddCollaborator(ctx context.Context, userID, docID str
 	if err := s.docRepo.Update(ctx, doc); err != nil {
 		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document: %w", err))
 	}
+	s.publish(events.Event{
+		Type:        events.CollaboratorAdded,
+		ActorID:     userID,
+		DocumentID:  doc.ID,
+		WorkspaceID: doc.WorkspaceID,
+		Data:        events.CollaboratorData{Title: doc.Title, CollaboratorID: collaborator.ID},
+	})
 
 	return s.toResponse(doc), nil
 }
 
-// ListDocuments lists documents for a user
-func (s *DocumentService) ListDocuments(ctx context.Context, userID string) ([]*DocumentResponse, error) {
-	docs, err := s.docRepo.ListByUserID(ctx, userID)
+// RemoveCollaborator removes a collaborator from a document
+// Requires managing the document, except for collaborators removing themselves
+func (s *DocumentService) RemoveCollaborator(ctx context.Context, userID, docID, collaboratorID string) error {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if collaboratorID != userID && !s.canManage(ctx, doc, userID) {
+		return errors.NewAppError(errors.ErrForbidden.Code, "Only owner can remove collaborators", nil)
+	}
+
+	if !slices.Contains(doc.CollaboratorIDs, collaboratorID) {
+		return errors.NewAppError(errors.ErrNotFound.Code, "Collaborator not found", nil)
+	}
+
+	doc.CollaboratorIDs = slices.DeleteFunc(doc.CollaboratorIDs, func(id string) bool {
+		return id == collaboratorID
+	})
+	doc.UpdatedAt = time.Now()
+
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document: %w", err))
+	}
+	s.publish(events.Event{
+		Type:        events.CollaboratorRemoved,
+		ActorID:     userID,
+		DocumentID:  doc.ID,
+		WorkspaceID: doc.WorkspaceID,
+		Data: events.CollaboratorData{
+			Title:          doc.Title,
+			CollaboratorID: collaboratorID,
+			AccessRevoked:  !s.hasAccess(ctx, doc, collaboratorID),
+		},
+	})
+
+	return nil
+}
+
+// MoveDocument moves a document into a workspace, or out of one when the workspace ID is empty
+// Requires managing the document and being at least an editor of the target workspace
+func (s *DocumentService) MoveDocument(ctx context.Context, userID, docID string, req *MoveDocumentRequest) (*DocumentResponse, error) {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.canManage(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Only owner can move document", nil)
+	}
+
+	if req.WorkspaceID != "" {
+		if _, err := s.requireWorkspaceRole(ctx, req.WorkspaceID, userID, workspace.RoleEditor); err != nil {
+			return nil, err
+		}
+	}
+
+	if doc.WorkspaceID != req.WorkspaceID {
+		// Folders do not span workspaces, so the document moves to the top level
+		doc.FolderID = ""
+	}
+	doc.WorkspaceID = req.WorkspaceID
+	doc.UpdatedAt = time.Now()
+
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document: %w", err))
+	}
+
+	return s.toResponse(doc), nil
+}
+
+// MoveDocumentToFolder moves a document into a folder, or to the top level when the folder ID is empty
+// Requires managing the document and edit access to the target folder, which must be in the document's workspace
+func (s *DocumentService) MoveDocumentToFolder(ctx context.Context, userID, docID string, req *MoveToFolderRequest) (*DocumentResponse, error) {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.canManage(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Only owner can move document", nil)
+	}
+
+	if req.FolderID != "" {
+		f, err := s.requireFolderAccess(ctx, req.FolderID, userID, accessEdit)
+		if err != nil {
+			return nil, err
+		}
+		if f.WorkspaceID != doc.WorkspaceID {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Document and folder must belong to the same workspace", nil)
+		}
+	}
+
+	doc.FolderID = req.FolderID
+	doc.UpdatedAt = time.Now()
+
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document: %w", err))
+	}
+
+	return s.toResponse(doc), nil
+}
+
+// ListDocumentsRequest represents the filters, sorting and pagination of a document listing
+type ListDocumentsRequest struct {
+	WorkspaceID   string    // Lists the documents of this workspace instead of the user's (members only)
+	Ownership     string    // "owned", "shared" or empty for both
+	TitleContains string    // Case-insensitive title filter
+	Tag           string    // Only documents with this tag
+	UpdatedSince  time.Time // Only documents updated at or after this time, if set
+	Sort          string    // "updated_at" (default), "created_at" or "title"
+	Order         string    // "asc" or "desc"; defaults to desc for times and asc for titles
+	Cursor        string    // next_cursor of the previous page
+	Limit         int       // Page size; defaults to 20, at most 100
+}
+
+// DocumentListResponse represents one page of a document listing
+type DocumentListResponse struct {
+	Documents  []*document.DocumentSummary `json:"documents"`
+	Total      int                         `json:"total"`                 // Matching documents across all pages
+	NextCursor string                      `json:"next_cursor,omitempty"` // Omitted on the last page
+}
+
+const (
+	// defaultDocumentPageSize and maxDocumentPageSize bound the size of a page of documents
+	defaultDocumentPageSize = 20
+	maxDocumentPageSize     = 100
+)
+
+// ListDocuments lists one page of the documents a user owns or collaborates on, without their content
+// If req.WorkspaceID is set, lists the documents of that workspace instead (members only)
+func (s *DocumentService) ListDocuments(ctx context.Context, userID string, req *ListDocumentsRequest) (*DocumentListResponse, error) {
+	opts := repository.DocumentListOptions{
+		UserID:        userID,
+		WorkspaceID:   req.WorkspaceID,
+		TitleContains: strings.TrimSpace(req.TitleContains),
+		UpdatedSince:  req.UpdatedSince,
+		Limit:         req.Limit,
+	}
+
+	if req.WorkspaceID != "" {
+		if _, err := s.requireWorkspaceRole(ctx, req.WorkspaceID, userID, workspace.RoleViewer); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Tag != "" {
+		tag, err := validation.NormalizeTag(req.Tag)
+		if err != nil {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, err.Error(), nil)
+		}
+		opts.Tag = tag
+	}
+
+	switch req.Ownership {
+	case repository.DocumentOwnershipAll, repository.DocumentOwnershipOwned, repository.DocumentOwnershipShared:
+		opts.Ownership = req.Ownership
+	default:
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "ownership must be \"owned\" or \"shared\"", nil)
+	}
+
+	switch req.Sort {
+	case "", repository.DocumentSortUpdatedAt, repository.DocumentSortCreatedAt:
+		opts.SortBy = req.Sort
+		if opts.SortBy == "" {
+			opts.SortBy = repository.DocumentSortUpdatedAt
+		}
+		opts.Descending = true
+	case repository.DocumentSortTitle:
+		opts.SortBy = req.Sort
+	default:
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "sort must be one of updated_at, created_at or title", nil)
+	}
+
+	switch req.Order {
+	case "":
+	case "asc":
+		opts.Descending = false
+	case "desc":
+		opts.Descending = true
+	default:
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "order must be \"asc\" or \"desc\"", nil)
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = defaultDocumentPageSize
+	}
+	opts.Limit = min(opts.Limit, maxDocumentPageSize)
+
+	if req.Cursor != "" {
+		cursor, err := decodeDocumentCursor(req.Cursor, opts.SortBy)
+		if err != nil {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Invalid cursor", nil)
+		}
+		opts.After = cursor
+	}
+
+	page, err := s.docRepo.ListPage(ctx, opts)
 	if err != nil {
 		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to list documents: %w", err))
 	}
 
-	var responses []*DocumentResponse
+	response := &DocumentListResponse{
+		Documents: page.Documents,
+		Total:     page.Total,
+	}
+	if page.Next != nil {
+		response.NextCursor = encodeDocumentCursor(page.Next, opts.SortBy)
+	}
+
+	return response, nil
+}
+
+// documentCursor is the opaque cursor handed to clients; it records the sort key so a cursor
+// cannot be reused with a different sort
+type documentCursor struct {
+	Sort string `json:"s"`
+	repository.DocumentCursor
+}
+
+// encodeDocumentCursor encodes a page position as an opaque string
+func encodeDocumentCursor(cursor *repository.DocumentCursor, sortBy string) string {
+	data, _ := json.Marshal(documentCursor{Sort: sortBy, DocumentCursor: *cursor})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeDocumentCursor decodes a cursor and checks it matches the sort key
+func decodeDocumentCursor(encoded, sortBy string) (*repository.DocumentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor documentCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	if cursor.Sort != sortBy || cursor.ID == "" {
+		return nil, fmt.Errorf("cursor does not match the sort")
+	}
+
+	// Titles sort as strings, times as Unix milliseconds
+	switch cursor.SortValue.(type) {
+	case string:
+		if sortBy != repository.DocumentSortTitle {
+			return nil, fmt.Errorf("cursor does not match the sort")
+		}
+	case float64:
+		if sortBy == repository.DocumentSortTitle {
+			return nil, fmt.Errorf("cursor does not match the sort")
+		}
+	default:
+		return nil, fmt.Errorf("invalid cursor value")
+	}
+
+	return &cursor.DocumentCursor, nil
+}
+
+// Policies for the documents owned by a deleted account
+const (
+	DocumentPolicyDelete   = "delete"   // Delete every owned document
+	DocumentPolicyTransfer = "transfer" // Hand owned documents over to another user
+)
+
+// ReleaseUserDocuments detaches a user from all documents before their account is deleted
+// Owned documents are deleted or transferred according to the policy, the user is
+// removed from every collaborator list, and their favorites are deleted. With the
+// transfer policy, documents go to transferToID if set, otherwise to their first
+// collaborator; documents nobody can take over are deleted permanently. Documents
+// in the trash follow the same policy
+func (s *DocumentService) ReleaseUserDocuments(ctx context.Context, userID, policy, transferToID string) error {
+	if policy != DocumentPolicyDelete && policy != DocumentPolicyTransfer {
+		return errors.NewAppError(errors.ErrInvalidInput.Code, "Document policy must be \"delete\" or \"transfer\"", nil)
+	}
+
+	docs, err := s.docRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to list documents: %w", err))
+	}
+
+	trashed, err := s.docRepo.ListTrash(ctx, userID, "")
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to list trash: %w", err))
+	}
+	docs = append(docs, trashed...)
+
 	for _, doc := range docs {
-		responses = append(responses, s.toResponse(doc))
+		wasCollaborator := slices.Contains(doc.CollaboratorIDs, userID)
+		doc.CollaboratorIDs = slices.DeleteFunc(doc.CollaboratorIDs, func(id string) bool {
+			return id == userID
+		})
+
+		if doc.OwnerID == userID {
+			// Workspace documents belong to the team, so they stay with it regardless of the policy
+			newOwnerID := s.workspaceSuccessor(ctx, doc.WorkspaceID, userID, transferToID)
+			if newOwnerID == "" && policy == DocumentPolicyTransfer {
+				newOwnerID = transferToID
+				if newOwnerID == "" && len(doc.CollaboratorIDs) > 0 {
+					newOwnerID = doc.CollaboratorIDs[0]
+				}
+			}
+
+			if newOwnerID == "" {
+				if err := s.docRepo.Delete(ctx, doc.ID); err != nil {
+					return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to delete document %s: %w", doc.ID, err))
+				}
+				s.unindexDocument(doc.ID)
+				s.deleteAttachments(ctx, doc.ID)
+				s.publishDocumentEvent(events.DocumentPurged, userID, doc)
+				continue
+			}
+
+			doc.OwnerID = newOwnerID
+			doc.CollaboratorIDs = slices.DeleteFunc(doc.CollaboratorIDs, func(id string) bool {
+				return id == newOwnerID
+			})
+		}
+
+		doc.UpdatedAt = time.Now()
+		if err := s.docRepo.Update(ctx, doc); err != nil {
+			return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document %s: %w", doc.ID, err))
+		}
+		if wasCollaborator {
+			s.publish(events.Event{
+				Type:        events.CollaboratorRemoved,
+				ActorID:     userID,
+				DocumentID:  doc.ID,
+				WorkspaceID: doc.WorkspaceID,
+				Data:        events.CollaboratorData{Title: doc.Title, CollaboratorID: userID, AccessRevoked: true},
+			})
+		}
 	}
 
-	return responses, nil
+	if err := s.docRepo.SetFavorites(ctx, userID, nil); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	return nil
 }
 
-// Helper: check access
-func (s *DocumentService) hasAccess(doc *document.Document, userID string) bool {
+// workspaceSuccessor picks who takes over a workspace document or folder from a departing owner:
+// transferToID if they are a member, otherwise the most privileged other member
+// Returns "" for personal documents or when nobody else is in the workspace
+func (s *DocumentService) workspaceSuccessor(ctx context.Context, workspaceID, userID, transferToID string) string {
+	w := s.getWorkspace(ctx, workspaceID)
+	if w == nil {
+		return ""
+	}
+	if transferToID != "" && w.RoleOf(transferToID) != "" {
+		return transferToID
+	}
+
+	successor, successorRole := "", ""
+	for _, m := range w.Members {
+		if m.UserID != userID && workspace.RoleAtLeast(m.Role, successorRole) {
+			if successor == "" || !workspace.RoleAtLeast(successorRole, m.Role) {
+				successor, successorRole = m.UserID, m.Role
+			}
+		}
+	}
+	return successor
+}
+
+// requireWorkspaceRole loads a workspace and checks the user has at least the given role
+func (s *DocumentService) requireWorkspaceRole(ctx context.Context, workspaceID, userID, role string) (*workspace.Workspace, error) {
+	if s.workspaceRepo == nil {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Workspaces are not enabled", nil)
+	}
+
+	w, err := s.workspaceRepo.GetByID(ctx, workspaceID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Workspace not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !w.HasRole(userID, role) {
+		// Do not reveal workspaces the user is not a member of
+		if w.RoleOf(userID) == "" {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Workspace not found", nil)
+		}
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Your workspace role does not allow this", nil)
+	}
+
+	return w, nil
+}
+
+// requireFolderAccess loads a folder and checks the user has at least the given access level
+// Users without any access get a not found error so folder IDs are not revealed
+func (s *DocumentService) requireFolderAccess(ctx context.Context, folderID, userID string, level int) (*folder.Folder, error) {
+	if s.folderRepo == nil {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Folders are not enabled", nil)
+	}
+
+	chain, err := s.folderChain(ctx, folderID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Folder not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	access := s.folderAccess(ctx, chain, userID)
+	if access == accessNone {
+		return nil, errors.NewAppError(errors.ErrNotFound.Code, "Folder not found", nil)
+	}
+	if access < level {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	return chain[0], nil
+}
+
+// getWorkspace loads a workspace, or nil if there is none
+func (s *DocumentService) getWorkspace(ctx context.Context, workspaceID string) *workspace.Workspace {
+	if workspaceID == "" || s.workspaceRepo == nil {
+		return nil
+	}
+	w, err := s.workspaceRepo.GetByID(ctx, workspaceID)
+	if err != nil {
+		log.Printf("Failed to load workspace %s: %v", workspaceID, err)
+		return nil
+	}
+	return w
+}
+
+// Access levels a user can have on a document or folder, from least to most
+const (
+	accessNone   = iota
+	accessView   // Read
+	accessEdit   // Read and write
+	accessManage // Delete, share and move
+)
+
+// workspaceRole returns the user's role in a workspace, or "" if none
+func (s *DocumentService) workspaceRole(ctx context.Context, workspaceID, userID string) string {
+	if w := s.getWorkspace(ctx, workspaceID); w != nil {
+		return w.RoleOf(userID)
+	}
+	return ""
+}
+
+// workspaceAccess maps a workspace role to the access it grants on the workspace's documents and folders
+func workspaceAccess(role string) int {
+	switch {
+	case workspace.RoleAtLeast(role, workspace.RoleAdmin):
+		return accessManage
+	case workspace.RoleAtLeast(role, workspace.RoleEditor):
+		return accessEdit
+	case workspace.RoleAtLeast(role, workspace.RoleViewer):
+		return accessView
+	}
+	return accessNone
+}
+
+// documentAccess returns the user's access level on a document:
+// the owner manages it, collaborators and anyone the containing folders are shared with edit it,
+// and workspace members get access according to their role
+func (s *DocumentService) documentAccess(ctx context.Context, doc *document.Document, userID string) int {
 	if doc.OwnerID == userID {
-		return true
+		return accessManage
+	}
+
+	level := workspaceAccess(s.workspaceRole(ctx, doc.WorkspaceID, userID))
+	if slices.Contains(doc.CollaboratorIDs, userID) {
+		level = max(level, accessEdit)
+	}
+	if level < accessEdit && doc.FolderID != "" {
+		// Folder sharing cascades to documents, but managing them stays with their owner
+		level = max(level, min(s.folderAccessByID(ctx, doc.FolderID, userID), accessEdit))
+	}
+	return level
+}
+
+// Helper: check access
+// The owner, collaborators, folder collaborators and every member of the owning workspace can read a document
+func (s *DocumentService) hasAccess(ctx context.Context, doc *document.Document, userID string) bool {
+	return s.documentAccess(ctx, doc, userID) >= accessView
+}
+
+// canEdit checks write access: the owner, collaborators, folder collaborators and workspace editors
+func (s *DocumentService) canEdit(ctx context.Context, doc *document.Document, userID string) bool {
+	return s.documentAccess(ctx, doc, userID) >= accessEdit
+}
+
+// canManage checks the right to delete, share and move a document: the owner and workspace admins
+func (s *DocumentService) canManage(ctx context.Context, doc *document.Document, userID string) bool {
+	return s.documentAccess(ctx, doc, userID) >= accessManage
+}
+
+// folderChain returns a folder followed by its ancestors, nearest first
+func (s *DocumentService) folderChain(ctx context.Context, folderID string) ([]*folder.Folder, error) {
+	if s.folderRepo == nil {
+		return nil, fmt.Errorf("folders are not enabled")
+	}
+
+	var chain []*folder.Folder
+	for id := folderID; id != ""; {
+		if len(chain) > maxFolderDepth {
+			return nil, fmt.Errorf("folder %s is nested too deeply", folderID)
+		}
+		f, err := s.folderRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, f)
+		id = f.ParentID
+	}
+	return chain, nil
+}
+
+// folderAccess returns the user's access level on the first folder of a chain:
+// the owner of the folder or of any ancestor manages it, collaborators on the folder or any
+// ancestor edit it, and workspace members get access according to their role
+func (s *DocumentService) folderAccess(ctx context.Context, chain []*folder.Folder, userID string) int {
+	if len(chain) == 0 {
+		return accessNone
+	}
+
+	level := workspaceAccess(s.workspaceRole(ctx, chain[0].WorkspaceID, userID))
+	for _, f := range chain {
+		if f.OwnerID == userID {
+			return accessManage
+		}
+		if slices.Contains(f.CollaboratorIDs, userID) {
+			level = max(level, accessEdit)
+		}
+	}
+	return level
+}
+
+// folderAccessByID returns the user's access level on a folder, or accessNone if it cannot be loaded
+func (s *DocumentService) folderAccessByID(ctx context.Context, folderID, userID string) int {
+	chain, err := s.folderChain(ctx, folderID)
+	if err != nil {
+		log.Printf("Failed to load folder %s: %v", folderID, err)
+		return accessNone
+	}
+	return s.folderAccess(ctx, chain, userID)
+}
+
+// indexDocument updates a document in the search index
+// Failures are logged, since the index can be rebuilt from the database
+func (s *DocumentService) indexDocument(doc *document.Document) {
+	if s.searchIndex == nil {
+		return
+	}
+	if err := s.searchIndex.Index(toSearchDocument(doc)); err != nil {
+		log.Printf("Failed to index document %s: %v", doc.ID, err)
+	}
+}
+
+// unindexDocument removes a document from the search index
+func (s *DocumentService) unindexDocument(docID string) {
+	if s.searchIndex == nil {
+		return
+	}
+	if err := s.searchIndex.Remove(docID); err != nil {
+		log.Printf("Failed to remove document %s from the search index: %v", docID, err)
+	}
+}
+
+// publish publishes an event on the event bus, if there is one
+func (s *DocumentService) publish(event events.Event) {
+	if s.eventBus != nil {
+		s.eventBus.Publish(event)
+	}
+}
+
+// publishDocumentEvent publishes an event about a document made by a user, or by the server when actorID is empty
+func (s *DocumentService) publishDocumentEvent(eventType, actorID string, doc *document.Document) {
+	s.publish(events.Event{
+		Type:        eventType,
+		ActorID:     actorID,
+		DocumentID:  doc.ID,
+		WorkspaceID: doc.WorkspaceID,
+		Data: events.DocumentData{
+			Title:     doc.Title,
+			OwnerID:   doc.OwnerID,
+			FolderID:  doc.FolderID,
+			UpdatedAt: doc.UpdatedAt,
+		},
+	})
+}
+
+// toSearchDocument extracts the searchable text of a document
+func toSearchDocument(doc *document.Document) search.Document {
+	return search.Document{
+		ID:    doc.ID,
+		Title: doc.Title,
+		Body:  search.TextFromHTML(doc.Content),
 	}
-	return slices.Contains(doc.CollaboratorIDs, userID)
 }
 
 // Helper: convert to response
 func (s *DocumentService) toResponse(doc *document.Document) *DocumentResponse {
+	tags := doc.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
 	return &DocumentResponse{
-		ID:        doc.ID,
-		Title:     doc.Title,
-		Content:   doc.Content,
-		OwnerID:   doc.OwnerID,
-		CreatedAt: doc.CreatedAt,
-		UpdatedAt: doc.UpdatedAt,
+		ID:          doc.ID,
+		Title:       doc.Title,
+		Content:     doc.Content,
+		OwnerID:     doc.OwnerID,
+		WorkspaceID: doc.WorkspaceID,
+		FolderID:    doc.FolderID,
+		Tags:        tags,
+		IsTemplate:  doc.IsTemplate,
+		CreatedAt:   doc.CreatedAt,
+		UpdatedAt:   doc.UpdatedAt,
 	}
 }
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/internal/validation"
+	"collaborative-editor/pkg/document"
+)
+
+const (
+	// maxTagsPerDocument bounds the number of tags on a document
+	maxTagsPerDocument = 20
+	// maxFavorites bounds the number of favorite documents per user
+	maxFavorites = 100
+	// maxMergedTags bounds the number of tags merged at once
+	maxMergedTags = 20
+)
+
+// SetTagsRequest represents a request to replace the tags of a document
+type SetTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// RenameTagRequest represents a request to rename a tag on all of the user's documents
+type RenameTagRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MergeTagsRequest represents a request to merge several tags into one on all of the user's documents
+type MergeTagsRequest struct {
+	From []string `json:"from"`
+	To   string   `json:"to"`
+}
+
+// TagOperationResponse reports the result of renaming or merging tags
+type TagOperationResponse struct {
+	Tag       string `json:"tag"`
+	Documents int    `json:"documents"` // Number of documents changed
+}
+
+// ReorderFavoritesRequest represents a new pinned order for the user's favorites
+type ReorderFavoritesRequest struct {
+	DocumentIDs []string `json:"document_ids"`
+}
+
+// SetTags replaces the tags of a document (requires edit access)
+// Tags are lowercased and deduplicated
+func (s *DocumentService) SetTags(ctx context.Context, userID, docID string, req *SetTagsRequest) (*DocumentResponse, error) {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.canEdit(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	tags := []string{}
+	for _, raw := range req.Tags {
+		tag, err := validation.NormalizeTag(raw)
+		if err != nil {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, err.Error(), nil)
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > maxTagsPerDocument {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("A document can have at most %d tags", maxTagsPerDocument), nil)
+	}
+	slices.Sort(tags)
+
+	// Tags are metadata, so they do not change when the document was last edited
+	doc.Tags = tags
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document: %w", err))
+	}
+
+	return s.toResponse(doc), nil
+}
+
+// ListTags lists the tags on the documents a user owns or collaborates on, with the number of documents using each
+func (s *DocumentService) ListTags(ctx context.Context, userID string) ([]repository.TagCount, error) {
+	tags, err := s.docRepo.ListTags(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return tags, nil
+}
+
+// RenameTag renames a tag on every document the user owns or collaborates on
+// Renaming to a tag that is already in use merges the two
+func (s *DocumentService) RenameTag(ctx context.Context, userID string, req *RenameTagRequest) (*TagOperationResponse, error) {
+	return s.MergeTags(ctx, userID, &MergeTagsRequest{From: []string{req.From}, To: req.To})
+}
+
+// MergeTags replaces several tags with one on every document the user owns or collaborates on
+func (s *DocumentService) MergeTags(ctx context.Context, userID string, req *MergeTagsRequest) (*TagOperationResponse, error) {
+	to, err := validation.NormalizeTag(req.To)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, err.Error(), nil)
+	}
+
+	from := []string{}
+	for _, raw := range req.From {
+		tag, err := validation.NormalizeTag(raw)
+		if err != nil {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, err.Error(), nil)
+		}
+		if tag != to && !slices.Contains(from, tag) {
+			from = append(from, tag)
+		}
+	}
+	if len(from) == 0 {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "At least one tag different from the target is required", nil)
+	}
+	if len(from) > maxMergedTags {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("At most %d tags can be merged at once", maxMergedTags), nil)
+	}
+
+	// Like SetTags, this only changes metadata: updated_at is kept and no update is published
+	count, err := s.docRepo.RenameTags(ctx, userID, from, to)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	return &TagOperationResponse{Tag: to, Documents: count}, nil
+}
+
+// ListFavorites lists a user's favorite documents in pinned order, without their content
+// Documents that were deleted are dropped from the favorites; those the user lost access to are hidden
+func (s *DocumentService) ListFavorites(ctx context.Context, userID string) ([]*document.DocumentSummary, error) {
+	ids, err := s.docRepo.GetFavorites(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	summaries := []*document.DocumentSummary{}
+	kept := []string{}
+	for _, id := range ids {
+		doc, err := s.docRepo.GetByID(ctx, id)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				continue
+			}
+			return nil, errors.WrapError(errors.ErrInternalServer, err)
+		}
+		kept = append(kept, id)
+		if s.hasAccess(ctx, doc, userID) {
+			summaries = append(summaries, doc.Summary())
+		}
+	}
+
+	if len(kept) != len(ids) {
+		if err := s.docRepo.SetFavorites(ctx, userID, kept); err != nil {
+			log.Printf("Failed to prune favorites of user %s: %v", userID, err)
+		}
+	}
+
+	return summaries, nil
+}
+
+// AddFavorite adds a document the user can access to the end of their favorites
+func (s *DocumentService) AddFavorite(ctx context.Context, userID, docID string) error {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.hasAccess(ctx, doc, userID) {
+		return errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	ids, err := s.docRepo.GetFavorites(ctx, userID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if slices.Contains(ids, docID) {
+		return nil
+	}
+	if len(ids) >= maxFavorites {
+		return errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("You can have at most %d favorites", maxFavorites), nil)
+	}
+
+	if err := s.docRepo.SetFavorites(ctx, userID, append(ids, docID)); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return nil
+}
+
+// RemoveFavorite removes a document from the user's favorites
+func (s *DocumentService) RemoveFavorite(ctx context.Context, userID, docID string) error {
+	ids, err := s.docRepo.GetFavorites(ctx, userID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if !slices.Contains(ids, docID) {
+		return nil
+	}
+
+	ids = slices.DeleteFunc(ids, func(id string) bool {
+		return id == docID
+	})
+	if err := s.docRepo.SetFavorites(ctx, userID, ids); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return nil
+}
+
+// ReorderFavorites sets the pinned order of the user's favorites
+// The request must list exactly the current favorites
+func (s *DocumentService) ReorderFavorites(ctx context.Context, userID string, req *ReorderFavoritesRequest) ([]*document.DocumentSummary, error) {
+	ids, err := s.docRepo.GetFavorites(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	current := slices.Clone(ids)
+	requested := slices.Clone(req.DocumentIDs)
+	slices.Sort(current)
+	slices.Sort(requested)
+	if !slices.Equal(current, requested) {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "document_ids must list each favorite exactly once", nil)
+	}
+
+	if err := s.docRepo.SetFavorites(ctx, userID, req.DocumentIDs); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	return s.ListFavorites(ctx, userID)
+}
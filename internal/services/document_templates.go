@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/events"
+	"collaborative-editor/pkg/document"
+	"collaborative-editor/pkg/workspace"
+)
+
+// templateVariableRegex matches template variables such as {{date}} or {{ author }}
+var templateVariableRegex = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// SetTemplateRequest represents a request to mark or unmark a document as a template
+type SetTemplateRequest struct {
+	IsTemplate bool `json:"is_template"`
+}
+
+// DuplicateDocumentRequest represents a request to copy a document
+type DuplicateDocumentRequest struct {
+	Title string `json:"title,omitempty"` // Defaults to "Copy of <title>"
+}
+
+// SetTemplate marks or unmarks a document as a template (only owner)
+// Templates in a workspace are offered to all of its members, personal ones to the owner and collaborators
+func (s *DocumentService) SetTemplate(ctx context.Context, userID, docID string, req *SetTemplateRequest) (*DocumentResponse, error) {
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.canManage(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Only owner can change templates", nil)
+	}
+
+	doc.IsTemplate = req.IsTemplate
+
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update document: %w", err))
+	}
+
+	return s.toResponse(doc), nil
+}
+
+// ListTemplates lists the templates a user can create documents from, without their content:
+// their own and shared templates, and those of every workspace they are a member of
+func (s *DocumentService) ListTemplates(ctx context.Context, userID string) ([]*document.DocumentSummary, error) {
+	var workspaceIDs []string
+	if s.workspaceRepo != nil {
+		workspaces, err := s.workspaceRepo.ListByUserID(ctx, userID)
+		if err != nil {
+			return nil, errors.WrapError(errors.ErrInternalServer, err)
+		}
+		for _, w := range workspaces {
+			workspaceIDs = append(workspaceIDs, w.ID)
+		}
+	}
+
+	templates, err := s.docRepo.ListTemplates(ctx, userID, workspaceIDs)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to list templates: %w", err))
+	}
+
+	return templates, nil
+}
+
+// DuplicateDocument copies a document the user can read into a new document they own
+// The copy stays in the original's workspace and folder when the user can add documents there;
+// collaborators are not copied
+func (s *DocumentService) DuplicateDocument(ctx context.Context, userID, docID string, req *DuplicateDocumentRequest) (*DocumentResponse, error) {
+	if err := s.requireVerifiedToCreate(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.docRepo.GetByID(ctx, docID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Document not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.hasAccess(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Access denied", nil)
+	}
+
+	// The original may predate the current sanitizer or content limits
+	content, err := s.sanitizeContent(doc.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = "Copy of " + doc.Title
+	}
+
+	dup := document.NewDocument(title, content, userID)
+	dup.Tags = slices.Clone(doc.Tags)
+	if doc.WorkspaceID == "" || workspace.RoleAtLeast(s.workspaceRole(ctx, doc.WorkspaceID, userID), workspace.RoleEditor) {
+		dup.WorkspaceID = doc.WorkspaceID
+		if doc.FolderID != "" && s.folderAccessByID(ctx, doc.FolderID, userID) >= accessEdit {
+			dup.FolderID = doc.FolderID
+		}
+	}
+
+	if err := s.docRepo.Create(ctx, dup); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to create document: %w", err))
+	}
+	s.indexDocument(dup)
+	s.publishDocumentEvent(events.DocumentCreated, userID, dup)
+
+	return s.toResponse(dup), nil
+}
+
+// instantiateTemplate builds a new document from a template the user can read, substituting its variables
+// The title defaults to the template's title
+func (s *DocumentService) instantiateTemplate(ctx context.Context, userID, templateID, title string) (*document.Document, error) {
+	tmpl, err := s.docRepo.GetByID(ctx, templateID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Template not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !tmpl.IsTemplate || !s.hasAccess(ctx, tmpl, userID) {
+		return nil, errors.NewAppError(errors.ErrNotFound.Code, "Template not found", nil)
+	}
+
+	author, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	now := time.Now()
+	vars := map[string]string{
+		"date":   now.Format("2006-01-02"),
+		"time":   now.Format("15:04"),
+		"author": author.Username,
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = expandTemplate(tmpl.Title, vars, false)
+	}
+	vars["title"] = title
+
+	doc := document.NewDocument(title, expandTemplate(tmpl.Content, vars, true), userID)
+	doc.Tags = slices.Clone(tmpl.Tags)
+	return doc, nil
+}
+
+// expandTemplate replaces the known {{variables}} in a template and leaves unknown ones as they are
+// Values are HTML-escaped when expanding document content
+func expandTemplate(text string, vars map[string]string, escapeHTML bool) string {
+	return templateVariableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		value, ok := vars[templateVariableRegex.FindStringSubmatch(match)[1]]
+		if !ok {
+			return match
+		}
+		if escapeHTML {
+			return html.EscapeString(value)
+		}
+		return value
+	})
+}
@@ -0,0 +1,34 @@
+package services
+
+import "testing"
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]string{
+		"title": `Q3 <Plan> & "Goals"`,
+		"date":  "2026-10-18",
+		"user":  "",
+	}
+
+	tests := []struct {
+		name       string
+		in         string
+		escapeHTML bool
+		want       string
+	}{
+		{"no variables", "<p>Notes</p>", true, "<p>Notes</p>"},
+		{"known variable", "Meeting {{date}}", false, "Meeting 2026-10-18"},
+		{"spaces inside braces", "{{ date }}/{{date  }}", false, "2026-10-18/2026-10-18"},
+		{"repeated variable", "{{date}} {{date}}", false, "2026-10-18 2026-10-18"},
+		{"empty value", "by {{user}}.", false, "by ."},
+		{"unescaped for titles", "{{title}}", false, `Q3 <Plan> & "Goals"`},
+		{"escaped for content", "<h1>{{title}}</h1>", true, "<h1>Q3 &lt;Plan&gt; &amp; &#34;Goals&#34;</h1>"},
+		{"unknown variable", "<p>{{owner}} on {{date}}</p>", true, "<p>{{owner}} on 2026-10-18</p>"},
+		{"not a variable", "{{Date}} {{date-1}} {date}", false, "{{Date}} {{date-1}} {date}"},
+	}
+
+	for _, tt := range tests {
+		if got := expandTemplate(tt.in, vars, tt.escapeHTML); got != tt.want {
+			t.Errorf("%s: expandTemplate(%q, %v) = %q, want %q", tt.name, tt.in, tt.escapeHTML, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/events"
+	"collaborative-editor/pkg/document"
+	"collaborative-editor/pkg/workspace"
+)
+
+const (
+	// defaultTrashRetention is how long deleted documents stay in the trash before they are purged
+	defaultTrashRetention = 30 * 24 * time.Hour
+	// trashSweepInterval is how often expired documents are purged from the trash
+	trashSweepInterval = time.Hour
+)
+
+// AttachmentCleaner removes the attachments of a document; implemented by AttachmentService
+type AttachmentCleaner interface {
+	DeleteDocumentAttachments(ctx context.Context, documentID string) error
+}
+
+// SetAttachmentCleaner sets what removes a document's attachments once it is permanently deleted
+func (s *DocumentService) SetAttachmentCleaner(cleaner AttachmentCleaner) {
+	s.attachmentCleaner = cleaner
+}
+
+// SetTrashRetention sets how long deleted documents can be restored before they are purged
+func (s *DocumentService) SetTrashRetention(retention time.Duration) {
+	s.trashRetention = retention
+}
+
+// TrashedDocumentResponse represents a document in the trash, without its content
+type TrashedDocumentResponse struct {
+	*document.DocumentSummary
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by"`
+	PurgeAt   time.Time `json:"purge_at"` // When the document is deleted permanently
+}
+
+// ListTrash lists the trashed documents a user can restore, most recently deleted first
+// If workspaceID is set, lists the workspace's trash instead (admins only)
+func (s *DocumentService) ListTrash(ctx context.Context, userID, workspaceID string) ([]*TrashedDocumentResponse, error) {
+	if workspaceID != "" {
+		if _, err := s.requireWorkspaceRole(ctx, workspaceID, userID, workspace.RoleAdmin); err != nil {
+			return nil, err
+		}
+	}
+
+	docs, err := s.docRepo.ListTrash(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to list trash: %w", err))
+	}
+
+	trash := []*TrashedDocumentResponse{}
+	for _, doc := range docs {
+		if !s.canManage(ctx, doc, userID) {
+			continue
+		}
+		trash = append(trash, &TrashedDocumentResponse{
+			DocumentSummary: doc.Summary(),
+			DeletedAt:       *doc.DeletedAt,
+			DeletedBy:       doc.DeletedBy,
+			PurgeAt:         doc.DeletedAt.Add(s.trashRetention),
+		})
+	}
+
+	return trash, nil
+}
+
+// RestoreDocument moves a document out of the trash (owner or workspace admin)
+// A document whose folder or workspace was deleted meanwhile is restored to the top level
+func (s *DocumentService) RestoreDocument(ctx context.Context, userID, docID string) (*DocumentResponse, error) {
+	doc, err := s.getTrashed(ctx, userID, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.detachMissingParents(ctx, doc); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	doc.DeletedAt = nil
+	doc.DeletedBy = ""
+
+	if err := s.docRepo.Update(ctx, doc); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to restore document: %w", err))
+	}
+	s.indexDocument(doc)
+	s.publishDocumentEvent(events.DocumentRestored, userID, doc)
+
+	return s.toResponse(doc), nil
+}
+
+// PurgeDocument permanently deletes a document from the trash (owner or workspace admin)
+func (s *DocumentService) PurgeDocument(ctx context.Context, userID, docID string) error {
+	doc, err := s.getTrashed(ctx, userID, docID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.docRepo.Delete(ctx, docID); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to delete document: %w", err))
+	}
+	s.deleteAttachments(ctx, docID)
+	s.publishDocumentEvent(events.DocumentPurged, userID, doc)
+
+	return nil
+}
+
+// PurgeExpiredDocuments permanently deletes the documents that have been in the trash longer than the retention
+func (s *DocumentService) PurgeExpiredDocuments(ctx context.Context) (int, error) {
+	docs, err := s.docRepo.ListTrashedBefore(ctx, time.Now().Add(-s.trashRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired documents: %w", err)
+	}
+
+	purged := 0
+	for _, doc := range docs {
+		if err := s.docRepo.Delete(ctx, doc.ID); err != nil {
+			log.Printf("Failed to purge document %s: %v", doc.ID, err)
+			continue
+		}
+		s.deleteAttachments(ctx, doc.ID)
+		s.publishDocumentEvent(events.DocumentPurged, "", doc)
+		purged++
+	}
+
+	return purged, nil
+}
+
+// RunTrashSweeper purges expired documents from the trash every hour
+func (s *DocumentService) RunTrashSweeper() {
+	ticker := time.NewTicker(trashSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		purged, err := s.PurgeExpiredDocuments(context.Background())
+		if err != nil {
+			log.Printf("Trash sweep failed: %v", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Purged %d documents from the trash", purged)
+		}
+	}
+}
+
+// getTrashed loads a document from the trash and checks the user can manage it
+func (s *DocumentService) getTrashed(ctx context.Context, userID, docID string) (*document.Document, error) {
+	doc, err := s.docRepo.GetTrashedByID(ctx, docID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Document not found in trash", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if !s.canManage(ctx, doc, userID) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Only owner can restore or purge document", nil)
+	}
+
+	return doc, nil
+}
+
+// detachMissingParents clears the folder and workspace of a document if they no longer exist
+func (s *DocumentService) detachMissingParents(ctx context.Context, doc *document.Document) error {
+	if doc.WorkspaceID != "" && s.workspaceRepo != nil {
+		if _, err := s.workspaceRepo.GetByID(ctx, doc.WorkspaceID); err != nil {
+			if !strings.Contains(err.Error(), "not found") {
+				return err
+			}
+			doc.WorkspaceID = ""
+			doc.FolderID = ""
+		}
+	}
+
+	if doc.FolderID != "" && s.folderRepo != nil {
+		f, err := s.folderRepo.GetByID(ctx, doc.FolderID)
+		if err != nil {
+			if !strings.Contains(err.Error(), "not found") {
+				return err
+			}
+			doc.FolderID = ""
+		} else if f.WorkspaceID != doc.WorkspaceID {
+			doc.FolderID = ""
+		}
+	}
+
+	return nil
+}
+
+// deleteAttachments removes the attachments of a permanently deleted document
+// Failures are only logged, since the document itself is already gone
+func (s *DocumentService) deleteAttachments(ctx context.Context, docID string) {
+	if s.attachmentCleaner == nil {
+		return
+	}
+	if err := s.attachmentCleaner.DeleteDocumentAttachments(ctx, docID); err != nil {
+		log.Printf("Failed to delete attachments of document %s: %v", docID, err)
+	}
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/auth"
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/pkg/document"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// exportRetention is how long a finished export can be downloaded
+	exportRetention = 24 * time.Hour
+	// exportJobTimeout is how long a job may stay pending or running before a new one may be started
+	// (jobs do not survive a server restart)
+	exportJobTimeout = 30 * time.Minute
+	// exportDownloadPurpose is the purpose of download link tokens
+	exportDownloadPurpose = "export_download"
+	// exportReadme explains the archive layout
+	exportReadme = `Collaborative Editor - personal data export
+
+profile.json             Your account profile and settings
+access_tokens.json       Your personal access tokens (metadata only, never the tokens)
+text.json                Your saved text, if any
+documents/owned/<id>/    Documents you own: metadata.json and content.html
+documents/shared/<id>/   Documents shared with you: metadata.json and content.html
+
+Comments and chat messages are not stored by the service, so there are none to export.
+`
+)
+
+// ExportService handles asynchronous personal data exports
+type ExportService struct {
+	jobRepo      repository.ExportJobRepository
+	userRepo     repository.UserRepository
+	docRepo      repository.DocumentRepository
+	textRepo     repository.TextRepository
+	apiTokenRepo repository.APITokenRepository
+	dir          string
+	apiBaseURL   string
+}
+
+// NewExportService creates a new export service
+// Archives are written to EXPORT_DIR (default "exports"); download links point at API_BASE_URL
+// Expired archives are removed periodically in the background
+func NewExportService(jobRepo repository.ExportJobRepository, userRepo repository.UserRepository, docRepo repository.DocumentRepository, textRepo repository.TextRepository, apiTokenRepo repository.APITokenRepository) *ExportService {
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = "exports"
+	}
+	apiBaseURL := os.Getenv("API_BASE_URL")
+	if apiBaseURL == "" {
+		apiBaseURL = "http://localhost:8080"
+	}
+
+	s := &ExportService{
+		jobRepo:      jobRepo,
+		userRepo:     userRepo,
+		docRepo:      docRepo,
+		textRepo:     textRepo,
+		apiTokenRepo: apiTokenRepo,
+		dir:          dir,
+		apiBaseURL:   strings.TrimSuffix(apiBaseURL, "/"),
+	}
+
+	go s.cleanupLoop()
+	return s
+}
+
+// ExportJobResponse represents the status of an export job
+type ExportJobResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	DownloadURL string     `json:"download_url,omitempty"` // Set once the job has completed
+}
+
+// exportDocumentMetadata is written next to each exported document's content
+type exportDocumentMetadata struct {
+	ID              string    `json:"id"`
+	Title           string    `json:"title"`
+	Role            string    `json:"role"` // "owner" or "collaborator"
+	OwnerID         string    `json:"owner_id"`
+	CollaboratorIDs []string  `json:"collaborator_ids"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// RequestExport starts a new export job for a user
+// Only one job per user may be in progress at a time
+func (s *ExportService) RequestExport(ctx context.Context, userID string) (*ExportJobResponse, error) {
+	active, err := s.jobRepo.FindActiveByUserID(ctx, userID, time.Now().Add(-exportJobTimeout))
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if active != nil {
+		return nil, errors.NewAppError(errors.ErrConflict.Code, "An export is already in progress", nil)
+	}
+
+	now := time.Now()
+	job := &repository.ExportJob{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Status:    repository.ExportStatusPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(exportRetention),
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	go s.run(job)
+
+	return s.toResponse(job)
+}
+
+// GetJob returns the status of an export job of the user
+func (s *ExportService) GetJob(ctx context.Context, userID, jobID string) (*ExportJobResponse, error) {
+	job, err := s.getJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(job)
+}
+
+// OpenDownload validates a download token and returns the archive path and a download file name
+func (s *ExportService) OpenDownload(ctx context.Context, jobID, token string) (string, string, error) {
+	claims, err := auth.ValidatePurposeToken(token, exportDownloadPurpose)
+	if err != nil || claims.Data["job_id"] != jobID {
+		return "", "", errors.NewAppError(errors.ErrUnauthorized.Code, "Invalid or expired download link", err)
+	}
+
+	job, err := s.getJob(ctx, claims.Subject, jobID)
+	if err != nil {
+		return "", "", err
+	}
+	if job.Status != repository.ExportStatusCompleted {
+		return "", "", errors.NewAppError(errors.ErrNotFound.Code, "Export is not ready", nil)
+	}
+
+	path := filepath.Join(s.dir, job.FileName)
+	if _, err := os.Stat(path); err != nil {
+		return "", "", errors.NewAppError(errors.ErrNotFound.Code, "Export has expired", err)
+	}
+
+	return path, fmt.Sprintf("export-%s.zip", job.CreatedAt.Format("2006-01-02")), nil
+}
+
+// getJob loads a job, hiding jobs of other users
+func (s *ExportService) getJob(ctx context.Context, userID, jobID string) (*repository.ExportJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if job.UserID != userID {
+		return nil, errors.ErrNotFound
+	}
+	return job, nil
+}
+
+// run builds the archive for a job and records the outcome
+func (s *ExportService) run(job *repository.ExportJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportJobTimeout)
+	defer cancel()
+
+	job.Status = repository.ExportStatusRunning
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("Failed to update export job %s: %v", job.ID, err)
+	}
+
+	fileName := job.ID + ".zip"
+	size, err := s.writeArchive(ctx, job.UserID, filepath.Join(s.dir, fileName))
+
+	now := time.Now()
+	job.CompletedAt = &now
+	if err != nil {
+		log.Printf("Export job %s failed: %v", job.ID, err)
+		job.Status = repository.ExportStatusFailed
+		job.Error = "Export failed, please try again"
+	} else {
+		job.Status = repository.ExportStatusCompleted
+		job.FileName = fileName
+		job.SizeBytes = size
+	}
+
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		log.Printf("Failed to update export job %s: %v", job.ID, err)
+	}
+}
+
+// writeArchive writes the ZIP archive of a user's data and returns its size
+// The archive is written to a temporary file first so a partial archive is never served
+func (s *ExportService) writeArchive(ctx context.Context, userID, path string) (int64, error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	zw := zip.NewWriter(f)
+	if err := s.writeEntries(ctx, zw, userID); err != nil {
+		zw.Close()
+		f.Close()
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// writeEntries adds all of a user's data to the archive
+func (s *ExportService) writeEntries(ctx context.Context, zw *zip.Writer, userID string) error {
+	if err := writeZipFile(zw, "README.txt", []byte(exportReadme)); err != nil {
+		return err
+	}
+
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load user: %w", err)
+	}
+	if err := writeZipJSON(zw, "profile.json", toProfileResponse(u)); err != nil {
+		return err
+	}
+
+	if s.apiTokenRepo != nil {
+		tokens, err := s.apiTokenRepo.ListByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to load access tokens: %w", err)
+		}
+		if err := writeZipJSON(zw, "access_tokens.json", tokens); err != nil {
+			return err
+		}
+	}
+
+	t, err := s.textRepo.GetByUserID(ctx, userID)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("failed to load text: %w", err)
+	}
+	if t != nil {
+		if err := writeZipJSON(zw, "text.json", t); err != nil {
+			return err
+		}
+	}
+
+	docs, err := s.docRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to load documents: %w", err)
+	}
+	for _, doc := range docs {
+		if err := writeExportDocument(zw, doc, userID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeExportDocument adds a document's metadata and content to the archive
+func writeExportDocument(zw *zip.Writer, doc *document.Document, userID string) error {
+	role, folder := "owner", "owned"
+	if doc.OwnerID != userID {
+		role, folder = "collaborator", "shared"
+	}
+	base := fmt.Sprintf("documents/%s/%s/", folder, doc.ID)
+
+	err := writeZipJSON(zw, base+"metadata.json", exportDocumentMetadata{
+		ID:              doc.ID,
+		Title:           doc.Title,
+		Role:            role,
+		OwnerID:         doc.OwnerID,
+		CollaboratorIDs: doc.CollaboratorIDs,
+		CreatedAt:       doc.CreatedAt,
+		UpdatedAt:       doc.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sanitized in case the content was stored before sanitization on write
+	return writeZipFile(zw, base+"content.html", []byte(document.SanitizeContent(doc.Content)))
+}
+
+// writeZipJSON adds an indented JSON file to the archive
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return writeZipFile(zw, name, data)
+}
+
+// writeZipFile adds a file to the archive
+func writeZipFile(zw *zip.Writer, name string, data []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// toResponse converts a job to a response, signing a download link for completed jobs
+func (s *ExportService) toResponse(job *repository.ExportJob) (*ExportJobResponse, error) {
+	response := &ExportJobResponse{
+		ID:          job.ID,
+		Status:      job.Status,
+		Error:       job.Error,
+		SizeBytes:   job.SizeBytes,
+		CreatedAt:   job.CreatedAt,
+		CompletedAt: job.CompletedAt,
+		ExpiresAt:   job.ExpiresAt,
+	}
+
+	if job.Status == repository.ExportStatusCompleted {
+		ttl := time.Until(job.ExpiresAt)
+		if ttl <= 0 {
+			return response, nil
+		}
+		token, err := auth.GeneratePurposeToken(exportDownloadPurpose, job.UserID, map[string]string{
+			"job_id": job.ID,
+		}, ttl)
+		if err != nil {
+			return nil, errors.WrapError(errors.ErrInternalServer, err)
+		}
+		response.DownloadURL = fmt.Sprintf("%s/exports/%s/download?token=%s", s.apiBaseURL, job.ID, url.QueryEscape(token))
+	}
+
+	return response, nil
+}
+
+// cleanupLoop removes archives older than the retention period
+func (s *ExportService) cleanupLoop() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		entries, err := os.ReadDir(s.dir)
+		if err != nil {
+			continue
+		}
+
+		cutoff := time.Now().Add(-exportRetention)
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || entry.IsDir() || info.ModTime().After(cutoff) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(s.dir, entry.Name())); err != nil {
+				log.Printf("Failed to remove expired export %s: %v", entry.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,540 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/pkg/document"
+	"collaborative-editor/pkg/folder"
+	"collaborative-editor/pkg/workspace"
+)
+
+const (
+	// maxFolderDepth bounds how deeply folders can be nested
+	maxFolderDepth = 10
+	// maxFolderNameLength bounds folder names
+	maxFolderNameLength = 100
+)
+
+// FolderService handles folder-related business logic
+// Access checks are shared with DocumentService, since folder sharing cascades to documents
+type FolderService struct {
+	folderRepo repository.FolderRepository
+	docRepo    repository.DocumentRepository
+	userRepo   repository.UserRepository
+	docService *DocumentService
+}
+
+// NewFolderService creates a new folder service
+func NewFolderService(folderRepo repository.FolderRepository, docRepo repository.DocumentRepository, userRepo repository.UserRepository, docService *DocumentService) *FolderService {
+	return &FolderService{
+		folderRepo: folderRepo,
+		docRepo:    docRepo,
+		userRepo:   userRepo,
+		docService: docService,
+	}
+}
+
+// CreateFolderRequest represents a request to create a folder
+type CreateFolderRequest struct {
+	Name        string `json:"name"`
+	ParentID    string `json:"parent_id,omitempty"`    // Creates a subfolder
+	WorkspaceID string `json:"workspace_id,omitempty"` // Creates a top-level workspace folder
+}
+
+// RenameFolderRequest represents a request to rename a folder
+type RenameFolderRequest struct {
+	Name string `json:"name"`
+}
+
+// FolderResponse represents a folder response
+type FolderResponse struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	ParentID        string    `json:"parent_id,omitempty"`
+	OwnerID         string    `json:"owner_id"`
+	WorkspaceID     string    `json:"workspace_id,omitempty"`
+	CollaboratorIDs []string  `json:"collaborator_ids"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Breadcrumb is one step of the path to a folder
+type Breadcrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// FolderContentsResponse lists the children of a folder, or of the top level when Folder is nil
+type FolderContentsResponse struct {
+	Folder      *FolderResponse     `json:"folder,omitempty"`
+	Breadcrumbs []Breadcrumb        `json:"breadcrumbs"` // From the topmost folder the user can see down to Folder
+	Folders     []*FolderResponse   `json:"folders"`
+	Documents   []*DocumentResponse `json:"documents"`
+}
+
+// CreateFolder creates a folder at the top level, inside a workspace or inside another folder
+func (s *FolderService) CreateFolder(ctx context.Context, userID string, req *CreateFolderRequest) (*FolderResponse, error) {
+	name, err := validateFolderName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceID := req.WorkspaceID
+	if req.ParentID != "" {
+		parent, err := s.docService.requireFolderAccess(ctx, req.ParentID, userID, accessEdit)
+		if err != nil {
+			return nil, err
+		}
+		if workspaceID != "" && workspaceID != parent.WorkspaceID {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Parent folder belongs to a different workspace", nil)
+		}
+		chain, err := s.docService.folderChain(ctx, parent.ID)
+		if err != nil {
+			return nil, errors.WrapError(errors.ErrInternalServer, err)
+		}
+		if len(chain) >= maxFolderDepth {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("Folders can be nested at most %d levels deep", maxFolderDepth), nil)
+		}
+		workspaceID = parent.WorkspaceID
+	} else if workspaceID != "" {
+		if _, err := s.docService.requireWorkspaceRole(ctx, workspaceID, userID, workspace.RoleEditor); err != nil {
+			return nil, err
+		}
+	}
+
+	f := folder.NewFolder(name, req.ParentID, userID, workspaceID)
+	if err := s.folderRepo.Create(ctx, f); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to create folder: %w", err))
+	}
+
+	return toFolderResponse(f), nil
+}
+
+// GetFolder returns a folder with its subfolders, documents and breadcrumb path
+func (s *FolderService) GetFolder(ctx context.Context, userID, folderID string) (*FolderContentsResponse, error) {
+	f, err := s.docService.requireFolderAccess(ctx, folderID, userID, accessView)
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := s.docService.folderChain(ctx, f.ID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	subfolders, err := s.folderRepo.ListByParentID(ctx, f.ID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	docs, err := s.docRepo.ListByFolderID(ctx, f.ID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	return &FolderContentsResponse{
+		Folder:      toFolderResponse(f),
+		Breadcrumbs: s.breadcrumbs(ctx, chain, userID),
+		Folders:     toFolderResponses(subfolders),
+		Documents:   s.toDocumentResponses(docs),
+	}, nil
+}
+
+// ListRoot returns the top level of a user's folder tree: their top-level folders and documents,
+// plus folders and documents shared with them that they cannot reach through a parent folder
+// If workspaceID is set, returns the top level of that workspace instead (members only)
+func (s *FolderService) ListRoot(ctx context.Context, userID, workspaceID string) (*FolderContentsResponse, error) {
+	if workspaceID != "" {
+		return s.listWorkspaceRoot(ctx, userID, workspaceID)
+	}
+
+	folders, err := s.folderRepo.ListRootByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	docs, err := s.docRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	// Hide items the user already reaches through a visible folder or one of their workspaces
+	visible := map[string]bool{}
+	canView := func(folderID string) bool {
+		if _, ok := visible[folderID]; !ok {
+			visible[folderID] = s.docService.folderAccessByID(ctx, folderID, userID) >= accessView
+		}
+		return visible[folderID]
+	}
+	inWorkspace := func(workspaceID string) bool {
+		return workspaceID != "" && s.docService.workspaceRole(ctx, workspaceID, userID) != ""
+	}
+
+	rootFolders := []*folder.Folder{}
+	for _, f := range folders {
+		if f.ParentID != "" && canView(f.ParentID) {
+			continue
+		}
+		if f.ParentID == "" && inWorkspace(f.WorkspaceID) {
+			continue
+		}
+		rootFolders = append(rootFolders, f)
+	}
+
+	rootDocs := []*document.Document{}
+	for _, doc := range docs {
+		if doc.FolderID != "" && canView(doc.FolderID) {
+			continue
+		}
+		if doc.FolderID == "" && inWorkspace(doc.WorkspaceID) {
+			continue
+		}
+		rootDocs = append(rootDocs, doc)
+	}
+
+	return &FolderContentsResponse{
+		Breadcrumbs: []Breadcrumb{},
+		Folders:     toFolderResponses(rootFolders),
+		Documents:   s.toDocumentResponses(rootDocs),
+	}, nil
+}
+
+// listWorkspaceRoot returns the top-level folders and documents of a workspace
+func (s *FolderService) listWorkspaceRoot(ctx context.Context, userID, workspaceID string) (*FolderContentsResponse, error) {
+	if _, err := s.docService.requireWorkspaceRole(ctx, workspaceID, userID, workspace.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	folders, err := s.folderRepo.ListRootByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	docs, err := s.docRepo.ListByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	rootDocs := []*document.Document{}
+	for _, doc := range docs {
+		if doc.FolderID == "" {
+			rootDocs = append(rootDocs, doc)
+		}
+	}
+
+	return &FolderContentsResponse{
+		Breadcrumbs: []Breadcrumb{},
+		Folders:     toFolderResponses(folders),
+		Documents:   s.toDocumentResponses(rootDocs),
+	}, nil
+}
+
+// RenameFolder renames a folder (requires edit access)
+func (s *FolderService) RenameFolder(ctx context.Context, userID, folderID string, req *RenameFolderRequest) (*FolderResponse, error) {
+	f, err := s.docService.requireFolderAccess(ctx, folderID, userID, accessEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := validateFolderName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Name = name
+	if err := s.save(ctx, f); err != nil {
+		return nil, err
+	}
+
+	return toFolderResponse(f), nil
+}
+
+// MoveFolder moves a folder into another folder, or to the top level when the folder ID is empty
+// Requires managing the folder and edit access to the target, which must be in the same workspace
+func (s *FolderService) MoveFolder(ctx context.Context, userID, folderID string, req *MoveToFolderRequest) (*FolderResponse, error) {
+	f, err := s.docService.requireFolderAccess(ctx, folderID, userID, accessManage)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FolderID != "" {
+		target, err := s.docService.requireFolderAccess(ctx, req.FolderID, userID, accessEdit)
+		if err != nil {
+			return nil, err
+		}
+		if target.WorkspaceID != f.WorkspaceID {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Folders must belong to the same workspace", nil)
+		}
+
+		chain, err := s.docService.folderChain(ctx, target.ID)
+		if err != nil {
+			return nil, errors.WrapError(errors.ErrInternalServer, err)
+		}
+		if slices.ContainsFunc(chain, func(ancestor *folder.Folder) bool { return ancestor.ID == f.ID }) {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Cannot move a folder into itself", nil)
+		}
+
+		height, err := s.subtreeHeight(ctx, f.ID, maxFolderDepth)
+		if err != nil {
+			return nil, errors.WrapError(errors.ErrInternalServer, err)
+		}
+		if len(chain)+height > maxFolderDepth {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("Folders can be nested at most %d levels deep", maxFolderDepth), nil)
+		}
+	}
+
+	f.ParentID = req.FolderID
+	if err := s.save(ctx, f); err != nil {
+		return nil, err
+	}
+
+	return toFolderResponse(f), nil
+}
+
+// DeleteFolder deletes a folder (requires managing it)
+// Its subfolders and documents are not deleted; they move up to the folder's parent
+func (s *FolderService) DeleteFolder(ctx context.Context, userID, folderID string) error {
+	f, err := s.docService.requireFolderAccess(ctx, folderID, userID, accessManage)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteFolder(ctx, f)
+}
+
+// AddCollaborator shares a folder, and everything inside it, with a user
+func (s *FolderService) AddCollaborator(ctx context.Context, userID, folderID string, req *AddCollaboratorRequest) (*FolderResponse, error) {
+	f, err := s.docService.requireFolderAccess(ctx, folderID, userID, accessManage)
+	if err != nil {
+		return nil, err
+	}
+
+	collaborator, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrNotFound.Code, "User not found with this email", nil)
+	}
+
+	if s.docService.verificationPolicy.RequireForCollaborators && !collaborator.Verified {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "User has not verified their email address", nil)
+	}
+
+	if collaborator.ID == f.OwnerID {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Owner is already a collaborator", nil)
+	}
+	if slices.Contains(f.CollaboratorIDs, collaborator.ID) {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "User is already a collaborator", nil)
+	}
+
+	f.CollaboratorIDs = append(f.CollaboratorIDs, collaborator.ID)
+	if err := s.save(ctx, f); err != nil {
+		return nil, err
+	}
+
+	return toFolderResponse(f), nil
+}
+
+// RemoveCollaborator stops sharing a folder with a user
+// Requires managing the folder, except for collaborators removing themselves
+func (s *FolderService) RemoveCollaborator(ctx context.Context, userID, folderID, collaboratorID string) error {
+	level := accessManage
+	if collaboratorID == userID {
+		level = accessView
+	}
+
+	f, err := s.docService.requireFolderAccess(ctx, folderID, userID, level)
+	if err != nil {
+		return err
+	}
+
+	if !slices.Contains(f.CollaboratorIDs, collaboratorID) {
+		return errors.NewAppError(errors.ErrNotFound.Code, "Collaborator not found", nil)
+	}
+
+	f.CollaboratorIDs = slices.DeleteFunc(f.CollaboratorIDs, func(id string) bool {
+		return id == collaboratorID
+	})
+	return s.save(ctx, f)
+}
+
+// ReleaseUserFolders detaches a user from all folders before their account is deleted
+// The user is removed from every collaborator list. Owned workspace folders go to another
+// workspace member; with the transfer policy, other owned folders go to transferToID if set,
+// otherwise to their first collaborator. The remaining owned folders are deleted, and
+// their contents move up to the parent folder
+func (s *FolderService) ReleaseUserFolders(ctx context.Context, userID, policy, transferToID string) error {
+	folders, err := s.folderRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to list folders: %w", err))
+	}
+
+	for _, listed := range folders {
+		// Reload, since deleting an earlier folder may have moved this one
+		f, err := s.folderRepo.GetByID(ctx, listed.ID)
+		if err != nil {
+			return errors.WrapError(errors.ErrInternalServer, err)
+		}
+
+		f.CollaboratorIDs = slices.DeleteFunc(f.CollaboratorIDs, func(id string) bool {
+			return id == userID
+		})
+
+		if f.OwnerID == userID {
+			newOwnerID := s.docService.workspaceSuccessor(ctx, f.WorkspaceID, userID, transferToID)
+			if newOwnerID == "" && policy == DocumentPolicyTransfer {
+				newOwnerID = transferToID
+				if newOwnerID == "" && len(f.CollaboratorIDs) > 0 {
+					newOwnerID = f.CollaboratorIDs[0]
+				}
+			}
+
+			if newOwnerID == "" {
+				if err := s.deleteFolder(ctx, f); err != nil {
+					return err
+				}
+				continue
+			}
+
+			f.OwnerID = newOwnerID
+			f.CollaboratorIDs = slices.DeleteFunc(f.CollaboratorIDs, func(id string) bool {
+				return id == newOwnerID
+			})
+		}
+
+		if err := s.save(ctx, f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteFolder moves a folder's subfolders and documents up to its parent and deletes it
+func (s *FolderService) deleteFolder(ctx context.Context, f *folder.Folder) error {
+	subfolders, err := s.folderRepo.ListByParentID(ctx, f.ID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	for _, subfolder := range subfolders {
+		subfolder.ParentID = f.ParentID
+		if err := s.save(ctx, subfolder); err != nil {
+			return err
+		}
+	}
+
+	docs, err := s.docRepo.ListByFolderID(ctx, f.ID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	for _, doc := range docs {
+		doc.FolderID = f.ParentID
+		doc.UpdatedAt = time.Now()
+		if err := s.docRepo.Update(ctx, doc); err != nil {
+			return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to move document %s: %w", doc.ID, err))
+		}
+	}
+
+	if err := s.folderRepo.Delete(ctx, f.ID); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to delete folder: %w", err))
+	}
+	return nil
+}
+
+// subtreeHeight returns the number of folder levels from a folder down to its deepest descendant
+// It stops counting at limit
+func (s *FolderService) subtreeHeight(ctx context.Context, folderID string, limit int) (int, error) {
+	if limit <= 0 {
+		return 1, nil
+	}
+
+	children, err := s.folderRepo.ListByParentID(ctx, folderID)
+	if err != nil {
+		return 0, err
+	}
+
+	height := 1
+	for _, child := range children {
+		childHeight, err := s.subtreeHeight(ctx, child.ID, limit-1)
+		if err != nil {
+			return 0, err
+		}
+		height = max(height, childHeight+1)
+	}
+	return height, nil
+}
+
+// breadcrumbs returns the path to the first folder of a chain, starting at the topmost folder the user can see
+func (s *FolderService) breadcrumbs(ctx context.Context, chain []*folder.Folder, userID string) []Breadcrumb {
+	// Access cascades down, so the topmost visible folder is the first one, from the root, the user can access
+	start := 0
+	for i := len(chain) - 1; i >= 0; i-- {
+		if s.docService.folderAccess(ctx, chain[i:], userID) >= accessView {
+			start = i
+			break
+		}
+	}
+
+	crumbs := make([]Breadcrumb, 0, start+1)
+	for i := start; i >= 0; i-- {
+		crumbs = append(crumbs, Breadcrumb{ID: chain[i].ID, Name: chain[i].Name})
+	}
+	return crumbs
+}
+
+// save stores a folder after updating its timestamp
+func (s *FolderService) save(ctx context.Context, f *folder.Folder) error {
+	f.UpdatedAt = time.Now()
+	if err := s.folderRepo.Update(ctx, f); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update folder: %w", err))
+	}
+	return nil
+}
+
+// toDocumentResponses converts documents to responses
+func (s *FolderService) toDocumentResponses(docs []*document.Document) []*DocumentResponse {
+	responses := make([]*DocumentResponse, 0, len(docs))
+	for _, doc := range docs {
+		responses = append(responses, s.docService.toResponse(doc))
+	}
+	return responses
+}
+
+// toFolderResponse converts a folder to a response
+func toFolderResponse(f *folder.Folder) *FolderResponse {
+	return &FolderResponse{
+		ID:              f.ID,
+		Name:            f.Name,
+		ParentID:        f.ParentID,
+		OwnerID:         f.OwnerID,
+		WorkspaceID:     f.WorkspaceID,
+		CollaboratorIDs: f.CollaboratorIDs,
+		CreatedAt:       f.CreatedAt,
+		UpdatedAt:       f.UpdatedAt,
+	}
+}
+
+// toFolderResponses converts folders to responses
+func toFolderResponses(folders []*folder.Folder) []*FolderResponse {
+	responses := make([]*FolderResponse, 0, len(folders))
+	for _, f := range folders {
+		responses = append(responses, toFolderResponse(f))
+	}
+	return responses
+}
+
+// validateFolderName trims and checks a folder name
+func validateFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "Name is required", nil)
+	}
+	if len(name) > maxFolderNameLength {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("Name must be at most %d characters", maxFolderNameLength), nil)
+	}
+	if strings.ContainsAny(name, "/\\") {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "Name cannot contain slashes", nil)
+	}
+	return name, nil
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/repository"
+)
+
+// Reasons recorded in the login audit log
+const (
+	LoginFailureUnknownUser     = "unknown_user"
+	LoginFailureInvalidPassword = "invalid_password"
+	LoginFailureInvalidMFACode  = "invalid_mfa_code"
+	LoginFailureLocked          = "locked"
+)
+
+// LoginThrottlePolicy configures backoff and lockout for one kind of key
+type LoginThrottlePolicy struct {
+	// BackoffAfter is the number of failures after which each further attempt must wait
+	BackoffAfter int
+	// BaseDelay is the wait after the first failure beyond BackoffAfter; it doubles with every failure
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff
+	MaxDelay time.Duration
+	// LockoutAfter is the number of failures after which the key is locked out
+	LockoutAfter int
+	// LockoutDuration is how long a lockout lasts after the last failure
+	LockoutDuration time.Duration
+	// ResetAfter is how long after the last failure the counter is forgotten
+	ResetAfter time.Duration
+}
+
+// DefaultAccountThrottlePolicy is applied per account
+var DefaultAccountThrottlePolicy = LoginThrottlePolicy{
+	BackoffAfter:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutAfter:    10,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+// DefaultIPThrottlePolicy is applied per client IP; it is more lenient since
+// several users may share an address
+var DefaultIPThrottlePolicy = LoginThrottlePolicy{
+	BackoffAfter:    20,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutAfter:    100,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+// retryAfter returns how long the key must wait before the next attempt (0 if none)
+func (p LoginThrottlePolicy) retryAfter(attempts *repository.LoginAttempts, now time.Time) time.Duration {
+	if attempts == nil || attempts.Failures == 0 {
+		return 0
+	}
+
+	var wait time.Duration
+	switch {
+	case p.LockoutAfter > 0 && attempts.Failures >= p.LockoutAfter:
+		wait = p.LockoutDuration
+	case attempts.Failures >= p.BackoffAfter:
+		exponent := float64(attempts.Failures - p.BackoffAfter)
+		wait = time.Duration(float64(p.BaseDelay) * math.Pow(2, exponent))
+		if wait > p.MaxDelay || wait <= 0 {
+			wait = p.MaxDelay
+		}
+	default:
+		return 0
+	}
+
+	remaining := attempts.LastFailureAt.Add(wait).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// LoginGuard protects login against brute-force attacks by tracking failed
+// attempts per account and per client IP
+type LoginGuard struct {
+	store         repository.LoginAttemptStore
+	auditRepo     repository.LoginAuditRepository
+	accountPolicy LoginThrottlePolicy
+	ipPolicy      LoginThrottlePolicy
+}
+
+// NewLoginGuard creates a new login guard with the default policies
+// auditRepo may be nil, in which case failures are only logged
+func NewLoginGuard(store repository.LoginAttemptStore, auditRepo repository.LoginAuditRepository) *LoginGuard {
+	return &LoginGuard{
+		store:         store,
+		auditRepo:     auditRepo,
+		accountPolicy: DefaultAccountThrottlePolicy,
+		ipPolicy:      DefaultIPThrottlePolicy,
+	}
+}
+
+// accountKey builds the counter key for an account (or an unknown identifier)
+func accountKey(id string) string {
+	return "account:" + id
+}
+
+// ipKey builds the counter key for a client IP
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// CheckIP returns an error if the client IP must wait before attempting to log in
+func (g *LoginGuard) CheckIP(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	return g.check(ctx, ipKey(ip), g.ipPolicy)
+}
+
+// CheckAccount returns an error if the account must wait before the next attempt
+func (g *LoginGuard) CheckAccount(ctx context.Context, accountID string) error {
+	return g.check(ctx, accountKey(accountID), g.accountPolicy)
+}
+
+// RecordFailure counts a failed attempt against the account and the IP and writes an audit record
+func (g *LoginGuard) RecordFailure(ctx context.Context, accountID, identifier, userID, ip, reason string) {
+	if _, err := g.store.RecordFailure(ctx, accountKey(accountID), g.accountPolicy.ResetAfter); err != nil {
+		log.Printf("Failed to record login failure for account: %v", err)
+	}
+	if ip != "" {
+		if _, err := g.store.RecordFailure(ctx, ipKey(ip), g.ipPolicy.ResetAfter); err != nil {
+			log.Printf("Failed to record login failure for IP %s: %v", ip, err)
+		}
+	}
+
+	g.audit(ctx, identifier, userID, ip, reason)
+}
+
+// RecordBlocked writes an audit record of an attempt rejected because of backoff or lockout
+// Blocked attempts are not counted, so waiting out a lockout is always enough
+func (g *LoginGuard) RecordBlocked(ctx context.Context, identifier, userID, ip string) {
+	g.audit(ctx, identifier, userID, ip, LoginFailureLocked)
+}
+
+// RecordSuccess clears the account counter after a successful login
+// The IP counter is left alone so logging into one account does not reset attempts on others
+func (g *LoginGuard) RecordSuccess(ctx context.Context, accountID string) {
+	if err := g.store.Reset(ctx, accountKey(accountID)); err != nil {
+		log.Printf("Failed to reset login attempts: %v", err)
+	}
+}
+
+// check evaluates a key against its policy
+func (g *LoginGuard) check(ctx context.Context, key string, policy LoginThrottlePolicy) error {
+	attempts, err := g.store.Get(ctx, key)
+	if err != nil {
+		// Fail open: an unavailable counter store must not lock everyone out
+		log.Printf("Failed to read login attempts: %v", err)
+		return nil
+	}
+
+	if wait := policy.retryAfter(attempts, time.Now()); wait > 0 {
+		seconds := int(math.Ceil(wait.Seconds()))
+		return errors.NewAppError(
+			errors.ErrTooManyRequests.Code,
+			fmt.Sprintf("Too many failed login attempts, try again in %d seconds", seconds),
+			nil,
+		)
+	}
+
+	return nil
+}
+
+// audit writes an audit record of a failed login attempt
+func (g *LoginGuard) audit(ctx context.Context, identifier, userID, ip, reason string) {
+	log.Printf("Failed login attempt (identifier: %s, user: %s, ip: %s, reason: %s)", identifier, userID, ip, reason)
+
+	if g.auditRepo == nil {
+		return
+	}
+
+	err := g.auditRepo.Record(ctx, &repository.LoginAuditEntry{
+		Identifier: identifier,
+		UserID:     userID,
+		IP:         ip,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Printf("Failed to write login audit record: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/search"
+)
+
+const (
+	// searchDefaultLimit and searchMaxLimit bound the number of search results
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+	// searchMaxQueryLength bounds the length of a search query
+	searchMaxQueryLength = 200
+	// searchMaxClauses bounds the number of words and phrases in a query
+	searchMaxClauses = 10
+	// searchMaxCandidates bounds how many hits are checked for access per search
+	searchMaxCandidates = 500
+)
+
+// SearchService handles full-text search across documents
+type SearchService struct {
+	index      search.Index
+	docService *DocumentService
+}
+
+// NewSearchService creates a new search service
+// The document service must use the same index so it stays up to date
+func NewSearchService(index search.Index, docService *DocumentService) *SearchService {
+	return &SearchService{
+		index:      index,
+		docService: docService,
+	}
+}
+
+// SearchResult represents a document matching a search
+type SearchResult struct {
+	ID             string    `json:"id"`
+	Title          string    `json:"title"`
+	TitleHighlight string    `json:"title_highlight"` // HTML with matches wrapped in <mark>
+	Snippet        string    `json:"snippet"`         // HTML with matches wrapped in <mark>
+	Score          float64   `json:"score"`
+	OwnerID        string    `json:"owner_id"`
+	WorkspaceID    string    `json:"workspace_id,omitempty"`
+	FolderID       string    `json:"folder_id,omitempty"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SearchResponse represents the results of a search
+type SearchResponse struct {
+	Query   string          `json:"query"`
+	Results []*SearchResult `json:"results"`
+}
+
+// RebuildIndex indexes every document in the database
+func (s *SearchService) RebuildIndex(ctx context.Context) (int, error) {
+	docs, err := s.docService.docRepo.ListAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	for _, doc := range docs {
+		if err := s.index.Index(toSearchDocument(doc)); err != nil {
+			return 0, fmt.Errorf("failed to index document %s: %w", doc.ID, err)
+		}
+	}
+	return len(docs), nil
+}
+
+// Search finds the documents the user can access whose title or content matches the query
+func (s *SearchService) Search(ctx context.Context, userID, rawQuery string, limit int) (*SearchResponse, error) {
+	rawQuery = strings.TrimSpace(rawQuery)
+	if rawQuery == "" {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Query is required", nil)
+	}
+	if len(rawQuery) > searchMaxQueryLength {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("Query must be at most %d characters", searchMaxQueryLength), nil)
+	}
+
+	query := search.ParseQuery(rawQuery)
+	if len(query.Clauses) > searchMaxClauses {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("Query can have at most %d words or phrases", searchMaxClauses), nil)
+	}
+
+	if limit <= 0 {
+		limit = searchDefaultLimit
+	}
+	limit = min(limit, searchMaxLimit)
+
+	hits, err := s.index.Search(query)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	// The index knows nothing about permissions, so check access on each hit, best first
+	results := []*SearchResult{}
+	for i, hit := range hits {
+		if len(results) == limit || i == searchMaxCandidates {
+			break
+		}
+
+		doc, err := s.docService.docRepo.GetByID(ctx, hit.ID)
+		if err != nil {
+			// Deleted since it was indexed
+			continue
+		}
+		if !s.docService.hasAccess(ctx, doc, userID) {
+			continue
+		}
+
+		results = append(results, &SearchResult{
+			ID:             doc.ID,
+			Title:          doc.Title,
+			TitleHighlight: hit.TitleHighlight,
+			Snippet:        hit.Snippet,
+			Score:          hit.Score,
+			OwnerID:        doc.OwnerID,
+			WorkspaceID:    doc.WorkspaceID,
+			FolderID:       doc.FolderID,
+			UpdatedAt:      doc.UpdatedAt,
+		})
+	}
+
+	return &SearchResponse{Query: rawQuery, Results: results}, nil
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/auth"
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/pkg/user"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// mfaTokenPurpose is the purpose of the token handed out between the two login steps
+	mfaTokenPurpose = "mfa"
+	// mfaTokenTTL is how long the user has to complete the second login step
+	mfaTokenTTL = 5 * time.Minute
+	// recoveryCodeCount is the number of recovery codes generated on enrollment
+	recoveryCodeCount = 10
+	// totpIssuer is the issuer name shown in authenticator apps
+	totpIssuer = "Collaborative Editor"
+)
+
+// EnrollTwoFactorResponse represents the data needed to set up an authenticator app
+type EnrollTwoFactorResponse struct {
+	Secret        string   `json:"secret"`
+	OTPAuthURI    string   `json:"otpauth_uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+	Message       string   `json:"message"`
+}
+
+// TwoFactorCodeRequest represents a request carrying a TOTP or recovery code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+	IP   string `json:"-"` // Client IP, set by the handler
+}
+
+// DisableTwoFactorRequest represents a request to disable two-factor authentication
+type DisableTwoFactorRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+	IP       string `json:"-"` // Client IP, set by the handler
+}
+
+// LoginMFARequest represents the second step of a login with two-factor authentication
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"` // TOTP code or recovery code
+	IP       string `json:"-"`    // Client IP, set by the handler
+}
+
+// RecoveryCodesResponse represents newly generated recovery codes
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Message       string   `json:"message"`
+}
+
+// EnrollTwoFactor generates a new TOTP secret and recovery codes for the user
+// 2FA is only enabled once a code from the authenticator app is verified
+func (s *UserService) EnrollTwoFactor(ctx context.Context, userID string) (*EnrollTwoFactorResponse, error) {
+	u, err := s.getUserForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTP.Enabled {
+		return nil, errors.NewAppError(errors.ErrConflict.Code, "Two-factor authentication is already enabled", nil)
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	u.TOTP = user.TOTP{
+		Secret:             secret,
+		RecoveryCodeHashes: hashes,
+	}
+	if err := s.saveUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return &EnrollTwoFactorResponse{
+		Secret:        secret,
+		OTPAuthURI:    auth.TOTPURI(totpIssuer, u.Email, secret),
+		RecoveryCodes: codes,
+		Message:       "Scan the URI with an authenticator app and verify a code to enable two-factor authentication",
+	}, nil
+}
+
+// VerifyTwoFactor enables two-factor authentication after checking a code from the new secret
+func (s *UserService) VerifyTwoFactor(ctx context.Context, userID string, req *TwoFactorCodeRequest) (*MessageResponse, error) {
+	u, err := s.getUserForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.TOTP.Enabled {
+		return nil, errors.NewAppError(errors.ErrConflict.Code, "Two-factor authentication is already enabled", nil)
+	}
+	if u.TOTP.Secret == "" {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Two-factor enrollment has not been started", nil)
+	}
+
+	step, ok := auth.ValidateTOTP(u.TOTP.Secret, req.Code, time.Now())
+	if !ok {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Invalid verification code", nil)
+	}
+
+	u.TOTP.Enabled = true
+	u.TOTP.LastUsedStep = step
+	if err := s.saveUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return &MessageResponse{
+		Message: "Two-factor authentication enabled",
+	}, nil
+}
+
+// DisableTwoFactor turns off two-factor authentication
+// Requires the account password (if the account has one) and a current TOTP or recovery code
+func (s *UserService) DisableTwoFactor(ctx context.Context, userID string, req *DisableTwoFactorRequest) (*MessageResponse, error) {
+	u, err := s.getUserForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.TOTP.Enabled {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Two-factor authentication is not enabled", nil)
+	}
+
+	if u.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(strings.TrimSpace(req.Password))); err != nil {
+			return nil, errors.NewAppError(errors.ErrUnauthorized.Code, "Password is incorrect", nil)
+		}
+	}
+
+	if err := s.verifySecondFactor(ctx, u, req.IP, func() bool { return s.checkSecondFactor(u, req.Code) }); err != nil {
+		return nil, err
+	}
+
+	u.TOTP = user.TOTP{}
+	if err := s.saveUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return &MessageResponse{
+		Message: "Two-factor authentication disabled",
+	}, nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current TOTP code
+func (s *UserService) RegenerateRecoveryCodes(ctx context.Context, userID string, req *TwoFactorCodeRequest) (*RecoveryCodesResponse, error) {
+	u, err := s.getUserForUpdate(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.TOTP.Enabled {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Two-factor authentication is not enabled", nil)
+	}
+
+	var step int64
+	err = s.verifySecondFactor(ctx, u, req.IP, func() bool {
+		var ok bool
+		step, ok = auth.ValidateTOTP(u.TOTP.Secret, req.Code, time.Now())
+		return ok && step > u.TOTP.LastUsedStep
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	u.TOTP.LastUsedStep = step
+	u.TOTP.RecoveryCodeHashes = hashes
+	if err := s.saveUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{
+		RecoveryCodes: codes,
+		Message:       "New recovery codes generated, previous codes no longer work",
+	}, nil
+}
+
+// LoginMFA completes a login with the MFA challenge token and a TOTP or recovery code
+func (s *UserService) LoginMFA(ctx context.Context, req *LoginMFARequest) (*LoginResponse, error) {
+	if req.MFAToken == "" || strings.TrimSpace(req.Code) == "" {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "MFA token and code are required", nil)
+	}
+
+	claims, err := auth.ValidatePurposeToken(req.MFAToken, mfaTokenPurpose)
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrUnauthorized.Code, "Invalid or expired MFA token", err)
+	}
+
+	u, err := s.userRepo.GetByID(ctx, claims.Subject)
+	if err != nil || !u.TOTP.Enabled {
+		return nil, errors.NewAppError(errors.ErrUnauthorized.Code, "Invalid or expired MFA token", err)
+	}
+
+	// Code guesses are throttled together with password guesses
+	if s.loginGuard != nil {
+		if err := s.loginGuard.CheckIP(ctx, req.IP); err != nil {
+			s.loginGuard.RecordBlocked(ctx, u.Email, u.ID, req.IP)
+			return nil, err
+		}
+		if err := s.loginGuard.CheckAccount(ctx, u.ID); err != nil {
+			s.loginGuard.RecordBlocked(ctx, u.Email, u.ID, req.IP)
+			return nil, err
+		}
+	}
+
+	if !s.checkSecondFactor(u, req.Code) {
+		if s.loginGuard != nil {
+			s.loginGuard.RecordFailure(ctx, u.ID, u.Email, u.ID, req.IP, LoginFailureInvalidMFACode)
+		}
+		return nil, errors.NewAppError(errors.ErrUnauthorized.Code, "Invalid two-factor code", nil)
+	}
+
+	// Persist the used step or consumed recovery code
+	if err := s.saveUser(ctx, u); err != nil {
+		return nil, err
+	}
+
+	if s.loginGuard != nil {
+		s.loginGuard.RecordSuccess(ctx, u.ID)
+	}
+
+	token, err := auth.GenerateToken(u.ID, u.Username, u.Email)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to generate token: %w", err))
+	}
+
+	return &LoginResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Email:     u.Email,
+		Verified:  u.Verified,
+		CreatedAt: u.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Token:     token,
+		Message:   "Login successful",
+	}, nil
+}
+
+// mfaChallenge returns the first-step login response for an account with 2FA enabled
+func (s *UserService) mfaChallenge(u *user.User) (*LoginResponse, error) {
+	mfaToken, err := auth.GeneratePurposeToken(mfaTokenPurpose, u.ID, nil, mfaTokenTTL)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to generate MFA token: %w", err))
+	}
+
+	return &LoginResponse{
+		ID:          u.ID,
+		Username:    u.Username,
+		Email:       u.Email,
+		Verified:    u.Verified,
+		CreatedAt:   u.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Message:     "Two-factor authentication required",
+		MFARequired: true,
+		MFAToken:    mfaToken,
+	}, nil
+}
+
+// checkSecondFactor checks a TOTP code or recovery code and records its use on the user
+// The caller is responsible for saving the user
+func (s *UserService) checkSecondFactor(u *user.User, code string) bool {
+	if step, ok := auth.ValidateTOTP(u.TOTP.Secret, code, time.Now()); ok {
+		if step <= u.TOTP.LastUsedStep {
+			return false
+		}
+		u.TOTP.LastUsedStep = step
+		return true
+	}
+
+	hash := hashRecoveryCode(code)
+	if i := slices.Index(u.TOTP.RecoveryCodeHashes, hash); i >= 0 {
+		u.TOTP.RecoveryCodeHashes = slices.Delete(u.TOTP.RecoveryCodeHashes, i, i+1)
+		return true
+	}
+
+	return false
+}
+
+// verifySecondFactor runs a second-factor check for a signed-in account change through the login guard,
+// so a stolen session cannot guess codes any faster than a login can
+func (s *UserService) verifySecondFactor(ctx context.Context, u *user.User, ip string, check func() bool) error {
+	if s.loginGuard != nil {
+		if err := s.loginGuard.CheckIP(ctx, ip); err != nil {
+			s.loginGuard.RecordBlocked(ctx, u.Email, u.ID, ip)
+			return err
+		}
+		if err := s.loginGuard.CheckAccount(ctx, u.ID); err != nil {
+			s.loginGuard.RecordBlocked(ctx, u.Email, u.ID, ip)
+			return err
+		}
+	}
+
+	if !check() {
+		if s.loginGuard != nil {
+			s.loginGuard.RecordFailure(ctx, u.ID, u.Email, u.ID, ip, LoginFailureInvalidMFACode)
+		}
+		return errors.NewAppError(errors.ErrUnauthorized.Code, "Invalid two-factor code", nil)
+	}
+
+	if s.loginGuard != nil {
+		s.loginGuard.RecordSuccess(ctx, u.ID)
+	}
+	return nil
+}
+
+// getUserForUpdate loads a user, mapping lookup failures to app errors
+func (s *UserService) getUserForUpdate(ctx context.Context, userID string) (*user.User, error) {
+	u, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return u, nil
+}
+
+// saveUser stores changes to a user
+func (s *UserService) saveUser(ctx context.Context, u *user.User) error {
+	u.UpdatedAt = time.Now()
+	if err := s.userRepo.Update(ctx, u); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update user: %w", err))
+	}
+	return nil
+}
+
+// newRecoveryCodes generates recovery codes and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode creates a SHA256 hash of a normalized recovery code for storage
+func hashRecoveryCode(code string) string {
+	hash := sha256.Sum256([]byte(auth.NormalizeRecoveryCode(code)))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/events"
+	"collaborative-editor/pkg/webhook"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// webhookQueueSize bounds how many events can wait for the dispatcher before new ones are dropped
+	webhookQueueSize = 1024
+	// maxConcurrentWebhookDeliveries bounds how many requests are sent at the same time
+	maxConcurrentWebhookDeliveries = 8
+	// webhookTimeout bounds a single delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookResponseBodyLimit is how much of a receiver's response is kept in the delivery log
+	webhookResponseBodyLimit = 1024
+)
+
+// webhookRetryDelays are the waits before each retry of a failed delivery; a delivery is
+// given up after the last one. Retries are kept in memory and do not survive a restart
+var webhookRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// webhookPayload is the JSON body posted to webhooks
+type webhookPayload struct {
+	ID          string      `json:"id"` // The event's ID
+	Event       string      `json:"event"`
+	OccurredAt  time.Time   `json:"occurred_at"`
+	ActorID     string      `json:"actor_id,omitempty"`
+	DocumentID  string      `json:"document_id,omitempty"`
+	WorkspaceID string      `json:"workspace_id,omitempty"`
+	Data        interface{} `json:"data"`
+}
+
+// webhookJob is one event to deliver to one webhook, across all its attempts
+type webhookJob struct {
+	webhookID  string
+	deliveryID string
+	eventID    string
+	eventType  string
+	body       []byte
+	attempt    int
+}
+
+// newWebhookJob encodes an event for delivery to a webhook
+func newWebhookJob(webhookID string, event events.Event) (*webhookJob, error) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		ID:          event.ID,
+		Event:       event.Type,
+		OccurredAt:  event.OccurredAt,
+		ActorID:     event.ActorID,
+		DocumentID:  event.DocumentID,
+		WorkspaceID: event.WorkspaceID,
+		Data:        event.Data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	return &webhookJob{
+		webhookID:  webhookID,
+		deliveryID: uuid.New().String(),
+		eventID:    event.ID,
+		eventType:  event.Type,
+		body:       body,
+		attempt:    1,
+	}, nil
+}
+
+// HandleEvent queues an event from the event bus for delivery; it never blocks the publisher
+// Events are dropped and logged if the queue is full
+func (s *WebhookService) HandleEvent(event events.Event) {
+	if event.Type == events.DocumentPurged {
+		go s.deleteDocumentWebhooks(event.DocumentID)
+		return
+	}
+	if !slices.Contains(WebhookEvents, event.Type) {
+		return
+	}
+
+	select {
+	case s.queue <- event:
+	default:
+		log.Printf("Webhook queue is full, dropping %s event %s", event.Type, event.ID)
+	}
+}
+
+// RunDispatcher delivers queued events to the webhooks subscribed to them
+func (s *WebhookService) RunDispatcher() {
+	for event := range s.queue {
+		s.dispatch(event)
+	}
+}
+
+// dispatch finds the webhooks of an event's document and workspace and starts delivering to them
+func (s *WebhookService) dispatch(event events.Event) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	hooks, err := s.webhookRepo.ListByDocumentID(ctx, event.DocumentID)
+	if err != nil {
+		log.Printf("Failed to list webhooks of document %s: %v", event.DocumentID, err)
+	}
+	if event.WorkspaceID != "" {
+		workspaceHooks, err := s.webhookRepo.ListByWorkspaceID(ctx, event.WorkspaceID)
+		if err != nil {
+			log.Printf("Failed to list webhooks of workspace %s: %v", event.WorkspaceID, err)
+		}
+		hooks = append(hooks, workspaceHooks...)
+	}
+
+	for _, hook := range hooks {
+		if !hook.Subscribes(event.Type) {
+			continue
+		}
+		job, err := newWebhookJob(hook.ID, event)
+		if err != nil {
+			log.Printf("Failed to prepare webhook %s: %v", hook.ID, err)
+			continue
+		}
+		go s.deliver(job)
+	}
+}
+
+// deliver makes one attempt at a delivery and schedules a retry if it fails
+// The webhook is reloaded first, so deleted or paused webhooks stop receiving retries, and
+// webhooks whose creator can no longer manage them are paused instead of delivered
+func (s *WebhookService) deliver(job *webhookJob) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*webhookTimeout)
+	defer cancel()
+
+	hook, err := s.webhookRepo.GetByID(ctx, job.webhookID)
+	if err != nil {
+		log.Printf("Dropping delivery %s: %v", job.deliveryID, err)
+		return
+	}
+	if !hook.Active {
+		return
+	}
+	if err := s.authorizeCreator(ctx, hook); err != nil {
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrForbidden.Code {
+			s.deactivate(ctx, hook)
+		} else {
+			log.Printf("Dropping delivery %s: %v", job.deliveryID, err)
+		}
+		return
+	}
+
+	delivery := s.send(ctx, hook, job)
+	if !delivery.Success && job.attempt <= len(webhookRetryDelays) {
+		delay := webhookRetryDelays[job.attempt-1]
+		retryAt := time.Now().Add(delay)
+		delivery.NextRetryAt = &retryAt
+
+		job.attempt++
+		time.AfterFunc(delay, func() { s.deliver(job) })
+	}
+	s.record(delivery)
+}
+
+// send posts a job's payload to a webhook, signed with its secret, and describes the outcome
+// Only 2xx responses count as success; redirects are not followed
+func (s *WebhookService) send(ctx context.Context, hook *webhook.Webhook, job *webhookJob) *webhook.Delivery {
+	now := time.Now()
+	delivery := &webhook.Delivery{
+		ID:          uuid.New().String(),
+		WebhookID:   hook.ID,
+		DeliveryID:  job.deliveryID,
+		EventID:     job.eventID,
+		Event:       job.eventType,
+		Attempt:     job.attempt,
+		URL:         hook.URL,
+		RequestBody: string(job.body),
+		CreatedAt:   now,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(job.body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "collaborative-editor-webhooks")
+	req.Header.Set(webhook.EventHeader, job.eventType)
+	req.Header.Set(webhook.DeliveryHeader, job.deliveryID)
+	req.Header.Set(webhook.TimestampHeader, fmt.Sprintf("%d", now.Unix()))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(hook.Secret, now, job.body))
+
+	resp, err := s.client.Do(req)
+	delivery.DurationMs = time.Since(now).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodyLimit))
+	delivery.StatusCode = resp.StatusCode
+	delivery.ResponseBody = string(body)
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("unexpected status %d", resp.StatusCode)
+	}
+	return delivery
+}
+
+// record stores a delivery attempt in the log; failures are only logged
+func (s *WebhookService) record(delivery *webhook.Delivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	if err := s.webhookRepo.RecordDelivery(ctx, delivery); err != nil {
+		log.Printf("Failed to record delivery %s of webhook %s: %v", delivery.DeliveryID, delivery.WebhookID, err)
+	}
+}
+
+// authorizeCreator checks the creator of a webhook may still manage it
+// Documents in the trash still count, so their deleted and restored events are delivered
+func (s *WebhookService) authorizeCreator(ctx context.Context, hook *webhook.Webhook) error {
+	if hook.WorkspaceID != "" {
+		return s.authorize(ctx, hook.CreatedBy, "", hook.WorkspaceID)
+	}
+
+	doc, err := s.docRepo.GetByID(ctx, hook.DocumentID)
+	if err != nil && strings.Contains(err.Error(), "not found") {
+		doc, err = s.docRepo.GetTrashedByID(ctx, hook.DocumentID)
+	}
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if !s.docService.canManage(ctx, doc, hook.CreatedBy) {
+		return errors.NewAppError(errors.ErrForbidden.Code, "Only owner can manage webhooks", nil)
+	}
+	return nil
+}
+
+// deactivate pauses a webhook whose creator lost access; someone who can manage it may resume it
+func (s *WebhookService) deactivate(ctx context.Context, hook *webhook.Webhook) {
+	hook.Active = false
+	hook.UpdatedAt = time.Now()
+	if err := s.webhookRepo.Update(ctx, hook); err != nil {
+		log.Printf("Failed to pause webhook %s: %v", hook.ID, err)
+		return
+	}
+	log.Printf("Paused webhook %s: its creator %s can no longer manage it", hook.ID, hook.CreatedBy)
+}
+
+// deleteDocumentWebhooks removes the webhooks of a permanently deleted document
+func (s *WebhookService) deleteDocumentWebhooks(docID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	hooks, err := s.webhookRepo.ListByDocumentID(ctx, docID)
+	if err != nil {
+		log.Printf("Failed to list webhooks of document %s: %v", docID, err)
+		return
+	}
+	for _, hook := range hooks {
+		if err := s.webhookRepo.Delete(ctx, hook.ID); err != nil {
+			log.Printf("Failed to delete webhook %s: %v", hook.ID, err)
+		}
+	}
+}
+
+// newClient creates the HTTP client used for deliveries
+// It connects directly rather than through a proxy, and refuses private addresses at connection
+// time, after DNS resolution, unless private networks are allowed
+func (s *WebhookService) newClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if s.allowPrivateNetworks {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("webhook address %s is not a public address", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublicNetworks are ranges that reach internal services but are not covered by the net.IP checks:
+// shared address space used for carrier-grade NAT and some cloud internal networks, and the NAT64
+// prefixes, which embed IPv4 addresses that could be private
+var nonPublicNetworks = mustParseCIDRs("100.64.0.0/10", "64:ff9b::/96", "64:ff9b:1::/48")
+
+// isPublicIP reports whether an address is routable on the internet: not loopback, private,
+// link-local (which includes cloud metadata services), multicast, unspecified, shared or NAT64
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// mustParseCIDRs parses CIDR ranges known at compile time
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
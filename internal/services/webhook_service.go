@@ -0,0 +1,339 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/events"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/pkg/webhook"
+	"collaborative-editor/pkg/workspace"
+)
+
+const (
+	// maxWebhooksPerTarget caps how many webhooks a document or workspace may have
+	maxWebhooksPerTarget = 20
+	// defaultWebhookDeliveryPage and maxWebhookDeliveryPage bound how much of the delivery log is returned
+	defaultWebhookDeliveryPage = 50
+	maxWebhookDeliveryPage     = 200
+	// webhookSecretPrefix is prepended to generated signing secrets so they are easy to recognize
+	webhookSecretPrefix = "whsec_"
+)
+
+// WebhookEvents lists the event types a webhook can subscribe to
+var WebhookEvents = []string{
+	events.DocumentCreated,
+	events.DocumentUpdated,
+	events.DocumentDeleted,
+	events.DocumentRestored,
+	events.CollaboratorAdded,
+	events.CollaboratorRemoved,
+}
+
+// WebhookService manages webhook subscriptions and delivers document events to them
+// Document webhooks are managed by whoever can manage the document, workspace webhooks by workspace admins
+type WebhookService struct {
+	webhookRepo          repository.WebhookRepository
+	docRepo              repository.DocumentRepository
+	docService           *DocumentService
+	client               *http.Client
+	queue                chan events.Event
+	slots                chan struct{}
+	allowPrivateNetworks bool
+}
+
+// NewWebhookService creates a new webhook service
+// Events are only delivered once RunDispatcher is running
+func NewWebhookService(webhookRepo repository.WebhookRepository, docRepo repository.DocumentRepository, docService *DocumentService) *WebhookService {
+	s := &WebhookService{
+		webhookRepo: webhookRepo,
+		docRepo:     docRepo,
+		docService:  docService,
+		queue:       make(chan events.Event, webhookQueueSize),
+		slots:       make(chan struct{}, maxConcurrentWebhookDeliveries),
+	}
+	s.client = s.newClient()
+	return s
+}
+
+// SetAllowPrivateNetworks allows webhook URLs on loopback and private addresses, for local development
+// They are refused by default so webhooks cannot be used to reach internal services
+func (s *WebhookService) SetAllowPrivateNetworks(allow bool) {
+	s.allowPrivateNetworks = allow
+}
+
+// CreateWebhookRequest represents a request to create a webhook on a document or a workspace
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	DocumentID  string   `json:"document_id,omitempty"`
+	WorkspaceID string   `json:"workspace_id,omitempty"`
+}
+
+// UpdateWebhookRequest represents a request to change a webhook; omitted fields are left as they are
+type UpdateWebhookRequest struct {
+	URL    *string  `json:"url,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// CreateWebhookResponse represents a newly created webhook
+// Secret is only ever returned here
+type CreateWebhookResponse struct {
+	*webhook.Webhook
+	Secret string `json:"secret"`
+}
+
+// CreateWebhook subscribes a URL to the events of a document or a workspace
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID string, req *CreateWebhookRequest) (*CreateWebhookResponse, error) {
+	if (req.DocumentID == "") == (req.WorkspaceID == "") {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Exactly one of document_id and workspace_id is required", nil)
+	}
+
+	if err := s.authorize(ctx, userID, req.DocumentID, req.WorkspaceID); err != nil {
+		return nil, err
+	}
+
+	hookURL, err := s.validateURL(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	eventTypes, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.listFor(ctx, req.DocumentID, req.WorkspaceID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if len(existing) >= maxWebhooksPerTarget {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("A document or workspace can have at most %d webhooks", maxWebhooksPerTarget), nil)
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	hook := webhook.NewWebhook(userID, req.DocumentID, req.WorkspaceID, hookURL, eventTypes, secret)
+	if err := s.webhookRepo.Create(ctx, hook); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	return &CreateWebhookResponse{Webhook: hook, Secret: secret}, nil
+}
+
+// ListWebhooks lists the webhooks of a document or a workspace
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID, documentID, workspaceID string) ([]*webhook.Webhook, error) {
+	if (documentID == "") == (workspaceID == "") {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "Exactly one of document_id and workspace_id is required", nil)
+	}
+
+	if err := s.authorize(ctx, userID, documentID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	hooks, err := s.listFor(ctx, documentID, workspaceID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return hooks, nil
+}
+
+// GetWebhook retrieves a webhook
+func (s *WebhookService) GetWebhook(ctx context.Context, userID, webhookID string) (*webhook.Webhook, error) {
+	return s.getWebhook(ctx, userID, webhookID)
+}
+
+// UpdateWebhook changes the URL or events of a webhook, or pauses and resumes it
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userID, webhookID string, req *UpdateWebhookRequest) (*webhook.Webhook, error) {
+	hook, err := s.getWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if hook.URL, err = s.validateURL(*req.URL); err != nil {
+			return nil, err
+		}
+	}
+	if req.Events != nil {
+		if hook.Events, err = normalizeWebhookEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Active != nil {
+		// Whoever resumes a webhook becomes responsible for it, so one paused because its
+		// creator lost access keeps running
+		if *req.Active && !hook.Active {
+			hook.CreatedBy = userID
+		}
+		hook.Active = *req.Active
+	}
+	hook.UpdatedAt = time.Now()
+
+	if err := s.webhookRepo.Update(ctx, hook); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return hook, nil
+}
+
+// DeleteWebhook removes a webhook; deliveries already scheduled are dropped
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, webhookID string) error {
+	if _, err := s.getWebhook(ctx, userID, webhookID); err != nil {
+		return err
+	}
+
+	if err := s.webhookRepo.Delete(ctx, webhookID); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the latest delivery attempts of a webhook, newest first
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, webhookID string, limit int) ([]*webhook.Delivery, error) {
+	if _, err := s.getWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryPage
+	}
+	limit = min(limit, maxWebhookDeliveryPage)
+
+	deliveries, err := s.webhookRepo.ListDeliveries(ctx, webhookID, limit)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+	return deliveries, nil
+}
+
+// PingWebhook sends a ping event to a webhook right away and returns the result
+// Pings are sent to paused webhooks too, and are not retried
+func (s *WebhookService) PingWebhook(ctx context.Context, userID, webhookID string) (*webhook.Delivery, error) {
+	hook, err := s.getWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := newWebhookJob(hook.ID, events.Event{
+		Type:        webhook.EventPing,
+		ActorID:     userID,
+		DocumentID:  hook.DocumentID,
+		WorkspaceID: hook.WorkspaceID,
+		Data:        map[string]string{"message": "Webhook is reachable"},
+	})
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	delivery := s.send(ctx, hook, job)
+	s.record(delivery)
+	return delivery, nil
+}
+
+// getWebhook loads a webhook and checks the user may manage it
+func (s *WebhookService) getWebhook(ctx context.Context, userID, webhookID string) (*webhook.Webhook, error) {
+	hook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Webhook not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if err := s.authorize(ctx, userID, hook.DocumentID, hook.WorkspaceID); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// authorize checks the user may manage the webhooks of a document (managing the document)
+// or of a workspace (workspace admin)
+func (s *WebhookService) authorize(ctx context.Context, userID, documentID, workspaceID string) error {
+	if workspaceID != "" {
+		_, err := s.docService.requireWorkspaceRole(ctx, workspaceID, userID, workspace.RoleAdmin)
+		return err
+	}
+
+	doc, err := s.docRepo.GetByID(ctx, documentID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.NewAppError(errors.ErrNotFound.Code, "Document not found", nil)
+		}
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	if !s.docService.canManage(ctx, doc, userID) {
+		return errors.NewAppError(errors.ErrForbidden.Code, "Only owner can manage webhooks", nil)
+	}
+	return nil
+}
+
+// listFor lists the webhooks of a document or, if documentID is empty, of a workspace
+func (s *WebhookService) listFor(ctx context.Context, documentID, workspaceID string) ([]*webhook.Webhook, error) {
+	if documentID != "" {
+		return s.webhookRepo.ListByDocumentID(ctx, documentID)
+	}
+	return s.webhookRepo.ListByWorkspaceID(ctx, workspaceID)
+}
+
+// validateURL checks a webhook URL is an absolute http or https URL without credentials
+// Whether its host name resolves to a public address is checked when connecting
+func (s *WebhookService) validateURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "URL must be an absolute http or https URL", nil)
+	}
+	if u.User != nil {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "URL must not contain credentials", nil)
+	}
+	if len(raw) > 2048 {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "URL must be at most 2048 characters", nil)
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isPublicIP(ip) && !s.allowPrivateNetworks {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "URL must not point to a private network address", nil)
+	}
+	return raw, nil
+}
+
+// normalizeWebhookEvents checks the requested event types and removes duplicates
+func normalizeWebhookEvents(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "At least one event is required", nil)
+	}
+
+	eventTypes := []string{}
+	for _, eventType := range requested {
+		if !slices.Contains(WebhookEvents, eventType) {
+			return nil, errors.NewAppError(
+				errors.ErrInvalidInput.Code,
+				fmt.Sprintf("Unknown event %q, valid events are: %s", eventType, strings.Join(WebhookEvents, ", ")),
+				nil,
+			)
+		}
+		if !slices.Contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return eventTypes, nil
+}
+
+// generateWebhookSecret creates a random signing secret
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,468 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"collaborative-editor/internal/errors"
+	"collaborative-editor/internal/events"
+	"collaborative-editor/internal/repository"
+	"collaborative-editor/pkg/document"
+	"collaborative-editor/pkg/workspace"
+)
+
+const (
+	// maxWorkspaceNameLength bounds workspace names
+	maxWorkspaceNameLength = 100
+	// maxWorkspaceMembers bounds the size of a workspace, since members are stored inline
+	maxWorkspaceMembers = 200
+)
+
+// WorkspaceService handles workspace-related business logic
+type WorkspaceService struct {
+	workspaceRepo repository.WorkspaceRepository
+	userRepo      repository.UserRepository
+	docRepo       repository.DocumentRepository
+	folderRepo    repository.FolderRepository
+	docService    *DocumentService
+	eventBus      *events.Bus
+}
+
+// NewWorkspaceService creates a new workspace service
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, userRepo repository.UserRepository, docRepo repository.DocumentRepository) *WorkspaceService {
+	return &WorkspaceService{
+		workspaceRepo: workspaceRepo,
+		userRepo:      userRepo,
+		docRepo:       docRepo,
+	}
+}
+
+// SetFolderRepository sets the folder repository, so folders are detached when a workspace is deleted
+func (s *WorkspaceService) SetFolderRepository(repo repository.FolderRepository) {
+	s.folderRepo = repo
+}
+
+// SetDocumentService sets the document service used to find the documents a removed member can no longer read
+func (s *WorkspaceService) SetDocumentService(docService *DocumentService) {
+	s.docService = docService
+}
+
+// SetEventBus sets the bus that member changes are published on
+func (s *WorkspaceService) SetEventBus(bus *events.Bus) {
+	s.eventBus = bus
+}
+
+// WorkspaceRequest represents a request to create or rename a workspace
+type WorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+// AddMemberRequest represents a request to add a member to a workspace
+type AddMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// UpdateMemberRequest represents a request to change a member's role
+type UpdateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+// MemberResponse represents a workspace member
+type MemberResponse struct {
+	UserID   string    `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// WorkspaceResponse represents a workspace response
+type WorkspaceResponse struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Role      string            `json:"role"` // Role of the requesting user
+	Members   []*MemberResponse `json:"members"`
+	CreatedBy string            `json:"created_by"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// CreateWorkspace creates a new workspace owned by the user
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, userID string, req *WorkspaceRequest) (*WorkspaceResponse, error) {
+	name, err := validateWorkspaceName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	w := workspace.NewWorkspace(name, userID)
+	if err := s.workspaceRepo.Create(ctx, w); err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to create workspace: %w", err))
+	}
+
+	return s.toResponse(ctx, w, userID), nil
+}
+
+// ListWorkspaces lists the workspaces a user is a member of
+func (s *WorkspaceService) ListWorkspaces(ctx context.Context, userID string) ([]*WorkspaceResponse, error) {
+	workspaces, err := s.workspaceRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	responses := make([]*WorkspaceResponse, 0, len(workspaces))
+	for _, w := range workspaces {
+		responses = append(responses, s.toResponse(ctx, w, userID))
+	}
+	return responses, nil
+}
+
+// GetWorkspace retrieves a workspace the user is a member of
+func (s *WorkspaceService) GetWorkspace(ctx context.Context, userID, workspaceID string) (*WorkspaceResponse, error) {
+	w, err := s.getForRole(ctx, workspaceID, userID, workspace.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, w, userID), nil
+}
+
+// UpdateWorkspace renames a workspace (admins and owners)
+func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, userID, workspaceID string, req *WorkspaceRequest) (*WorkspaceResponse, error) {
+	w, err := s.getForRole(ctx, workspaceID, userID, workspace.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := validateWorkspaceName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Name = name
+	if err := s.save(ctx, w); err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(ctx, w, userID), nil
+}
+
+// DeleteWorkspace deletes a workspace (owners only)
+// Its documents and folders are not deleted; they become personal ones of their owners
+func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, userID, workspaceID string) error {
+	w, err := s.getForRole(ctx, workspaceID, userID, workspace.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	return s.deleteWorkspace(ctx, w)
+}
+
+// AddMember adds a user to a workspace by email (admins and owners)
+// Only owners can add other owners
+func (s *WorkspaceService) AddMember(ctx context.Context, userID, workspaceID string, req *AddMemberRequest) (*WorkspaceResponse, error) {
+	w, err := s.getForRole(ctx, workspaceID, userID, workspace.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.checkAssignableRole(w, userID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(w.Members) >= maxWorkspaceMembers {
+		return nil, errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("A workspace can have at most %d members", maxWorkspaceMembers), nil)
+	}
+
+	member, err := s.userRepo.GetByEmail(ctx, strings.TrimSpace(strings.ToLower(req.Email)))
+	if err != nil {
+		return nil, errors.NewAppError(errors.ErrNotFound.Code, "User not found with this email", nil)
+	}
+
+	if w.RoleOf(member.ID) != "" {
+		return nil, errors.NewAppError(errors.ErrConflict.Code, "User is already a member of this workspace", nil)
+	}
+
+	w.Members = append(w.Members, workspace.Member{UserID: member.ID, Role: role, JoinedAt: time.Now()})
+	if err := s.save(ctx, w); err != nil {
+		return nil, err
+	}
+
+	return s.toResponse(ctx, w, userID), nil
+}
+
+// UpdateMemberRole changes the role of a member (admins and owners)
+// Only owners can grant or revoke the owner role, and the last owner cannot be demoted
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, userID, workspaceID, memberID string, req *UpdateMemberRequest) (*WorkspaceResponse, error) {
+	w, err := s.getForRole(ctx, workspaceID, userID, workspace.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.checkAssignableRole(w, userID, req.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	current := w.RoleOf(memberID)
+	if current == "" {
+		return nil, errors.NewAppError(errors.ErrNotFound.Code, "Member not found", nil)
+	}
+	if current == workspace.RoleOwner {
+		if !w.HasRole(userID, workspace.RoleOwner) {
+			return nil, errors.NewAppError(errors.ErrForbidden.Code, "Only owners can change the role of an owner", nil)
+		}
+		if role != workspace.RoleOwner && w.CountRole(workspace.RoleOwner) == 1 {
+			return nil, errors.NewAppError(errors.ErrInvalidInput.Code, "A workspace must have at least one owner", nil)
+		}
+	}
+
+	for i := range w.Members {
+		if w.Members[i].UserID == memberID {
+			w.Members[i].Role = role
+		}
+	}
+	if err := s.save(ctx, w); err != nil {
+		return nil, err
+	}
+	s.publishMemberChange(ctx, userID, w.ID, memberID, role, nil)
+
+	return s.toResponse(ctx, w, userID), nil
+}
+
+// RemoveMember removes a member from a workspace
+// Admins can remove members, owners can remove anyone, and everyone can leave
+// The last owner cannot leave; they must delete the workspace or appoint another owner first
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userID, workspaceID, memberID string) error {
+	minimum := workspace.RoleAdmin
+	if memberID == userID {
+		minimum = workspace.RoleViewer
+	}
+
+	w, err := s.getForRole(ctx, workspaceID, userID, minimum)
+	if err != nil {
+		return err
+	}
+
+	current := w.RoleOf(memberID)
+	if current == "" {
+		return errors.NewAppError(errors.ErrNotFound.Code, "Member not found", nil)
+	}
+	if current == workspace.RoleOwner {
+		if !w.HasRole(userID, workspace.RoleOwner) {
+			return errors.NewAppError(errors.ErrForbidden.Code, "Only owners can remove an owner", nil)
+		}
+		if w.CountRole(workspace.RoleOwner) == 1 {
+			return errors.NewAppError(errors.ErrInvalidInput.Code, "A workspace must have at least one owner", nil)
+		}
+	}
+
+	w.Members = removeMember(w.Members, memberID)
+	if err := s.save(ctx, w); err != nil {
+		return err
+	}
+
+	docs, err := s.docRepo.ListByWorkspaceID(ctx, w.ID)
+	if err != nil {
+		log.Printf("Failed to list documents of workspace %s: %v", w.ID, err)
+	}
+	s.publishMemberChange(ctx, userID, w.ID, memberID, "", docs)
+	return nil
+}
+
+// RemoveUserFromWorkspaces removes a user from every workspace, used when their account is deleted
+// If they were the last owner, the most privileged remaining member becomes owner;
+// workspaces left without members are deleted
+func (s *WorkspaceService) RemoveUserFromWorkspaces(ctx context.Context, userID string) error {
+	workspaces, err := s.workspaceRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	for _, w := range workspaces {
+		wasOwner := w.RoleOf(userID) == workspace.RoleOwner
+		w.Members = removeMember(w.Members, userID)
+
+		if len(w.Members) == 0 {
+			if err := s.deleteWorkspace(ctx, w); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if wasOwner && w.CountRole(workspace.RoleOwner) == 0 {
+			successor := 0
+			for i, m := range w.Members {
+				if workspace.RoleAtLeast(m.Role, w.Members[successor].Role) && !workspace.RoleAtLeast(w.Members[successor].Role, m.Role) {
+					successor = i
+				}
+			}
+			w.Members[successor].Role = workspace.RoleOwner
+		}
+
+		if err := s.save(ctx, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteWorkspace detaches the workspace's documents and folders and deletes it
+func (s *WorkspaceService) deleteWorkspace(ctx context.Context, w *workspace.Workspace) error {
+	if s.folderRepo != nil {
+		folders, err := s.folderRepo.ListByWorkspaceID(ctx, w.ID)
+		if err != nil {
+			return errors.WrapError(errors.ErrInternalServer, err)
+		}
+		for _, f := range folders {
+			f.WorkspaceID = ""
+			f.UpdatedAt = time.Now()
+			if err := s.folderRepo.Update(ctx, f); err != nil {
+				return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to detach folder %s: %w", f.ID, err))
+			}
+		}
+	}
+
+	docs, err := s.docRepo.ListByWorkspaceID(ctx, w.ID)
+	if err != nil {
+		return errors.WrapError(errors.ErrInternalServer, err)
+	}
+	for _, doc := range docs {
+		doc.WorkspaceID = ""
+		doc.UpdatedAt = time.Now()
+		if err := s.docRepo.Update(ctx, doc); err != nil {
+			return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to detach document %s: %w", doc.ID, err))
+		}
+	}
+
+	if err := s.workspaceRepo.Delete(ctx, w.ID); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to delete workspace: %w", err))
+	}
+
+	// Detached documents are only readable through their owner, collaborators and folders now
+	for _, m := range w.Members {
+		s.publishMemberChange(ctx, "", w.ID, m.UserID, "", docs)
+	}
+	return nil
+}
+
+// publishMemberChange publishes a member's new role, or their removal when role is empty, along with
+// those of the given workspace documents they can no longer read
+func (s *WorkspaceService) publishMemberChange(ctx context.Context, actorID, workspaceID, memberID, role string, docs []*document.Document) {
+	if s.eventBus == nil {
+		return
+	}
+
+	var revoked []string
+	if s.docService != nil {
+		for _, doc := range docs {
+			if !s.docService.hasAccess(ctx, doc, memberID) {
+				revoked = append(revoked, doc.ID)
+			}
+		}
+	}
+
+	s.eventBus.Publish(events.Event{
+		Type:        events.WorkspaceMemberChanged,
+		ActorID:     actorID,
+		WorkspaceID: workspaceID,
+		Data:        events.WorkspaceMemberData{UserID: memberID, Role: role, RevokedDocumentIDs: revoked},
+	})
+}
+
+// getForRole loads a workspace and checks the user has at least the given role
+// Non-members get a not found error so workspace IDs are not revealed
+func (s *WorkspaceService) getForRole(ctx context.Context, workspaceID, userID, role string) (*workspace.Workspace, error) {
+	w, err := s.workspaceRepo.GetByID(ctx, workspaceID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, errors.NewAppError(errors.ErrNotFound.Code, "Workspace not found", nil)
+		}
+		return nil, errors.WrapError(errors.ErrInternalServer, err)
+	}
+
+	if w.RoleOf(userID) == "" {
+		return nil, errors.NewAppError(errors.ErrNotFound.Code, "Workspace not found", nil)
+	}
+	if !w.HasRole(userID, role) {
+		return nil, errors.NewAppError(errors.ErrForbidden.Code, "Your workspace role does not allow this", nil)
+	}
+
+	return w, nil
+}
+
+// checkAssignableRole validates a role and checks the user may grant it
+func (s *WorkspaceService) checkAssignableRole(w *workspace.Workspace, userID, role string) (string, error) {
+	role = strings.TrimSpace(strings.ToLower(role))
+	if role == "" {
+		role = workspace.RoleEditor
+	}
+	if !workspace.IsValidRole(role) {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "Role must be one of owner, admin, editor or viewer", nil)
+	}
+	if role == workspace.RoleOwner && !w.HasRole(userID, workspace.RoleOwner) {
+		return "", errors.NewAppError(errors.ErrForbidden.Code, "Only owners can grant the owner role", nil)
+	}
+	return role, nil
+}
+
+// save stores a workspace after updating its timestamp
+func (s *WorkspaceService) save(ctx context.Context, w *workspace.Workspace) error {
+	w.UpdatedAt = time.Now()
+	if err := s.workspaceRepo.Update(ctx, w); err != nil {
+		return errors.WrapError(errors.ErrInternalServer, fmt.Errorf("failed to update workspace: %w", err))
+	}
+	return nil
+}
+
+// toResponse converts a workspace to a response, resolving member names
+func (s *WorkspaceService) toResponse(ctx context.Context, w *workspace.Workspace, userID string) *WorkspaceResponse {
+	members := make([]*MemberResponse, 0, len(w.Members))
+	for _, m := range w.Members {
+		member := &MemberResponse{UserID: m.UserID, Role: m.Role, JoinedAt: m.JoinedAt}
+		if u, err := s.userRepo.GetByID(ctx, m.UserID); err == nil {
+			member.Username = u.Username
+			member.Email = u.Email
+		} else {
+			log.Printf("Failed to load workspace member %s: %v", m.UserID, err)
+		}
+		members = append(members, member)
+	}
+
+	return &WorkspaceResponse{
+		ID:        w.ID,
+		Name:      w.Name,
+		Role:      w.RoleOf(userID),
+		Members:   members,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// validateWorkspaceName trims and checks a workspace name
+func validateWorkspaceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, "Name is required", nil)
+	}
+	if len(name) > maxWorkspaceNameLength {
+		return "", errors.NewAppError(errors.ErrInvalidInput.Code, fmt.Sprintf("Name must be at most %d characters", maxWorkspaceNameLength), nil)
+	}
+	return name, nil
+}
+
+// removeMember returns the members without the given user
+func removeMember(members []workspace.Member, userID string) []workspace.Member {
+	remaining := make([]workspace.Member, 0, len(members))
+	for _, m := range members {
+		if m.UserID != userID {
+			remaining = append(remaining, m)
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrBlobNotFound is returned by Get when no blob is stored under a key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore defines the interface for storing uploaded files by key
+// Keys are slash-separated paths such as "documents/<id>/<attachment id>"
+type BlobStore interface {
+	// Put stores a blob of the given size, replacing any blob with the same key
+	Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error
+
+	// Get opens a blob for reading; the caller must close it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes a blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStoreFromEnv creates the blob store selected by the BLOB_STORE environment variable
+// "s3" uses S3BlobStore (AWS S3 or any S3-compatible service such as MinIO), anything else
+// falls back to FileSystemBlobStore in ATTACHMENT_DIR (default "attachments")
+func NewBlobStoreFromEnv() (BlobStore, error) {
+	if strings.ToLower(os.Getenv("BLOB_STORE")) == "s3" {
+		config := S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		if config.Bucket == "" {
+			return nil, fmt.Errorf("S3_BUCKET environment variable is not set")
+		}
+		if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+			return nil, fmt.Errorf("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY environment variables must be set")
+		}
+		return NewS3BlobStore(config)
+	}
+
+	dir := os.Getenv("ATTACHMENT_DIR")
+	if dir == "" {
+		dir = "attachments"
+	}
+	return NewFileSystemBlobStore(dir), nil
+}
+
+// FileSystemBlobStore stores blobs as files under a local directory
+// It suits single-instance deployments and local development
+type FileSystemBlobStore struct {
+	dir string
+}
+
+// NewFileSystemBlobStore creates a new file system blob store
+func NewFileSystemBlobStore(dir string) *FileSystemBlobStore {
+	return &FileSystemBlobStore{
+		dir: dir,
+	}
+}
+
+// Put writes the blob to a temporary file and moves it into place, so readers never see a partial file
+func (s *FileSystemBlobStore) Put(ctx context.Context, key string, data io.Reader, size int64, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if written != size {
+		return fmt.Errorf("failed to write blob: wrote %d of %d bytes", written, size)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob: %w", err)
+	}
+	return nil
+}
+
+// Get opens the blob's file
+func (s *FileSystemBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return file, nil
+}
+
+// Delete removes the blob's file
+func (s *FileSystemBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// path maps a key to a file under the store's directory
+func (s *FileSystemBlobStore) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// validKey reports whether a key is a relative slash-separated path without empty, "." or ".." segments,
+// so it cannot escape the directory or bucket prefix it is stored under
+func validKey(key string) bool {
+	if key == "" || strings.Contains(key, `\`) {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -2,47 +2,138 @@ package validation
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 )
 
 var (
 	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+	tagRegex   = regexp.MustCompile(`^[\p{L}\p{N}][\p{L}\p{N} _\-]*$`)
 )
 
+// MaxTagLength is the maximum length of a document tag, in characters
+const MaxTagLength = 32
+
 // ValidateSignupRequest validates user signup request
 func ValidateSignupRequest(username, email, password string) error {
 	var errors []string
 
+	if msg := usernameError(username); msg != "" {
+		errors = append(errors, msg)
+	}
+
+	if msg := emailError(email); msg != "" {
+		errors = append(errors, msg)
+	}
+
+	if msg := passwordError(password); msg != "" {
+		errors = append(errors, msg)
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("validation failed: %s", strings.Join(errors, ", "))
+	}
+
+	return nil
+}
+
+// ValidatePassword validates a new password
+func ValidatePassword(password string) error {
+	if msg := passwordError(password); msg != "" {
+		return fmt.Errorf("validation failed: %s", msg)
+	}
+	return nil
+}
+
+// ValidateUsername validates a new username
+func ValidateUsername(username string) error {
+	if msg := usernameError(username); msg != "" {
+		return fmt.Errorf("validation failed: %s", msg)
+	}
+	return nil
+}
+
+// ValidateEmail validates a new email address
+func ValidateEmail(email string) error {
+	if msg := emailError(email); msg != "" {
+		return fmt.Errorf("validation failed: %s", msg)
+	}
+	return nil
+}
+
+// ValidateDisplayName validates a display name (empty clears it)
+func ValidateDisplayName(displayName string) error {
+	if len(displayName) > 100 {
+		return fmt.Errorf("validation failed: display name must be less than 100 characters")
+	}
+	return nil
+}
+
+// ValidateAvatarURL validates an avatar URL (empty clears it)
+func ValidateAvatarURL(avatarURL string) error {
+	if avatarURL == "" {
+		return nil
+	}
+	if len(avatarURL) > 2048 {
+		return fmt.Errorf("validation failed: avatar URL must be less than 2048 characters")
+	}
+	u, err := url.Parse(avatarURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("validation failed: avatar URL must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// NormalizeTag lowercases a document tag and collapses its whitespace, then validates it
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	if tag == "" {
+		return "", fmt.Errorf("validation failed: tags cannot be empty")
+	}
+	if len([]rune(tag)) > MaxTagLength {
+		return "", fmt.Errorf("validation failed: tags must be at most %d characters", MaxTagLength)
+	}
+	if !tagRegex.MatchString(tag) {
+		return "", fmt.Errorf("validation failed: tags can only contain letters, digits, spaces, hyphens and underscores")
+	}
+	return tag, nil
+}
+
+// usernameError returns a description of what is wrong with a username, or "" if it is valid
+func usernameError(username string) string {
 	if username == "" {
-		errors = append(errors, "username is required")
+		return "username is required"
 	} else if len(username) < 3 {
-		errors = append(errors, "username must be at least 3 characters")
+		return "username must be at least 3 characters"
 	} else if len(username) > 50 {
-		errors = append(errors, "username must be less than 50 characters")
+		return "username must be less than 50 characters"
 	} else if !isValidUsername(username) {
-		errors = append(errors, "username can only contain letters, numbers, and underscores")
+		return "username can only contain letters, numbers, and underscores"
 	}
+	return ""
+}
 
+// emailError returns a description of what is wrong with an email address, or "" if it is valid
+func emailError(email string) string {
 	if email == "" {
-		errors = append(errors, "email is required")
+		return "email is required"
 	} else if !emailRegex.MatchString(email) {
-		errors = append(errors, "email format is invalid")
+		return "email format is invalid"
 	}
+	return ""
+}
 
+// passwordError returns a description of what is wrong with a password, or "" if it is valid
+func passwordError(password string) string {
 	if password == "" {
-		errors = append(errors, "password is required")
+		return "password is required"
 	} else if len(password) < 8 {
-		errors = append(errors, "password must be at least 8 characters")
+		return "password must be at least 8 characters"
 	} else if len(password) > 128 {
-		errors = append(errors, "password must be less than 128 characters")
+		return "password must be less than 128 characters"
 	}
-
-	if len(errors) > 0 {
-		return fmt.Errorf("validation failed: %s", strings.Join(errors, ", "))
-	}
-
-	return nil
+	return ""
 }
 
 // isValidUsername checks if username contains only valid characters
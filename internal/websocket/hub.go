@@ -6,9 +6,17 @@ import (
 	"sync"
 	"time"
 
+	"collaborative-editor/internal/events"
+
 	"github.com/gorilla/websocket"
 )
 
+// Reasons sent with DOCUMENT_CLOSED
+const (
+	CloseReasonDeleted       = "deleted"        // The document was moved to the trash
+	CloseReasonAccessRevoked = "access_revoked" // The user was removed from the document or their account was deleted
+)
+
 // Client represents a WebSocket connection
 type Client struct {
 	ID         string
@@ -26,6 +34,8 @@ type Message struct {
 	Type       string    `json:"type"`
 	DocumentID string    `json:"document_id"`
 	User       UserInfo  `json:"user"`
+	Title      string    `json:"title,omitempty"`  // The new title, for DOCUMENT_UPDATED
+	Reason     string    `json:"reason,omitempty"` // Why the document was closed, for DOCUMENT_CLOSED
 	Timestamp  time.Time `json:"timestamp"`
 }
 
@@ -37,6 +47,8 @@ type UserInfo struct {
 }
 
 // Hub maintains active clients and broadcasts messages
+// Only the Run goroutine changes the rooms and sends to or closes the clients' send channels,
+// so a channel is never written to after it is closed; the mutex lets other goroutines read the rooms
 type Hub struct {
 	// Map: documentID -> map[clientID]*Client
 	documents map[string]map[string]*Client
@@ -50,6 +62,13 @@ type Hub struct {
 	// Broadcast messages to clients
 	broadcast chan *Message
 
+	// Close requests: notify every client in a document, then disconnect them
+	closeDocument chan *Message
+
+	// Close requests for one user: notify and disconnect their clients in a document,
+	// or in every document when DocumentID is empty
+	closeUser chan *Message
+
 	// Mutex for thread-safe access
 	mu sync.RWMutex
 }
@@ -61,6 +80,9 @@ func NewHub() *Hub {
 		Register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan *Message, 256),
+
+		closeDocument: make(chan *Message, 64),
+		closeUser:     make(chan *Message, 64),
 	}
 }
 
@@ -76,6 +98,12 @@ func (h *Hub) Run() {
 
 		case message := <-h.broadcast:
 			h.broadcastToDocument(message)
+
+		case message := <-h.closeDocument:
+			h.closeDocumentClients(message)
+
+		case message := <-h.closeUser:
+			h.closeUserClients(message)
 		}
 	}
 }
@@ -108,8 +136,7 @@ func (h *Hub) registerClient(client *Client) {
 		Timestamp: time.Now(),
 	}
 
-	// Use goroutine to avoid blocking
-	go h.broadcastToDocument(joinMessage)
+	h.broadcastToDocument(joinMessage)
 }
 
 // unregisterClient removes a client and broadcasts LEAVE
@@ -122,14 +149,21 @@ func (h *Hub) unregisterClient(client *Client) {
 		return
 	}
 
+	// Clients already disconnected by the hub are gone from the room
 	_, exists := clients[client.ID]
 	if !exists {
 		h.mu.Unlock()
 		return
 	}
 
-	// Broadcast LEAVE message BEFORE removing the client
-	leaveMessage := &Message{
+	h.removeClient(client)
+	h.mu.Unlock()
+
+	log.Printf("Client %s (user: %s) left document %s. Remaining clients: %d",
+		client.ID, client.Username, client.DocumentID, len(clients))
+
+	// Tell the rest of the room
+	h.broadcastToDocument(&Message{
 		Type:       "LEAVE",
 		DocumentID: client.DocumentID,
 		User: UserInfo{
@@ -138,14 +172,12 @@ func (h *Hub) unregisterClient(client *Client) {
 			Email:    client.Email,
 		},
 		Timestamp: time.Now(),
-	}
-	h.mu.Unlock()
-
-	// Broadcast without holding the lock
-	go h.broadcastToDocument(leaveMessage)
+	})
+}
 
-	// Now remove the client
-	h.mu.Lock()
+// removeClient removes a client from its room and closes its send channel; the caller holds the lock
+func (h *Hub) removeClient(client *Client) {
+	clients := h.documents[client.DocumentID]
 	delete(clients, client.ID)
 	close(client.Send)
 
@@ -153,19 +185,13 @@ func (h *Hub) unregisterClient(client *Client) {
 	if len(clients) == 0 {
 		delete(h.documents, client.DocumentID)
 	}
-	h.mu.Unlock()
-
-	log.Printf("Client %s (user: %s) left document %s. Remaining clients: %d",
-		client.ID, client.Username, client.DocumentID, len(clients))
 }
 
 // broadcastToDocument sends a message to all clients in a document
+// It runs on the Run goroutine and never blocks: clients that fall behind are disconnected
 func (h *Hub) broadcastToDocument(message *Message) {
-	h.mu.RLock()
 	clients := h.documents[message.DocumentID]
-	h.mu.RUnlock()
-
-	if clients == nil {
+	if len(clients) == 0 {
 		return
 	}
 
@@ -180,10 +206,176 @@ func (h *Hub) broadcastToDocument(message *Message) {
 		case client.Send <- messageBytes:
 		default:
 			// Client's send channel is full, close it
-			close(client.Send)
-			delete(clients, client.ID)
+			h.mu.Lock()
+			h.removeClient(client)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// CloseDocument sends a DOCUMENT_CLOSED message with the reason to every client in a document
+// and disconnects them, for example when the document is deleted
+func (h *Hub) CloseDocument(documentID, reason string) {
+	h.closeDocument <- &Message{
+		Type:       "DOCUMENT_CLOSED",
+		DocumentID: documentID,
+		Reason:     reason,
+		Timestamp:  time.Now(),
+	}
+}
+
+// closeDocumentClients removes a document room and closes its clients' send channels
+// The write pumps flush the queued message before sending a close frame
+func (h *Hub) closeDocumentClients(message *Message) {
+	messageBytes, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	clients := h.documents[message.DocumentID]
+	delete(h.documents, message.DocumentID)
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		select {
+		case client.Send <- messageBytes:
+		default:
+		}
+		close(client.Send)
+	}
+
+	if len(clients) > 0 {
+		log.Printf("Closed document %s (%s), disconnected %d clients", message.DocumentID, message.Reason, len(clients))
+	}
+}
+
+// closeUserClients notifies and disconnects one user's clients, in one document or in all of them,
+// and tells the rest of each room the user left
+func (h *Hub) closeUserClients(message *Message) {
+	type closed struct {
+		client  *Client
+		message []byte
+	}
+
+	h.mu.Lock()
+	var toClose []closed
+	var rooms []string
+	for documentID, clients := range h.documents {
+		if message.DocumentID != "" && documentID != message.DocumentID {
+			continue
+		}
+
+		var messageBytes []byte
+		for id, client := range clients {
+			if client.UserID != message.User.UserID {
+				continue
+			}
+			if messageBytes == nil {
+				roomMessage := *message
+				roomMessage.DocumentID = documentID
+				messageBytes, _ = json.Marshal(&roomMessage)
+			}
+			toClose = append(toClose, closed{client: client, message: messageBytes})
+			delete(clients, id)
+		}
+		if len(clients) == 0 {
+			delete(h.documents, documentID)
+		} else if messageBytes != nil {
+			rooms = append(rooms, documentID)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range toClose {
+		select {
+		case c.client.Send <- c.message:
+		default:
+		}
+		close(c.client.Send)
+	}
+
+	for _, documentID := range rooms {
+		h.broadcastToDocument(&Message{
+			Type:       "LEAVE",
+			DocumentID: documentID,
+			User:       message.User,
+			Timestamp:  time.Now(),
+		})
+	}
+
+	if len(toClose) > 0 {
+		log.Printf("Disconnected %d clients of user %s (%s)", len(toClose), message.User.UserID, message.Reason)
+	}
+}
+
+// HandleEvent keeps live sessions in step with changes made through the API; subscribe it to the event bus
+// Updates are broadcast to the document's room, deleted documents are closed, and users who lost
+// access to a document, directly or through a workspace, or deleted their account, are disconnected
+func (h *Hub) HandleEvent(event events.Event) {
+	switch event.Type {
+	case events.DocumentUpdated:
+		data, _ := event.Data.(events.DocumentData)
+		h.broadcast <- &Message{
+			Type:       "DOCUMENT_UPDATED",
+			DocumentID: event.DocumentID,
+			User:       h.userInfo(event.DocumentID, event.ActorID),
+			Title:      data.Title,
+			Timestamp:  event.OccurredAt,
+		}
+
+	case events.DocumentDeleted, events.DocumentPurged:
+		// Purges without a prior delete happen when an account's unshared documents are released
+		h.CloseDocument(event.DocumentID, CloseReasonDeleted)
+
+	case events.CollaboratorRemoved:
+		if data, ok := event.Data.(events.CollaboratorData); ok && data.AccessRevoked {
+			h.closeUser <- &Message{
+				Type:       "DOCUMENT_CLOSED",
+				DocumentID: event.DocumentID,
+				User:       UserInfo{UserID: data.CollaboratorID},
+				Reason:     CloseReasonAccessRevoked,
+				Timestamp:  event.OccurredAt,
+			}
+		}
+
+	case events.WorkspaceMemberChanged:
+		if data, ok := event.Data.(events.WorkspaceMemberData); ok {
+			for _, documentID := range data.RevokedDocumentIDs {
+				h.closeUser <- &Message{
+					Type:       "DOCUMENT_CLOSED",
+					DocumentID: documentID,
+					User:       UserInfo{UserID: data.UserID},
+					Reason:     CloseReasonAccessRevoked,
+					Timestamp:  event.OccurredAt,
+				}
+			}
+		}
+
+	case events.UserDeleted:
+		if data, ok := event.Data.(events.UserData); ok {
+			h.closeUser <- &Message{
+				Type:      "DOCUMENT_CLOSED",
+				User:      UserInfo{UserID: data.UserID, Username: data.Username},
+				Reason:    CloseReasonAccessRevoked,
+				Timestamp: event.OccurredAt,
+			}
+		}
+	}
+}
+
+// userInfo describes a user from their connection to a document, or by ID alone if they are not connected
+func (h *Hub) userInfo(documentID, userID string) UserInfo {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, client := range h.documents[documentID] {
+		if client.UserID == userID {
+			return UserInfo{UserID: client.UserID, Username: client.Username, Email: client.Email}
 		}
 	}
+	return UserInfo{UserID: userID}
 }
 
 // GetActiveUsers returns a list of active users in a document
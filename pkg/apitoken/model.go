@@ -0,0 +1,116 @@
+package apitoken
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Prefix is prepended to every personal access token so it can be told apart from a JWT
+const Prefix = "cep_"
+
+// Scopes that can be granted to a personal access token
+const (
+	ScopeDocsRead  = "docs:read"  // Read documents and texts
+	ScopeDocsWrite = "docs:write" // Create, update, delete and share documents (implies docs:read)
+	ScopeAdmin     = "admin"      // Full access, including account settings (implies all scopes)
+)
+
+// ValidScopes lists every scope a token may be granted
+var ValidScopes = []string{ScopeDocsRead, ScopeDocsWrite, ScopeAdmin}
+
+// APIToken represents a personal access token
+// The token itself is only shown once on creation; only its hash is stored
+type APIToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Hint       string     `json:"hint"` // Last characters of the token, to help users recognize it
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewAPIToken creates a new personal access token record
+func NewAPIToken(userID, name string, scopes []string, hint string, expiresAt *time.Time) *APIToken {
+	return &APIToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		Hint:      hint,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+}
+
+// APITokenDocument represents the token as stored in Couchbase
+type APITokenDocument struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Hint       string     `json:"hint"`
+	TokenHash  string     `json:"token_hash"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ToDocument converts APIToken to APITokenDocument for database storage
+func (t *APIToken) ToDocument(tokenHash string) *APITokenDocument {
+	return &APITokenDocument{
+		ID:         t.ID,
+		UserID:     t.UserID,
+		Name:       t.Name,
+		Scopes:     t.Scopes,
+		Hint:       t.Hint,
+		TokenHash:  tokenHash,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+// FromDocument creates an APIToken from APITokenDocument
+func FromDocument(doc *APITokenDocument) *APIToken {
+	if doc == nil {
+		return nil
+	}
+	return &APIToken{
+		ID:         doc.ID,
+		UserID:     doc.UserID,
+		Name:       doc.Name,
+		Scopes:     doc.Scopes,
+		Hint:       doc.Hint,
+		ExpiresAt:  doc.ExpiresAt,
+		LastUsedAt: doc.LastUsedAt,
+		CreatedAt:  doc.CreatedAt,
+	}
+}
+
+// IsExpired reports whether the token has passed its expiration time
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && now.After(*t.ExpiresAt)
+}
+
+// IsValidScope reports whether scope is a known scope
+func IsValidScope(scope string) bool {
+	return slices.Contains(ValidScopes, scope)
+}
+
+// HasScope reports whether the granted scopes allow the required scope
+// admin implies every scope and docs:write implies docs:read
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		switch {
+		case scope == required, scope == ScopeAdmin:
+			return true
+		case scope == ScopeDocsWrite && required == ScopeDocsRead:
+			return true
+		}
+	}
+	return false
+}
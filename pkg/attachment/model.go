@@ -0,0 +1,87 @@
+package attachment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment represents an image or file uploaded to a document
+// The file itself lives in blob storage under StorageKey
+type Attachment struct {
+	ID          string    `json:"id"`
+	DocumentID  string    `json:"document_id"`
+	UploaderID  string    `json:"uploader_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"` // Sniffed from the file's contents, not taken from the client
+	SizeBytes   int64     `json:"size_bytes"`
+	StorageKey  string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// NewAttachment creates a new attachment instance, stored under a key derived from its document and ID
+func NewAttachment(documentID, uploaderID, fileName, contentType string, sizeBytes int64) *Attachment {
+	id := uuid.New().String()
+	return &Attachment{
+		ID:          id,
+		DocumentID:  documentID,
+		UploaderID:  uploaderID,
+		FileName:    fileName,
+		ContentType: contentType,
+		SizeBytes:   sizeBytes,
+		StorageKey:  "documents/" + documentID + "/" + id,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// IsImage reports whether the attachment can be displayed inline as an image
+func (a *Attachment) IsImage() bool {
+	switch a.ContentType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
+// AttachmentDocument represents the attachment as stored in Couchbase
+type AttachmentDocument struct {
+	ID          string    `json:"id"`
+	DocumentID  string    `json:"document_id"`
+	UploaderID  string    `json:"uploader_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	SizeBytes   int64     `json:"size_bytes"`
+	StorageKey  string    `json:"storage_key"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToDocument converts Attachment to AttachmentDocument for database storage
+func (a *Attachment) ToDocument() *AttachmentDocument {
+	return &AttachmentDocument{
+		ID:          a.ID,
+		DocumentID:  a.DocumentID,
+		UploaderID:  a.UploaderID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		SizeBytes:   a.SizeBytes,
+		StorageKey:  a.StorageKey,
+		CreatedAt:   a.CreatedAt,
+	}
+}
+
+// FromDocument creates an Attachment from AttachmentDocument
+func FromDocument(doc *AttachmentDocument) *Attachment {
+	if doc == nil {
+		return nil
+	}
+	return &Attachment{
+		ID:          doc.ID,
+		DocumentID:  doc.DocumentID,
+		UploaderID:  doc.UploaderID,
+		FileName:    doc.FileName,
+		ContentType: doc.ContentType,
+		SizeBytes:   doc.SizeBytes,
+		StorageKey:  doc.StorageKey,
+		CreatedAt:   doc.CreatedAt,
+	}
+}
@@ -0,0 +1,442 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Namespaces and relationship types of Office Open XML
+const (
+	docxMainNS          = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	docxRelNS           = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	docxRelStyles       = docxRelNS + "/styles"
+	docxRelNumbering    = docxRelNS + "/numbering"
+	docxRelHyperlink    = docxRelNS + "/hyperlink"
+	docxRelDocument     = docxRelNS + "/officeDocument"
+	docxRelCoreProps    = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	docxXMLHeader       = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	docxTextWidth       = 9026 // Width between the margins of an A4 page, in twentieths of a point
+	docxListIndent      = 720  // Indent per list level, in twentieths of a point
+	docxFirstLinkRel    = 3    // Relationships 1 and 2 are the styles and numbering
+	docxBulletAbstract  = 0
+	docxOrderedAbstract = 1
+)
+
+// Paragraph and character styles written to and recognized in DOCX files
+const (
+	docxStyleTitle        = "Title"
+	docxStyleQuote        = "Quote"
+	docxStyleCode         = "Code"
+	docxStyleCodeChar     = "CodeChar"
+	docxStyleHyperlink    = "Hyperlink"
+	docxStyleTableHeading = "TableHeading"
+)
+
+// docxHeadingSizes are the font sizes of heading levels 1-6, in half-points
+var docxHeadingSizes = []int{40, 34, 30, 26, 24, 22}
+
+// docxBullets and docxNumberFormats cycle through list levels
+var (
+	docxBullets       = []string{"•", "◦", "▪"}
+	docxNumberFormats = []string{"decimal", "lowerLetter", "lowerRoman"}
+)
+
+// docxWriter builds the parts of a DOCX file that depend on the content
+type docxWriter struct {
+	body  strings.Builder
+	links map[string]string // Relationship IDs of hyperlink targets
+	rels  []string          // Hyperlink relationships, in order
+	nums  []string          // <w:num> elements, one per list so each restarts its numbering
+}
+
+// docxContext is where a block is being written
+type docxContext struct {
+	style string // Paragraph style, e.g. Quote inside block quotes
+	depth int    // List nesting depth, -1 outside lists
+	numID int    // Numbering of a list item's first paragraph, 0 once it is written
+}
+
+// RenderDOCX renders a tree as a Word document, with the title as its first paragraph
+// and the title, author and last-modified time in its document properties
+func RenderDOCX(doc *Node, meta ExportMetadata) ([]byte, error) {
+	w := &docxWriter{links: map[string]string{}}
+	if meta.Title != "" {
+		fmt.Fprintf(&w.body, `<w:p><w:pPr><w:pStyle w:val="%s"/></w:pPr><w:r><w:t xml:space="preserve">%s</w:t></w:r></w:p>`,
+			docxStyleTitle, xmlEscape(meta.Title))
+	}
+	w.blocks(doc.Content, &docxContext{depth: -1})
+
+	var out bytes.Buffer
+	zw := zip.NewWriter(&out)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"docProps/core.xml", docxCoreProperties(meta)},
+		{"word/_rels/document.xml.rels", w.documentRels()},
+		{"word/document.xml", w.document()},
+		{"word/styles.xml", docxStyles()},
+		{"word/numbering.xml", w.numbering()},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+		if _, err := f.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write DOCX: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// blocks writes block nodes
+func (w *docxWriter) blocks(nodes []*Node, ctx *docxContext) {
+	for _, n := range nodes {
+		w.block(n, ctx)
+	}
+}
+
+// block writes one block node
+func (w *docxWriter) block(n *Node, ctx *docxContext) {
+	switch n.Type {
+	case NodeParagraph:
+		w.paragraph(ctx, ctx.style, n.Attr("textAlign"), n.Content)
+	case NodeHeading:
+		level := min(max(n.IntAttr("level", 1), 1), 6)
+		w.paragraph(ctx, fmt.Sprintf("Heading%d", level), n.Attr("textAlign"), n.Content)
+	case NodeBlockquote:
+		quote := *ctx
+		quote.style = docxStyleQuote
+		w.blocks(n.Content, &quote)
+		ctx.numID = quote.numID
+	case NodeBulletList, NodeOrderedList:
+		numID := w.list(n.Type == NodeOrderedList, ctx.depth+1, n.IntAttr("start", 1))
+		for _, item := range n.Content {
+			itemCtx := &docxContext{style: ctx.style, depth: ctx.depth + 1, numID: numID}
+			w.blocks(item.Content, itemCtx)
+		}
+	case NodeCodeBlock:
+		w.paragraphStart(ctx, docxStyleCode, "")
+		w.body.WriteString("<w:r>")
+		w.text(n.TextContent())
+		w.body.WriteString("</w:r></w:p>")
+	case NodeHorizontalRule:
+		w.body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="auto"/></w:pBdr></w:pPr></w:p>`)
+	case NodeTable:
+		w.table(n)
+	case NodeImage, NodeText, NodeHardBreak:
+		w.paragraph(ctx, ctx.style, "", []*Node{n})
+	default:
+		w.blocks(n.Content, ctx)
+	}
+}
+
+// paragraph writes a paragraph of inline nodes
+func (w *docxWriter) paragraph(ctx *docxContext, style, align string, content []*Node) {
+	w.paragraphStart(ctx, style, align)
+	w.inline(content)
+	w.body.WriteString("</w:p>")
+}
+
+// paragraphStart opens a paragraph and writes its properties
+// The first paragraph of a list item is numbered; later ones are indented to line up with it
+func (w *docxWriter) paragraphStart(ctx *docxContext, style, align string) {
+	w.body.WriteString("<w:p><w:pPr>")
+	if style != "" {
+		fmt.Fprintf(&w.body, `<w:pStyle w:val="%s"/>`, style)
+	}
+	if ctx.numID != 0 {
+		fmt.Fprintf(&w.body, `<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, ctx.depth, ctx.numID)
+		ctx.numID = 0
+	} else if ctx.depth >= 0 {
+		fmt.Fprintf(&w.body, `<w:ind w:left="%d"/>`, docxListIndent*(ctx.depth+1))
+	}
+	switch align {
+	case "center", "right":
+		fmt.Fprintf(&w.body, `<w:jc w:val="%s"/>`, align)
+	case "justify":
+		w.body.WriteString(`<w:jc w:val="both"/>`)
+	}
+	w.body.WriteString("</w:pPr>")
+}
+
+// inline writes inline nodes as runs, grouping runs with the same link target into one hyperlink
+func (w *docxWriter) inline(nodes []*Node) {
+	nodes = mergeTextNodes(nodes)
+	for i := 0; i < len(nodes); {
+		href := docxLinkTarget(nodes[i])
+		if href == "" {
+			w.run(nodes[i], false)
+			i++
+			continue
+		}
+
+		fmt.Fprintf(&w.body, `<w:hyperlink r:id="%s">`, w.link(href))
+		for ; i < len(nodes) && docxLinkTarget(nodes[i]) == href; i++ {
+			w.run(nodes[i], true)
+		}
+		w.body.WriteString("</w:hyperlink>")
+	}
+}
+
+// run writes one inline node as a run
+func (w *docxWriter) run(n *Node, inLink bool) {
+	switch n.Type {
+	case NodeHardBreak:
+		w.body.WriteString("<w:r><w:br/></w:r>")
+	case NodeImage:
+		fmt.Fprintf(&w.body, `<w:r><w:rPr><w:i/></w:rPr><w:t xml:space="preserve">%s</w:t></w:r>`, xmlEscape(imagePlaceholder(n)))
+	case NodeText:
+		w.body.WriteString("<w:r><w:rPr>")
+		switch {
+		case n.HasMark(MarkCode):
+			fmt.Fprintf(&w.body, `<w:rStyle w:val="%s"/>`, docxStyleCodeChar)
+		case inLink:
+			fmt.Fprintf(&w.body, `<w:rStyle w:val="%s"/>`, docxStyleHyperlink)
+		}
+		if n.HasMark(MarkBold) {
+			w.body.WriteString("<w:b/>")
+		}
+		if n.HasMark(MarkItalic) {
+			w.body.WriteString("<w:i/>")
+		}
+		if n.HasMark(MarkStrike) {
+			w.body.WriteString("<w:strike/>")
+		}
+		if n.HasMark(MarkHighlight) {
+			w.body.WriteString(`<w:highlight w:val="yellow"/>`)
+		}
+		if n.HasMark(MarkUnderline) {
+			w.body.WriteString(`<w:u w:val="single"/>`)
+		}
+		w.body.WriteString("</w:rPr>")
+		w.text(n.Text)
+		w.body.WriteString("</w:r>")
+	}
+}
+
+// text writes the text of a run, turning newlines and tabs into breaks and tabs
+func (w *docxWriter) text(text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			w.body.WriteString("<w:br/>")
+		}
+		for j, part := range strings.Split(line, "\t") {
+			if j > 0 {
+				w.body.WriteString("<w:tab/>")
+			}
+			if part != "" {
+				fmt.Fprintf(&w.body, `<w:t xml:space="preserve">%s</w:t>`, xmlEscape(part))
+			}
+		}
+	}
+}
+
+// table writes a table with equal column widths, marking a leading row of header cells as the header row
+func (w *docxWriter) table(n *Node) {
+	columns := 0
+	for _, row := range n.Content {
+		width := 0
+		for _, cell := range row.Content {
+			width += max(cell.IntAttr("colspan", 1), 1)
+		}
+		columns = max(columns, width)
+	}
+	if columns == 0 {
+		return
+	}
+	columnWidth := docxTextWidth / columns
+
+	w.body.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr><w:tblGrid>`)
+	for i := 0; i < columns; i++ {
+		fmt.Fprintf(&w.body, `<w:gridCol w:w="%d"/>`, columnWidth)
+	}
+	w.body.WriteString("</w:tblGrid>")
+
+	for _, row := range n.Content {
+		w.body.WriteString("<w:tr>")
+		header := len(row.Content) > 0
+		for _, cell := range row.Content {
+			header = header && cell.Type == NodeTableHeader
+		}
+		if header {
+			w.body.WriteString("<w:trPr><w:tblHeader/></w:trPr>")
+		}
+
+		used := 0
+		for _, cell := range row.Content {
+			span := max(cell.IntAttr("colspan", 1), 1)
+			used += span
+			fmt.Fprintf(&w.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, columnWidth*span)
+			if span > 1 {
+				fmt.Fprintf(&w.body, `<w:gridSpan w:val="%d"/>`, span)
+			}
+			w.body.WriteString("</w:tcPr>")
+
+			ctx := &docxContext{depth: -1}
+			if cell.Type == NodeTableHeader {
+				ctx.style = docxStyleTableHeading
+			}
+			w.blocks(cell.Content, ctx)
+			// A cell must end with a paragraph
+			if len(cell.Content) == 0 || cell.Content[len(cell.Content)-1].Type == NodeTable {
+				w.body.WriteString("<w:p/>")
+			}
+			w.body.WriteString("</w:tc>")
+		}
+		for ; used < columns; used++ {
+			fmt.Fprintf(&w.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr><w:p/></w:tc>`, columnWidth)
+		}
+		w.body.WriteString("</w:tr>")
+	}
+	w.body.WriteString("</w:tbl>")
+}
+
+// list adds the numbering of a list and returns its ID
+// Ordered lists override their start so that each restarts its numbering
+func (w *docxWriter) list(ordered bool, depth, start int) int {
+	id := len(w.nums) + 1
+	abstract := docxBulletAbstract
+	if ordered {
+		abstract = docxOrderedAbstract
+	}
+
+	num := fmt.Sprintf(`<w:num w:numId="%d"><w:abstractNumId w:val="%d"/>`, id, abstract)
+	if ordered {
+		num += fmt.Sprintf(`<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="%d"/></w:lvlOverride>`, depth, start)
+	}
+	w.nums = append(w.nums, num+"</w:num>")
+	return id
+}
+
+// link returns the relationship ID of a hyperlink target, adding it if needed
+func (w *docxWriter) link(href string) string {
+	if id, ok := w.links[href]; ok {
+		return id
+	}
+	id := fmt.Sprintf("rId%d", docxFirstLinkRel+len(w.rels))
+	w.links[href] = id
+	w.rels = append(w.rels, fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s" TargetMode="External"/>`,
+		id, docxRelHyperlink, xmlEscape(href)))
+	return id
+}
+
+// document returns word/document.xml
+func (w *docxWriter) document() string {
+	return docxXMLHeader + `<w:document xmlns:w="` + docxMainNS + `" xmlns:r="` + docxRelNS + `"><w:body>` +
+		w.body.String() +
+		`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="708" w:footer="708" w:gutter="0"/></w:sectPr>` +
+		`</w:body></w:document>`
+}
+
+// documentRels returns word/_rels/document.xml.rels
+func (w *docxWriter) documentRels() string {
+	return docxXMLHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + docxRelStyles + `" Target="styles.xml"/>` +
+		`<Relationship Id="rId2" Type="` + docxRelNumbering + `" Target="numbering.xml"/>` +
+		strings.Join(w.rels, "") + `</Relationships>`
+}
+
+// numbering returns word/numbering.xml, with a bullet and an ordered list definition
+func (w *docxWriter) numbering() string {
+	var b strings.Builder
+	b.WriteString(docxXMLHeader + `<w:numbering xmlns:w="` + docxMainNS + `">`)
+	for _, ordered := range []bool{false, true} {
+		abstract := docxBulletAbstract
+		if ordered {
+			abstract = docxOrderedAbstract
+		}
+		fmt.Fprintf(&b, `<w:abstractNum w:abstractNumId="%d"><w:multiLevelType w:val="hybridMultilevel"/>`, abstract)
+		for level := 0; level < 9; level++ {
+			format, text := "bullet", docxBullets[level%len(docxBullets)]
+			if ordered {
+				format, text = docxNumberFormats[level%len(docxNumberFormats)], fmt.Sprintf("%%%d.", level+1)
+			}
+			fmt.Fprintf(&b, `<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				level, format, text, docxListIndent*(level+1))
+		}
+		b.WriteString("</w:abstractNum>")
+	}
+	b.WriteString(strings.Join(w.nums, ""))
+	b.WriteString("</w:numbering>")
+	return b.String()
+}
+
+// docxLinkTarget returns the target of a text node's link, or "" if it has no safe link
+func docxLinkTarget(n *Node) string {
+	if link := n.Mark(MarkLink); link != nil && IsSafeURL(link.Attr("href")) {
+		return link.Attr("href")
+	}
+	return ""
+}
+
+// docxCoreProperties returns docProps/core.xml with the document's metadata
+func docxCoreProperties(meta ExportMetadata) string {
+	var b strings.Builder
+	b.WriteString(docxXMLHeader + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`)
+	fmt.Fprintf(&b, "<dc:title>%s</dc:title>", xmlEscape(meta.Title))
+	if meta.Author != "" {
+		fmt.Fprintf(&b, "<dc:creator>%s</dc:creator>", xmlEscape(meta.Author))
+	}
+	if !meta.ModifiedAt.IsZero() {
+		fmt.Fprintf(&b, `<dcterms:modified xsi:type="dcterms:W3CDTF">%s</dcterms:modified>`, meta.ModifiedAt.UTC().Format(time.RFC3339))
+	}
+	b.WriteString("</cp:coreProperties>")
+	return b.String()
+}
+
+// docxStyles returns word/styles.xml with the styles the writer uses
+func docxStyles() string {
+	var b strings.Builder
+	b.WriteString(docxXMLHeader + `<w:styles xmlns:w="` + docxMainNS + `">`)
+	b.WriteString(`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>`)
+	b.WriteString(`<w:pPrDefault><w:pPr><w:spacing w:after="160" w:line="276" w:lineRule="auto"/></w:pPr></w:pPrDefault></w:docDefaults>`)
+	b.WriteString(`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>`)
+	b.WriteString(`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="48"/><w:szCs w:val="48"/></w:rPr></w:style>`)
+	for i, size := range docxHeadingSizes {
+		fmt.Fprintf(&b, `<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="%d"/></w:pPr><w:rPr><w:b/><w:sz w:val="%d"/><w:szCs w:val="%d"/></w:rPr></w:style>`,
+			i+1, i+1, i, size, size)
+	}
+	b.WriteString(`<w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:pBdr><w:left w:val="single" w:sz="18" w:space="8" w:color="D0D7DE"/></w:pBdr><w:ind w:left="360"/></w:pPr><w:rPr><w:color w:val="656D76"/></w:rPr></w:style>`)
+	b.WriteString(`<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:shd w:val="clear" w:color="auto" w:fill="F6F8FA"/><w:spacing w:after="160" w:line="240" w:lineRule="auto"/></w:pPr><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/><w:sz w:val="20"/><w:szCs w:val="20"/></w:rPr></w:style>`)
+	b.WriteString(`<w:style w:type="paragraph" w:styleId="TableHeading"><w:name w:val="Table Heading"/><w:basedOn w:val="Normal"/><w:rPr><w:b/></w:rPr></w:style>`)
+	b.WriteString(`<w:style w:type="character" w:styleId="CodeChar"><w:name w:val="Code Char"/><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New" w:cs="Courier New"/></w:rPr></w:style>`)
+	b.WriteString(`<w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>`)
+	b.WriteString(`<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		fmt.Fprintf(&b, `<w:%s w:val="single" w:sz="4" w:space="0" w:color="auto"/>`, side)
+	}
+	b.WriteString(`</w:tblBorders><w:tblCellMar><w:left w:w="108" w:type="dxa"/><w:right w:w="108" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>`)
+	b.WriteString("</w:styles>")
+	return b.String()
+}
+
+// docxContentTypes is [Content_Types].xml
+const docxContentTypes = docxXMLHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`</Types>`
+
+// docxPackageRels is _rels/.rels
+const docxPackageRels = docxXMLHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="` + docxRelDocument + `" Target="word/document.xml"/>` +
+	`<Relationship Id="rId2" Type="` + docxRelCoreProps + `" Target="docProps/core.xml"/>` +
+	`</Relationships>`
+
+// xmlEscape escapes text for XML content and attribute values
+func xmlEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
@@ -0,0 +1,541 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxDOCXPartSize limits how much of one part of a DOCX file is decompressed
+const maxDOCXPartSize = 64 << 20
+
+// docxMonospaceFonts are fonts whose runs are read as inline code
+var docxMonospaceFonts = []string{"courier", "courier new", "consolas", "menlo", "monaco", "source code pro", "lucida console"}
+
+// xmlElement is an element of a parsed XML part, with names stripped of their namespace
+type xmlElement struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlElement
+	text     string
+}
+
+// child returns the first child element with the given name, or nil
+func (e *xmlElement) child(name string) *xmlElement {
+	if e == nil {
+		return nil
+	}
+	for _, c := range e.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// attr returns an attribute of the element, or "" if it or the element is missing
+func (e *xmlElement) attr(name string) string {
+	if e == nil {
+		return ""
+	}
+	return e.attrs[name]
+}
+
+// on reports whether a toggle property such as <w:b/> is present and not switched off
+func (e *xmlElement) on() bool {
+	if e == nil {
+		return false
+	}
+	switch e.attrs["val"] {
+	case "0", "false", "off", "none":
+		return false
+	}
+	return true
+}
+
+// parseXMLElement decodes an XML part into an element tree
+func parseXMLElement(data []byte) (*xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	root := &xmlElement{}
+	stack := []*xmlElement{root}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		top := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			e := &xmlElement{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				e.attrs[a.Name.Local] = a.Value
+			}
+			top.children = append(top.children, e)
+			stack = append(stack, e)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top.text += string(t)
+		}
+	}
+
+	if len(root.children) == 0 {
+		return nil, errors.New("empty XML part")
+	}
+	return root.children[0], nil
+}
+
+// docxStyle is what a paragraph or character style means for the tree
+type docxStyle struct {
+	heading int // Heading level, 0 if not a heading
+	title   bool
+	quote   bool
+	code    bool
+}
+
+// docxNumbering describes one level of a list definition
+type docxNumbering struct {
+	ordered bool
+	start   int
+}
+
+// docxParser converts the parts of a DOCX file to a tree
+type docxParser struct {
+	styles    map[string]docxStyle
+	numbering map[string][]docxNumbering // List levels by numId
+	links     map[string]string          // Hyperlink targets by relationship ID
+	title     string
+}
+
+// ParseDOCX parses a Word document into a tree
+// A paragraph in the Title style at the start is returned as the title instead of being part of the content
+func ParseDOCX(data []byte) (*Node, string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid DOCX file: %w", err)
+	}
+
+	parts := map[string]*zip.File{}
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+	readPart := func(name string) (*xmlElement, error) {
+		f, ok := parts[name]
+		if !ok {
+			return nil, nil
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		content, err := io.ReadAll(io.LimitReader(rc, maxDOCXPartSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > maxDOCXPartSize {
+			return nil, fmt.Errorf("%s is too large", name)
+		}
+		return parseXMLElement(content)
+	}
+
+	document, err := readPart("word/document.xml")
+	if err == nil && document.child("body") == nil {
+		err = errors.New("word/document.xml has no body")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid DOCX file: %w", err)
+	}
+
+	// Styles, numbering and relationships are optional
+	p := &docxParser{styles: map[string]docxStyle{}, numbering: map[string][]docxNumbering{}, links: map[string]string{}}
+	if styles, err := readPart("word/styles.xml"); err == nil && styles != nil {
+		p.readStyles(styles)
+	}
+	if numbering, err := readPart("word/numbering.xml"); err == nil && numbering != nil {
+		p.readNumbering(numbering)
+	}
+	if rels, err := readPart("word/_rels/document.xml.rels"); err == nil && rels != nil {
+		for _, rel := range rels.children {
+			if strings.HasSuffix(rel.attr("Type"), "/hyperlink") {
+				p.links[rel.attr("Id")] = rel.attr("Target")
+			}
+		}
+	}
+
+	b := &docxBuilder{parser: p, titleAllowed: true}
+	b.elements(document.child("body").children)
+	return NewNode(NodeDoc, b.blocks...), p.title, nil
+}
+
+// readStyles records the styles that map to headings, titles, quotes and code
+func (p *docxParser) readStyles(styles *xmlElement) {
+	for _, s := range styles.children {
+		if s.name != "style" {
+			continue
+		}
+		id := s.attr("styleId")
+		name := strings.ToLower(s.child("name").attr("val"))
+
+		var style docxStyle
+		switch {
+		case name == "title" || id == docxStyleTitle:
+			style.title = true
+		case strings.HasPrefix(name, "heading "):
+			style.heading, _ = strconv.Atoi(strings.TrimPrefix(name, "heading "))
+		case strings.Contains(name, "quote"):
+			style.quote = true
+		case strings.Contains(name, "code") || strings.Contains(name, "source") || strings.Contains(name, "preformatted"):
+			style.code = true
+		}
+		if level := s.child("pPr").child("outlineLvl").attr("val"); level != "" && style.heading == 0 && !style.title {
+			if n, err := strconv.Atoi(level); err == nil && n < 6 {
+				style.heading = n + 1
+			}
+		}
+		if style.heading > 6 {
+			style.heading = 0
+		}
+		p.styles[id] = style
+	}
+}
+
+// readNumbering records whether each list level is ordered and where it starts
+func (p *docxParser) readNumbering(numbering *xmlElement) {
+	abstracts := map[string][]docxNumbering{}
+	for _, a := range numbering.children {
+		if a.name != "abstractNum" {
+			continue
+		}
+		var levels []docxNumbering
+		for _, lvl := range a.children {
+			if lvl.name != "lvl" {
+				continue
+			}
+			format := lvl.child("numFmt").attr("val")
+			start, err := strconv.Atoi(lvl.child("start").attr("val"))
+			if err != nil {
+				start = 1
+			}
+			levels = append(levels, docxNumbering{ordered: format != "bullet" && format != "none" && format != "", start: start})
+		}
+		abstracts[a.attr("abstractNumId")] = levels
+	}
+
+	for _, num := range numbering.children {
+		if num.name != "num" {
+			continue
+		}
+		levels := slices.Clone(abstracts[num.child("abstractNumId").attr("val")])
+		for _, override := range num.children {
+			if override.name != "lvlOverride" {
+				continue
+			}
+			level, err := strconv.Atoi(override.attr("ilvl"))
+			start, startErr := strconv.Atoi(override.child("startOverride").attr("val"))
+			if err == nil && startErr == nil && level >= 0 && level < len(levels) {
+				levels[level].start = start
+			}
+		}
+		p.numbering[num.attr("numId")] = levels
+	}
+}
+
+// docxListFrame is an open list while building the tree
+type docxListFrame struct {
+	list  *Node
+	level int
+	numID string
+}
+
+// docxBuilder assembles paragraphs into blocks, grouping list paragraphs into nested lists,
+// quote paragraphs into block quotes and code paragraphs into code blocks
+type docxBuilder struct {
+	parser       *docxParser
+	blocks       []*Node
+	lists        []docxListFrame
+	quote        *Node
+	lastCode     bool
+	titleAllowed bool
+}
+
+// elements processes the block-level elements of a body or table cell
+func (b *docxBuilder) elements(elements []*xmlElement) {
+	for _, e := range elements {
+		switch e.name {
+		case "p":
+			b.paragraph(e)
+		case "tbl":
+			b.add(b.table(e), false)
+		case "sdt":
+			b.elements(e.child("sdtContent").children)
+		case "customXml", "ins", "smartTag":
+			b.elements(e.children)
+		}
+	}
+}
+
+// add appends a block to the document or the open block quote, closing open lists
+func (b *docxBuilder) add(block *Node, quote bool) {
+	b.lists = nil
+	b.lastCode = false
+	b.titleAllowed = false
+	if !quote {
+		b.quote = nil
+		b.blocks = append(b.blocks, block)
+		return
+	}
+	if b.quote == nil {
+		b.quote = NewNode(NodeBlockquote)
+		b.blocks = append(b.blocks, b.quote)
+	}
+	b.quote.Content = append(b.quote.Content, block)
+}
+
+// paragraph converts a <w:p> and adds it to the tree
+func (b *docxBuilder) paragraph(p *xmlElement) {
+	props := p.child("pPr")
+	style := b.parser.styles[props.child("pStyle").attr("val")]
+	content := trimInline(b.parser.inline(p.children, nil))
+
+	if style.title && b.titleAllowed && b.parser.title == "" {
+		b.parser.title = strings.TrimSpace(NewNode(NodeParagraph, content...).TextContent())
+		b.titleAllowed = false
+		return
+	}
+
+	// A paragraph with only a bottom border is a horizontal rule
+	if props.child("pBdr").child("bottom") != nil && len(content) == 0 {
+		b.add(NewNode(NodeHorizontalRule), false)
+		return
+	}
+
+	if style.code {
+		text := docxPlainText(p)
+		if b.lastCode {
+			last := b.blocks[len(b.blocks)-1]
+			last.Content[0].Text += "\n" + text
+			return
+		}
+		code := NewNode(NodeCodeBlock)
+		code.Content = []*Node{NewText(text)}
+		b.add(code, false)
+		b.lastCode = true
+		return
+	}
+
+	var block *Node
+	switch {
+	case style.heading > 0 || style.title:
+		block = NewNode(NodeHeading, content...)
+		block.SetAttr("level", max(style.heading, 1))
+	default:
+		block = NewNode(NodeParagraph, content...)
+	}
+	switch props.child("jc").attr("val") {
+	case "center":
+		block.SetAttr("textAlign", "center")
+	case "right", "end":
+		block.SetAttr("textAlign", "right")
+	case "both", "distribute":
+		block.SetAttr("textAlign", "justify")
+	}
+
+	numID := props.child("numPr").child("numId").attr("val")
+	levels := b.parser.numbering[numID]
+	if numID != "" && numID != "0" && len(levels) > 0 {
+		level, _ := strconv.Atoi(props.child("numPr").child("ilvl").attr("val"))
+		b.listItem(block, numID, min(max(level, 0), len(levels)-1), levels, style.quote)
+		return
+	}
+
+	// Indented paragraphs after a list item continue the item at the level of their indent
+	if left, err := strconv.Atoi(props.child("ind").attr("left")); err == nil && left > 0 && len(b.lists) > 0 {
+		for len(b.lists) > 1 && b.lists[len(b.lists)-1].level > left/docxListIndent-1 {
+			b.lists = b.lists[:len(b.lists)-1]
+		}
+		items := b.lists[len(b.lists)-1].list.Content
+		item := items[len(items)-1]
+		item.Content = append(item.Content, block)
+		return
+	}
+
+	b.add(block, style.quote)
+}
+
+// listItem adds a numbered paragraph as a new item, opening and closing lists to reach its level
+func (b *docxBuilder) listItem(block *Node, numID string, level int, levels []docxNumbering, quote bool) {
+	b.lastCode = false
+	b.titleAllowed = false
+
+	for len(b.lists) > 0 {
+		top := b.lists[len(b.lists)-1]
+		if top.level < level || (top.level == level && top.numID == numID) {
+			break
+		}
+		b.lists = b.lists[:len(b.lists)-1]
+	}
+
+	if len(b.lists) == 0 || b.lists[len(b.lists)-1].level < level {
+		listType := NodeBulletList
+		if levels[level].ordered {
+			listType = NodeOrderedList
+		}
+		list := NewNode(listType)
+		if listType == NodeOrderedList && levels[level].start != 1 {
+			list.SetAttr("start", levels[level].start)
+		}
+
+		if len(b.lists) == 0 {
+			b.add(list, quote)
+		} else {
+			items := b.lists[len(b.lists)-1].list.Content
+			item := items[len(items)-1]
+			item.Content = append(item.Content, list)
+		}
+		b.lists = append(b.lists, docxListFrame{list: list, level: level, numID: numID})
+	}
+
+	top := b.lists[len(b.lists)-1]
+	top.list.Content = append(top.list.Content, NewNode(NodeListItem, block))
+}
+
+// table converts a <w:tbl>, reading rows marked as header rows as header cells
+func (b *docxBuilder) table(tbl *xmlElement) *Node {
+	table := NewNode(NodeTable)
+	for _, tr := range tbl.children {
+		if tr.name != "tr" {
+			continue
+		}
+		cellType := NodeTableCell
+		if tr.child("trPr").child("tblHeader").on() {
+			cellType = NodeTableHeader
+		}
+
+		row := NewNode(NodeTableRow)
+		for _, tc := range tr.children {
+			if tc.name != "tc" {
+				continue
+			}
+			cellBuilder := &docxBuilder{parser: b.parser}
+			cellBuilder.elements(tc.children)
+			cell := NewNode(cellType, cellBuilder.blocks...)
+			if len(cell.Content) == 0 {
+				cell.Content = []*Node{NewNode(NodeParagraph)}
+			}
+			if span, err := strconv.Atoi(tc.child("tcPr").child("gridSpan").attr("val")); err == nil && span > 1 {
+				cell.SetAttr("colspan", span)
+			}
+			row.Content = append(row.Content, cell)
+		}
+		table.Content = append(table.Content, row)
+	}
+	return table
+}
+
+// inline converts the runs inside a paragraph or hyperlink to inline nodes
+func (p *docxParser) inline(elements []*xmlElement, link *Mark) []*Node {
+	var nodes []*Node
+	for _, e := range elements {
+		switch e.name {
+		case "r":
+			nodes = append(nodes, p.run(e, link)...)
+		case "hyperlink":
+			target := p.links[e.attr("id")]
+			if anchor := e.attr("anchor"); target == "" && anchor != "" {
+				target = "#" + anchor
+			}
+			var mark *Mark
+			if target != "" {
+				mark = &Mark{Type: MarkLink, Attrs: map[string]interface{}{"href": target}}
+			}
+			nodes = append(nodes, p.inline(e.children, mark)...)
+		case "ins", "smartTag", "customXml", "fldSimple":
+			nodes = append(nodes, p.inline(e.children, link)...)
+		case "sdt":
+			nodes = append(nodes, p.inline(e.child("sdtContent").children, link)...)
+		}
+	}
+	return nodes
+}
+
+// run converts a <w:r> to inline nodes
+func (p *docxParser) run(r *xmlElement, link *Mark) []*Node {
+	props := r.child("rPr")
+	var marks []Mark
+	if link != nil {
+		marks = append(marks, *link)
+	}
+	if props.child("b").on() {
+		marks = append(marks, Mark{Type: MarkBold})
+	}
+	if props.child("i").on() {
+		marks = append(marks, Mark{Type: MarkItalic})
+	}
+	if props.child("strike").on() || props.child("dstrike").on() {
+		marks = append(marks, Mark{Type: MarkStrike})
+	}
+	if props.child("u").on() {
+		marks = append(marks, Mark{Type: MarkUnderline})
+	}
+	if props.child("highlight").on() {
+		marks = append(marks, Mark{Type: MarkHighlight})
+	}
+	font := strings.ToLower(props.child("rFonts").attr("ascii"))
+	if p.styles[props.child("rStyle").attr("val")].code || slices.Contains(docxMonospaceFonts, font) {
+		marks = append(marks, Mark{Type: MarkCode})
+	}
+
+	var nodes []*Node
+	for _, e := range r.children {
+		switch e.name {
+		case "t":
+			nodes = append(nodes, NewText(e.text, cloneMarks(marks)...))
+		case "tab":
+			nodes = append(nodes, NewText(" ", cloneMarks(marks)...))
+		case "br", "cr":
+			if e.attr("type") != "page" && e.attr("type") != "column" {
+				nodes = append(nodes, NewNode(NodeHardBreak))
+			}
+		case "noBreakHyphen":
+			nodes = append(nodes, NewText("-", cloneMarks(marks)...))
+		}
+	}
+	return nodes
+}
+
+// docxPlainText returns the text of a paragraph keeping tabs and line breaks, for code
+func docxPlainText(p *xmlElement) string {
+	var b strings.Builder
+	var walk func(e *xmlElement)
+	walk = func(e *xmlElement) {
+		switch e.name {
+		case "t":
+			b.WriteString(e.text)
+		case "tab":
+			b.WriteString("\t")
+		case "br", "cr":
+			b.WriteString("\n")
+		case "pPr", "rPr", "del":
+		default:
+			for _, c := range e.children {
+				walk(c)
+			}
+		}
+	}
+	walk(p)
+	return b.String()
+}
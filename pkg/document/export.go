@@ -0,0 +1,159 @@
+package document
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// ExportMetadata describes a document in its exported forms
+type ExportMetadata struct {
+	Title      string
+	Author     string
+	ModifiedAt time.Time
+}
+
+// byline returns the author and last-modified line shown under the title
+func (m ExportMetadata) byline() string {
+	var parts []string
+	if m.Author != "" {
+		parts = append(parts, "By "+m.Author)
+	}
+	if !m.ModifiedAt.IsZero() {
+		parts = append(parts, "Last modified "+m.ModifiedAt.UTC().Format("2 January 2006, 15:04 MST"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// exportHTMLStyle is the stylesheet of exported HTML pages
+const exportHTMLStyle = `body{max-width:48rem;margin:2rem auto;padding:0 1rem;font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;line-height:1.6;color:#1f2328}
+header{border-bottom:1px solid #d0d7de;margin-bottom:1.5rem}
+.byline{color:#656d76;font-size:.875rem}
+pre{background:#f6f8fa;padding:.75rem 1rem;overflow:auto}
+code{font-family:ui-monospace,Menlo,Consolas,monospace;font-size:.9em}
+blockquote{margin-left:0;padding-left:1rem;border-left:.25rem solid #d0d7de;color:#656d76}
+table{border-collapse:collapse}
+th,td{border:1px solid #d0d7de;padding:.25rem .75rem}
+img{max-width:100%}`
+
+// RenderHTMLPage renders a tree as a standalone HTML page with the document's metadata
+// The content goes through the tree, so only the editor's elements and safe URLs are kept,
+// and the page's content security policy blocks scripts regardless
+func RenderHTMLPage(doc *Node, meta ExportMetadata) string {
+	var b strings.Builder
+	title := html.EscapeString(meta.Title)
+
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta http-equiv=\"Content-Security-Policy\" content=\"default-src 'none'; img-src * data:; style-src 'unsafe-inline'\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", title)
+	if meta.Author != "" {
+		fmt.Fprintf(&b, "<meta name=\"author\" content=\"%s\">\n", html.EscapeString(meta.Author))
+	}
+	if !meta.ModifiedAt.IsZero() {
+		fmt.Fprintf(&b, "<meta name=\"dcterms.modified\" content=\"%s\">\n", meta.ModifiedAt.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "<style>\n%s\n</style>\n</head>\n<body>\n", exportHTMLStyle)
+
+	fmt.Fprintf(&b, "<header>\n<h1>%s</h1>\n", title)
+	if byline := meta.byline(); byline != "" {
+		fmt.Fprintf(&b, "<p class=\"byline\">%s</p>\n", html.EscapeString(byline))
+	}
+	b.WriteString("</header>\n<main>\n")
+	b.WriteString(RenderHTML(doc))
+	b.WriteString("\n</main>\n</body>\n</html>\n")
+
+	return b.String()
+}
+
+// RenderText renders a tree as plain text, headed by the document's metadata
+func RenderText(doc *Node, meta ExportMetadata) string {
+	var b strings.Builder
+	b.WriteString(meta.Title + "\n")
+	if byline := meta.byline(); byline != "" {
+		b.WriteString(byline + "\n")
+	}
+	b.WriteString("\n")
+
+	if body := renderTextBlocks(doc.Content); body != "" {
+		b.WriteString(body + "\n")
+	}
+	return b.String()
+}
+
+// renderTextBlocks renders block nodes as plain text separated by blank lines
+func renderTextBlocks(blocks []*Node) string {
+	var parts []string
+	for _, block := range blocks {
+		if part := renderTextBlock(block); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// renderTextBlock renders one block node as plain text
+func renderTextBlock(n *Node) string {
+	switch n.Type {
+	case NodeParagraph, NodeHeading:
+		return renderTextInline(n.Content)
+	case NodeBlockquote:
+		return prefixLines(renderTextBlocks(n.Content), "> ", ">")
+	case NodeBulletList, NodeOrderedList:
+		start := n.IntAttr("start", 1)
+		var items []string
+		for i, item := range n.Content {
+			marker := "- "
+			if n.Type == NodeOrderedList {
+				marker = fmt.Sprintf("%d. ", start+i)
+			}
+			items = append(items, marker+indentLines(renderTextBlocks(item.Content), strings.Repeat(" ", len(marker))))
+		}
+		return strings.Join(items, "\n")
+	case NodeCodeBlock:
+		return prefixLines(n.TextContent(), "    ", "")
+	case NodeHorizontalRule:
+		return strings.Repeat("-", 40)
+	case NodeTable:
+		var rows []string
+		for _, row := range n.Content {
+			var cells []string
+			for _, cell := range row.Content {
+				cells = append(cells, strings.ReplaceAll(renderTextBlocks(cell.Content), "\n", " "))
+			}
+			rows = append(rows, strings.Join(cells, " | "))
+		}
+		return strings.Join(rows, "\n")
+	case NodeImage, NodeText, NodeHardBreak:
+		return renderTextInline([]*Node{n})
+	}
+	return renderTextBlocks(n.Content)
+}
+
+// renderTextInline renders inline nodes as plain text, following link text with its target
+func renderTextInline(nodes []*Node) string {
+	var b strings.Builder
+	for _, n := range mergeTextNodes(nodes) {
+		switch n.Type {
+		case NodeText:
+			b.WriteString(n.Text)
+			if link := n.Mark(MarkLink); link != nil && link.Attr("href") != n.Text {
+				fmt.Fprintf(&b, " (%s)", link.Attr("href"))
+			}
+		case NodeHardBreak:
+			b.WriteString("\n")
+		case NodeImage:
+			b.WriteString(imagePlaceholder(n))
+		}
+	}
+	return b.String()
+}
+
+// imagePlaceholder describes an image in formats that cannot show it
+func imagePlaceholder(n *Node) string {
+	if alt := n.Attr("alt"); alt != "" {
+		return "[Image: " + alt + "]"
+	}
+	return "[Image]"
+}
@@ -0,0 +1,470 @@
+package document
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// whitespaceRegex matches runs of HTML whitespace, which collapse to one space outside <pre>
+	whitespaceRegex = regexp.MustCompile(`[ \t\n\r\f]+`)
+	// textAlignRegex extracts the alignment from a style attribute
+	textAlignRegex = regexp.MustCompile(`text-align:\s*(left|center|right|justify)`)
+)
+
+// ParseHTML parses the editor's HTML into a tree
+// Unknown elements are unwrapped, keeping their content
+func ParseHTML(content string) (*Node, error) {
+	body := &nethtml.Node{Type: nethtml.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := nethtml.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document HTML: %w", err)
+	}
+
+	var p htmlParser
+	return NewNode(NodeDoc, p.blocks(nodes)...), nil
+}
+
+// htmlParser converts HTML nodes to tree nodes
+type htmlParser struct{}
+
+// blocks converts a list of HTML nodes to block nodes, wrapping stray inline content in paragraphs
+func (p *htmlParser) blocks(nodes []*nethtml.Node) []*Node {
+	var blocks []*Node
+	var inline []*Node
+
+	flush := func() {
+		inline = trimInline(inline)
+		if len(inline) > 0 {
+			blocks = append(blocks, NewNode(NodeParagraph, inline...))
+		}
+		inline = nil
+	}
+
+	for _, n := range nodes {
+		if block, ok := p.block(n); ok {
+			flush()
+			blocks = append(blocks, block...)
+			continue
+		}
+		inline = append(inline, p.inline(n, nil)...)
+	}
+	flush()
+
+	return blocks
+}
+
+// block converts an HTML node to block nodes; ok is false for inline content
+func (p *htmlParser) block(n *nethtml.Node) ([]*Node, bool) {
+	if n.Type != nethtml.ElementNode {
+		return nil, false
+	}
+
+	switch n.DataAtom {
+	case atom.P:
+		para := NewNode(NodeParagraph, trimInline(p.inlineChildren(n, nil))...)
+		setTextAlign(para, n)
+		return []*Node{para}, true
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		heading := NewNode(NodeHeading, trimInline(p.inlineChildren(n, nil))...)
+		heading.SetAttr("level", int(n.Data[1]-'0'))
+		setTextAlign(heading, n)
+		return []*Node{heading}, true
+	case atom.Blockquote:
+		return []*Node{NewNode(NodeBlockquote, p.blocks(children(n))...)}, true
+	case atom.Ul:
+		return []*Node{NewNode(NodeBulletList, p.listItems(n)...)}, true
+	case atom.Ol:
+		list := NewNode(NodeOrderedList, p.listItems(n)...)
+		if start, err := strconv.Atoi(attr(n, "start")); err == nil && start != 1 {
+			list.SetAttr("start", start)
+		}
+		return []*Node{list}, true
+	case atom.Li:
+		return []*Node{NewNode(NodeListItem, p.blocks(children(n))...)}, true
+	case atom.Pre:
+		code := NewNode(NodeCodeBlock)
+		text := htmlText(n)
+		if text != "" {
+			code.Content = []*Node{NewText(strings.TrimSuffix(text, "\n"))}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.DataAtom == atom.Code {
+				for _, class := range strings.Fields(attr(c, "class")) {
+					if language, ok := strings.CutPrefix(class, "language-"); ok {
+						code.SetAttr("language", language)
+					}
+				}
+			}
+		}
+		return []*Node{code}, true
+	case atom.Hr:
+		return []*Node{NewNode(NodeHorizontalRule)}, true
+	case atom.Table:
+		return []*Node{NewNode(NodeTable, p.tableRows(n)...)}, true
+	case atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Aside, atom.Nav,
+		atom.Figure, atom.Html, atom.Body:
+		return p.blocks(children(n)), true
+	case atom.Head, atom.Script, atom.Style, atom.Template:
+		return nil, true
+	}
+
+	return nil, false
+}
+
+// listItems converts the children of a list, wrapping anything that is not an <li>
+func (p *htmlParser) listItems(n *nethtml.Node) []*Node {
+	var items []*Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == nethtml.TextNode && strings.TrimSpace(c.Data) == "" {
+			continue
+		}
+		if c.DataAtom == atom.Li {
+			items = append(items, NewNode(NodeListItem, p.blocks(children(c))...))
+			continue
+		}
+		if blocks := p.blocks([]*nethtml.Node{c}); len(blocks) > 0 {
+			items = append(items, NewNode(NodeListItem, blocks...))
+		}
+	}
+	return items
+}
+
+// tableRows collects the rows of a table, including those in <thead>, <tbody> and <tfoot>
+func (p *htmlParser) tableRows(n *nethtml.Node) []*Node {
+	var rows []*Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch c.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			rows = append(rows, p.tableRows(c)...)
+		case atom.Tr:
+			row := NewNode(NodeTableRow)
+			for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+				cellType := NodeTableCell
+				switch cell.DataAtom {
+				case atom.Th:
+					cellType = NodeTableHeader
+				case atom.Td:
+				default:
+					continue
+				}
+				node := NewNode(cellType, p.blocks(children(cell))...)
+				if len(node.Content) == 0 {
+					node.Content = []*Node{NewNode(NodeParagraph)}
+				}
+				for _, span := range []string{"colspan", "rowspan"} {
+					if value, err := strconv.Atoi(attr(cell, span)); err == nil && value > 1 {
+						node.SetAttr(span, value)
+					}
+				}
+				row.Content = append(row.Content, node)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// inlineChildren converts the children of an element to inline nodes
+func (p *htmlParser) inlineChildren(n *nethtml.Node, marks []Mark) []*Node {
+	var nodes []*Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, p.inline(c, marks)...)
+	}
+	return nodes
+}
+
+// inline converts an HTML node to inline nodes carrying the given marks
+func (p *htmlParser) inline(n *nethtml.Node, marks []Mark) []*Node {
+	switch n.Type {
+	case nethtml.TextNode:
+		text := whitespaceRegex.ReplaceAllString(n.Data, " ")
+		if text == "" {
+			return nil
+		}
+		return []*Node{NewText(text, marks...)}
+	case nethtml.ElementNode:
+	default:
+		return nil
+	}
+
+	var mark *Mark
+	switch n.DataAtom {
+	case atom.Br:
+		return []*Node{NewNode(NodeHardBreak)}
+	case atom.Img:
+		image := NewNode(NodeImage)
+		for _, name := range []string{"src", "alt", "title"} {
+			if value := attr(n, name); value != "" {
+				image.SetAttr(name, value)
+			}
+		}
+		return []*Node{image}
+	case atom.Strong, atom.B:
+		mark = &Mark{Type: MarkBold}
+	case atom.Em, atom.I:
+		mark = &Mark{Type: MarkItalic}
+	case atom.S, atom.Strike, atom.Del:
+		mark = &Mark{Type: MarkStrike}
+	case atom.U:
+		mark = &Mark{Type: MarkUnderline}
+	case atom.Mark:
+		mark = &Mark{Type: MarkHighlight}
+	case atom.Code:
+		// Inline code keeps its whitespace and cannot contain other marks
+		return []*Node{NewText(htmlText(n), append(cloneMarks(marks), Mark{Type: MarkCode})...)}
+	case atom.A:
+		if href := attr(n, "href"); href != "" {
+			mark = &Mark{Type: MarkLink, Attrs: map[string]interface{}{"href": href}}
+			if title := attr(n, "title"); title != "" {
+				mark.Attrs["title"] = title
+			}
+		}
+	case atom.Script, atom.Style:
+		return nil
+	}
+
+	if mark != nil {
+		marks = append(cloneMarks(marks), *mark)
+	}
+	return p.inlineChildren(n, marks)
+}
+
+// RenderHTML renders a tree as the editor's HTML
+func RenderHTML(doc *Node) string {
+	var b strings.Builder
+	for _, child := range doc.Content {
+		renderHTMLNode(&b, child)
+	}
+	return b.String()
+}
+
+// renderHTMLNode writes a node and its children as HTML
+func renderHTMLNode(b *strings.Builder, n *Node) {
+	switch n.Type {
+	case NodeText:
+		renderHTMLText(b, n)
+		return
+	case NodeHardBreak:
+		b.WriteString("<br>")
+		return
+	case NodeHorizontalRule:
+		b.WriteString("<hr>")
+		return
+	case NodeImage:
+		if !IsSafeImageURL(n.Attr("src")) {
+			return
+		}
+		b.WriteString("<img")
+		for _, name := range []string{"src", "alt", "title"} {
+			if value := n.Attr(name); value != "" {
+				fmt.Fprintf(b, ` %s="%s"`, name, html.EscapeString(value))
+			}
+		}
+		b.WriteString(">")
+		return
+	case NodeCodeBlock:
+		b.WriteString("<pre><code")
+		if language := n.Attr("language"); language != "" {
+			fmt.Fprintf(b, ` class="language-%s"`, html.EscapeString(language))
+		}
+		b.WriteString(">")
+		b.WriteString(html.EscapeString(n.TextContent()))
+		b.WriteString("</code></pre>")
+		return
+	}
+
+	tag, attrs := "", ""
+	switch n.Type {
+	case NodeParagraph:
+		tag = "p"
+	case NodeHeading:
+		tag = fmt.Sprintf("h%d", min(max(n.IntAttr("level", 1), 1), 6))
+	case NodeBlockquote:
+		tag = "blockquote"
+	case NodeBulletList:
+		tag = "ul"
+	case NodeOrderedList:
+		tag = "ol"
+		if start := n.IntAttr("start", 1); start != 1 {
+			attrs = fmt.Sprintf(` start="%d"`, start)
+		}
+	case NodeListItem:
+		tag = "li"
+	case NodeTable:
+		tag = "table"
+	case NodeTableRow:
+		tag = "tr"
+	case NodeTableHeader, NodeTableCell:
+		tag = "td"
+		if n.Type == NodeTableHeader {
+			tag = "th"
+		}
+		for _, span := range []string{"colspan", "rowspan"} {
+			if value := n.IntAttr(span, 1); value > 1 {
+				attrs += fmt.Sprintf(` %s="%d"`, span, value)
+			}
+		}
+	default:
+		// Unknown nodes are unwrapped
+		for _, child := range n.Content {
+			renderHTMLNode(b, child)
+		}
+		return
+	}
+
+	if align := n.Attr("textAlign"); align != "" && align != "left" {
+		attrs += fmt.Sprintf(` style="text-align: %s"`, html.EscapeString(align))
+	}
+
+	fmt.Fprintf(b, "<%s%s>", tag, attrs)
+	if n.Type == NodeTable {
+		b.WriteString("<tbody>")
+	}
+	for _, child := range n.Content {
+		renderHTMLNode(b, child)
+	}
+	if n.Type == NodeTable {
+		b.WriteString("</tbody>")
+	}
+	fmt.Fprintf(b, "</%s>", tag)
+}
+
+// htmlMarkTags maps marks to the HTML elements the editor uses for them
+var htmlMarkTags = map[string]string{
+	MarkBold:      "strong",
+	MarkItalic:    "em",
+	MarkStrike:    "s",
+	MarkCode:      "code",
+	MarkUnderline: "u",
+	MarkHighlight: "mark",
+}
+
+// renderHTMLText writes a text node wrapped in its marks
+func renderHTMLText(b *strings.Builder, n *Node) {
+	var closing []string
+	for _, m := range n.Marks {
+		if m.Type == MarkLink {
+			if !IsSafeURL(m.Attr("href")) {
+				continue
+			}
+			fmt.Fprintf(b, `<a href="%s"`, html.EscapeString(m.Attr("href")))
+			if title := m.Attr("title"); title != "" {
+				fmt.Fprintf(b, ` title="%s"`, html.EscapeString(title))
+			}
+			b.WriteString(">")
+			closing = append(closing, "</a>")
+			continue
+		}
+		if tag, ok := htmlMarkTags[m.Type]; ok {
+			fmt.Fprintf(b, "<%s>", tag)
+			closing = append(closing, fmt.Sprintf("</%s>", tag))
+		}
+	}
+	b.WriteString(html.EscapeString(n.Text))
+	for i := len(closing) - 1; i >= 0; i-- {
+		b.WriteString(closing[i])
+	}
+}
+
+// safeURLSchemes are the URL schemes allowed in links; anything else could run script when clicked
+var safeURLSchemes = []string{"http", "https", "mailto", "tel"}
+
+// safeImageDataRegex matches the inline images allowed as image sources
+var safeImageDataRegex = regexp.MustCompile(`^data:image/(png|gif|jpeg|webp);base64,`)
+
+// IsSafeURL reports whether a link target is relative or uses an allowed scheme
+func IsSafeURL(rawURL string) bool {
+	// Browsers ignore whitespace and control characters inside a scheme, as in "java\tscript:"
+	u := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, rawURL)
+
+	end := strings.IndexAny(u, ":/?#")
+	if end < 0 || u[end] != ':' {
+		return true
+	}
+	return slices.Contains(safeURLSchemes, strings.ToLower(u[:end]))
+}
+
+// IsSafeImageURL reports whether an image source is a safe URL or an inline raster image
+func IsSafeImageURL(rawURL string) bool {
+	return IsSafeURL(rawURL) || safeImageDataRegex.MatchString(strings.TrimSpace(rawURL))
+}
+
+// trimInline drops leading and trailing whitespace from a run of inline nodes
+func trimInline(nodes []*Node) []*Node {
+	for len(nodes) > 0 && nodes[0].Type == NodeText && !nodes[0].HasMark(MarkCode) {
+		nodes[0].Text = strings.TrimLeft(nodes[0].Text, " ")
+		if nodes[0].Text != "" {
+			break
+		}
+		nodes = nodes[1:]
+	}
+	for len(nodes) > 0 && nodes[len(nodes)-1].Type == NodeText && !nodes[len(nodes)-1].HasMark(MarkCode) {
+		last := nodes[len(nodes)-1]
+		last.Text = strings.TrimRight(last.Text, " ")
+		if last.Text != "" {
+			break
+		}
+		nodes = nodes[:len(nodes)-1]
+	}
+	return nodes
+}
+
+// setTextAlign copies the text alignment of an element's style to a node
+func setTextAlign(node *Node, n *nethtml.Node) {
+	if match := textAlignRegex.FindStringSubmatch(attr(n, "style")); match != nil && match[1] != "left" {
+		node.SetAttr("textAlign", match[1])
+	}
+}
+
+// children returns the child nodes of an HTML node
+func children(n *nethtml.Node) []*nethtml.Node {
+	var nodes []*nethtml.Node
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, c)
+	}
+	return nodes
+}
+
+// attr returns an attribute of an HTML element, or ""
+func attr(n *nethtml.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// htmlText returns the raw text inside an HTML node, keeping whitespace
+func htmlText(n *nethtml.Node) string {
+	if n.Type == nethtml.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Br {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(htmlText(c))
+	}
+	return b.String()
+}
+
+// cloneMarks copies a mark list so appending to it does not affect siblings
+func cloneMarks(marks []Mark) []Mark {
+	return append([]Mark(nil), marks...)
+}
@@ -0,0 +1,353 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// markdownEscapeRegex matches characters that would otherwise be read as Markdown syntax
+	markdownEscapeRegex = regexp.MustCompile("[\\\\`*_\\[\\]<>~|]")
+	// markdownLineStartRegex matches line starts that would otherwise begin a block
+	markdownLineStartRegex = regexp.MustCompile(`^(\s*)(#{1,6}(?:\s|$)|[-+](?:\s|$)|\d+[.)](?:\s|$)|=+\s*$)`)
+	// backtickRunRegex matches runs of backticks, to size code spans and fences
+	backtickRunRegex = regexp.MustCompile("`+")
+)
+
+// markdownMarkOrder is the nesting order of marks with Markdown syntax, outermost first
+var markdownMarkOrder = []string{MarkLink, MarkBold, MarkItalic, MarkStrike}
+
+// markdownMarkDelimiters are the delimiters of the emphasis marks
+var markdownMarkDelimiters = map[string]string{
+	MarkBold:   "**",
+	MarkItalic: "*",
+	MarkStrike: "~~",
+}
+
+// RenderMarkdown renders a tree as CommonMark, with GitHub-flavored tables and strikethrough
+// Underline and highlight have no Markdown syntax and are dropped
+func RenderMarkdown(doc *Node) string {
+	out := renderMarkdownBlocks(doc.Content, false)
+	if out == "" {
+		return ""
+	}
+	return out + "\n"
+}
+
+// renderMarkdownBlocks renders block nodes separated by blank lines, or by single newlines when tight
+func renderMarkdownBlocks(blocks []*Node, tight bool) string {
+	separator := "\n\n"
+	if tight {
+		separator = "\n"
+	}
+
+	var parts []string
+	for i, block := range blocks {
+		part := renderMarkdownBlock(block)
+		if part == "" {
+			continue
+		}
+		// Consecutive lists of the same kind would merge, so separate them with a comment
+		if i > 0 && block.Type == blocks[i-1].Type && (block.Type == NodeBulletList || block.Type == NodeOrderedList) {
+			parts = append(parts, "<!-- -->")
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, separator)
+}
+
+// renderMarkdownBlock renders one block node
+func renderMarkdownBlock(n *Node) string {
+	switch n.Type {
+	case NodeParagraph:
+		return escapeLineStarts(renderMarkdownInline(n.Content, false))
+	case NodeHeading:
+		level := min(max(n.IntAttr("level", 1), 1), 6)
+		text := strings.ReplaceAll(renderMarkdownInline(n.Content, false), "\\\n", " ")
+		return strings.Repeat("#", level) + " " + text
+	case NodeBlockquote:
+		return prefixLines(renderMarkdownBlocks(n.Content, false), "> ", ">")
+	case NodeBulletList, NodeOrderedList:
+		return renderMarkdownList(n)
+	case NodeCodeBlock:
+		code := n.TextContent()
+		fence := "```"
+		if longest := longestRun(backtickRunRegex, code); longest >= 3 {
+			fence = strings.Repeat("`", longest+1)
+		}
+		return fmt.Sprintf("%s%s\n%s\n%s", fence, n.Attr("language"), code, fence)
+	case NodeHorizontalRule:
+		return "---"
+	case NodeTable:
+		return renderMarkdownTable(n)
+	case NodeImage, NodeText, NodeHardBreak:
+		return renderMarkdownInline([]*Node{n}, false)
+	}
+	// Unknown containers are unwrapped
+	return renderMarkdownBlocks(n.Content, false)
+}
+
+// renderMarkdownList renders a bullet or ordered list, tight when every item is a single paragraph
+// optionally followed by a nested list
+func renderMarkdownList(n *Node) string {
+	tight := true
+	for _, item := range n.Content {
+		for i, child := range item.Content {
+			if (i == 0 && child.Type != NodeParagraph) || (i > 0 && child.Type != NodeBulletList && child.Type != NodeOrderedList) {
+				tight = false
+			}
+		}
+	}
+
+	start := n.IntAttr("start", 1)
+	var items []string
+	for i, item := range n.Content {
+		marker := "- "
+		if n.Type == NodeOrderedList {
+			marker = fmt.Sprintf("%d. ", start+i)
+		}
+		content := renderMarkdownBlocks(item.Content, tight)
+		items = append(items, marker+indentLines(content, strings.Repeat(" ", len(marker))))
+	}
+
+	if tight {
+		return strings.Join(items, "\n")
+	}
+	return strings.Join(items, "\n\n")
+}
+
+// renderMarkdownTable renders a table as a GitHub-flavored table with the first row as header
+func renderMarkdownTable(n *Node) string {
+	columns := 0
+	for _, row := range n.Content {
+		columns = max(columns, len(row.Content))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var lines []string
+	for i, row := range n.Content {
+		cells := make([]string, columns)
+		for j, cell := range row.Content {
+			var paragraphs []string
+			for _, block := range cell.Content {
+				paragraphs = append(paragraphs, renderMarkdownInline(block.Content, true))
+			}
+			cells[j] = strings.Join(paragraphs, "<br>")
+		}
+		lines = append(lines, "| "+strings.Join(cells, " | ")+" |")
+		if i == 0 {
+			lines = append(lines, markdownDelimiterRow(row, columns))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// markdownDelimiterRow renders the row under a table header, taking column alignment from the header cells
+func markdownDelimiterRow(header *Node, columns int) string {
+	var b strings.Builder
+	b.WriteString("|")
+	for j := 0; j < columns; j++ {
+		align := ""
+		if j < len(header.Content) && len(header.Content[j].Content) > 0 {
+			align = header.Content[j].Content[0].Attr("textAlign")
+		}
+		switch align {
+		case "center":
+			b.WriteString(" :---: |")
+		case "right":
+			b.WriteString(" ---: |")
+		default:
+			b.WriteString(" --- |")
+		}
+	}
+	return b.String()
+}
+
+// renderMarkdownInline renders inline nodes, opening and closing marks only where they change
+// so that formatting spanning several text nodes is written once
+func renderMarkdownInline(nodes []*Node, inTable bool) string {
+	var b strings.Builder
+	var active []Mark
+	pending := ""
+
+	closeTo := func(keep int) {
+		for i := len(active) - 1; i >= keep; i-- {
+			b.WriteString(markdownCloser(active[i]))
+		}
+		active = active[:keep]
+	}
+
+	for _, n := range mergeTextNodes(nodes) {
+		switch n.Type {
+		case NodeText:
+			// Delimiters cannot be next to whitespace, so whitespace goes outside the marks
+			core := strings.TrimSpace(n.Text)
+			if core == "" {
+				pending += n.Text
+				continue
+			}
+			lead := n.Text[:strings.Index(n.Text, core)]
+			trail := n.Text[len(lead)+len(core):]
+
+			marks := markdownMarks(n)
+			keep := 0
+			for keep < len(active) && keep < len(marks) && sameMark(active[keep], marks[keep]) {
+				keep++
+			}
+			closeTo(keep)
+			b.WriteString(pending + lead)
+			for _, m := range marks[keep:] {
+				b.WriteString(markdownOpener(m))
+			}
+			active = marks
+
+			if n.HasMark(MarkCode) {
+				b.WriteString(codeSpan(core))
+			} else {
+				b.WriteString(escapeMarkdown(core))
+			}
+			pending = trail
+		case NodeHardBreak:
+			b.WriteString(pending)
+			pending = ""
+			if inTable {
+				b.WriteString("<br>")
+			} else {
+				b.WriteString("\\\n")
+			}
+		case NodeImage:
+			b.WriteString(pending)
+			pending = ""
+			alt := escapeMarkdown(n.Attr("alt"))
+			fmt.Fprintf(&b, "![%s](%s)", alt, markdownDestination(n.Attr("src"), n.Attr("title")))
+		}
+	}
+	closeTo(0)
+	b.WriteString(pending)
+
+	return b.String()
+}
+
+// markdownMarks returns the marks of a text node that have Markdown delimiters, outermost first
+func markdownMarks(n *Node) []Mark {
+	var marks []Mark
+	for _, markType := range markdownMarkOrder {
+		if m := n.Mark(markType); m != nil {
+			marks = append(marks, *m)
+		}
+	}
+	return marks
+}
+
+// markdownOpener returns the syntax that starts a mark
+func markdownOpener(m Mark) string {
+	if m.Type == MarkLink {
+		return "["
+	}
+	return markdownMarkDelimiters[m.Type]
+}
+
+// markdownCloser returns the syntax that ends a mark
+func markdownCloser(m Mark) string {
+	if m.Type == MarkLink {
+		return "](" + markdownDestination(m.Attr("href"), m.Attr("title")) + ")"
+	}
+	return markdownMarkDelimiters[m.Type]
+}
+
+// markdownDestination formats a link destination with an optional title
+func markdownDestination(href, title string) string {
+	if href == "" || strings.ContainsAny(href, " ()<>") {
+		href = "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(href) + ">"
+	}
+	if title != "" {
+		href += ` "` + strings.ReplaceAll(title, `"`, `\"`) + `"`
+	}
+	return href
+}
+
+// codeSpan wraps text in enough backticks that it cannot end early
+func codeSpan(text string) string {
+	fence := strings.Repeat("`", longestRun(backtickRunRegex, text)+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return fence + " " + text + " " + fence
+	}
+	return fence + text + fence
+}
+
+// sameMark reports whether two marks are equal, comparing link targets
+func sameMark(a, b Mark) bool {
+	return a.Type == b.Type && a.Attr("href") == b.Attr("href") && a.Attr("title") == b.Attr("title")
+}
+
+// mergeTextNodes joins adjacent text nodes with the same marks
+func mergeTextNodes(nodes []*Node) []*Node {
+	var merged []*Node
+	for _, n := range nodes {
+		if last := len(merged) - 1; last >= 0 && n.Type == NodeText && merged[last].Type == NodeText &&
+			slices.EqualFunc(n.Marks, merged[last].Marks, sameMark) {
+			merged[last] = NewText(merged[last].Text+n.Text, n.Marks...)
+			continue
+		}
+		merged = append(merged, n)
+	}
+	return merged
+}
+
+// escapeMarkdown escapes the characters of plain text that would be read as Markdown syntax or entities
+func escapeMarkdown(text string) string {
+	return entityRegex.ReplaceAllString(markdownEscapeRegex.ReplaceAllString(text, `\$0`), `\$0`)
+}
+
+// escapeLineStarts escapes characters at the start of lines that would begin a heading or list
+func escapeLineStarts(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if match := markdownLineStartRegex.FindStringSubmatchIndex(line); match != nil {
+			at := match[3]
+			if line[at] >= '0' && line[at] <= '9' {
+				// Escape the delimiter of "1." rather than the number
+				at = strings.IndexAny(line, ".)")
+			}
+			lines[i] = line[:at] + `\` + line[at:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines prefixes every line, using emptyPrefix for empty lines
+func prefixLines(text, prefix, emptyPrefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = emptyPrefix
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentLines indents every line but the first, leaving empty lines empty
+func indentLines(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// longestRun returns the length of the longest match of re in text
+func longestRun(re *regexp.Regexp, text string) int {
+	longest := 0
+	for _, run := range re.FindAllString(text, -1) {
+		longest = max(longest, len(run))
+	}
+	return longest
+}
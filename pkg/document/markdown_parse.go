@@ -0,0 +1,912 @@
+package document
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	fenceRegex         = regexp.MustCompile("^(`{3,}|~{3,})[ \t]*(.*)$")
+	atxHeadingRegex    = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))??(?:[ \t]+#+)?[ \t]*$`)
+	thematicBreakRegex = regexp.MustCompile(`^(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	setextRegex        = regexp.MustCompile(`^(=+|-+)[ \t]*$`)
+	listItemRegex      = regexp.MustCompile(`^([-+*]|(\d{1,9})[.)])([ \t]+|$)`)
+	tableDelimiterRow  = regexp.MustCompile(`^\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*$`)
+	linkReferenceRegex = regexp.MustCompile(`^\[((?:[^\]\\]|\\.)+)\]:[ \t]*(<[^>\n]*>|\S+)(?:[ \t]+("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*'|\((?:[^)\\]|\\.)*\)))?[ \t]*$`)
+	autolinkRegex      = regexp.MustCompile(`^<([A-Za-z][A-Za-z0-9+.-]{1,31}:[^\s<>]*)>`)
+	emailAutolinkRegex = regexp.MustCompile(`^<([a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*)>`)
+	entityRegex        = regexp.MustCompile(`&(?:#[0-9]{1,7}|#[xX][0-9a-fA-F]{1,6}|[A-Za-z][A-Za-z0-9]{1,31});`)
+)
+
+// nodeRawInline holds the unparsed inline text of a block until link references are known
+const nodeRawInline = "rawInline"
+
+// linkReference is a link reference definition such as [label]: /url "title"
+type linkReference struct {
+	href, title string
+}
+
+// markdownParser parses CommonMark into a tree
+type markdownParser struct {
+	refs map[string]linkReference
+}
+
+// ParseMarkdown parses CommonMark, with GitHub-flavored tables and strikethrough, into a tree
+// Raw HTML is kept as text
+func ParseMarkdown(src string) *Node {
+	src = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\x00", "�").Replace(src)
+	lines := strings.Split(strings.TrimSuffix(src, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = expandLeadingTabs(line)
+	}
+
+	p := &markdownParser{refs: map[string]linkReference{}}
+	doc := NewNode(NodeDoc, p.blocks(lines)...)
+
+	// Inlines are parsed once all link reference definitions have been collected
+	doc.Walk(func(n *Node) bool {
+		if len(n.Content) == 1 && n.Content[0].Type == nodeRawInline {
+			n.Content = p.inlines(n.Content[0].Text)
+		}
+		return true
+	})
+	return doc
+}
+
+// blocks parses lines into block nodes
+func (p *markdownParser) blocks(lines []string) []*Node {
+	var blocks []*Node
+
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		if isBlankLine(line) {
+			i++
+			continue
+		}
+
+		indent := leadingSpaces(line)
+		if indent >= 4 {
+			var code []string
+			for ; i < len(lines) && (isBlankLine(lines[i]) || leadingSpaces(lines[i]) >= 4); i++ {
+				code = append(code, removeIndent(lines[i], 4))
+			}
+			for len(code) > 0 && isBlankLine(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			blocks = append(blocks, newCodeBlock("", strings.Join(code, "\n")))
+			continue
+		}
+
+		rest := line[indent:]
+		switch {
+		case fenceRegex.MatchString(rest) && !(rest[0] == '`' && strings.Contains(fenceRegex.FindStringSubmatch(rest)[2], "`")):
+			var block *Node
+			block, i = p.fencedCode(lines, i)
+			blocks = append(blocks, block)
+		case atxHeadingRegex.MatchString(rest):
+			m := atxHeadingRegex.FindStringSubmatch(rest)
+			heading := newRawBlock(NodeHeading, m[2])
+			heading.SetAttr("level", len(m[1]))
+			blocks = append(blocks, heading)
+			i++
+		case thematicBreakRegex.MatchString(rest):
+			blocks = append(blocks, NewNode(NodeHorizontalRule))
+			i++
+		case strings.HasPrefix(rest, ">"):
+			var quote []string
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if leadingSpaces(l) < 4 && strings.HasPrefix(strings.TrimLeft(l, " "), ">") {
+					l = strings.TrimPrefix(strings.TrimLeft(l, " ")[1:], " ")
+				} else if isBlankLine(l) || len(quote) == 0 || isBlankLine(quote[len(quote)-1]) || startsBlock(l) {
+					break
+				}
+				quote = append(quote, l)
+			}
+			blocks = append(blocks, NewNode(NodeBlockquote, p.blocks(quote)...))
+		case listItemRegex.MatchString(rest):
+			var list *Node
+			list, i = p.list(lines, i)
+			blocks = append(blocks, list)
+		case i+1 < len(lines) && strings.Contains(rest, "|") && tableDelimiterRow.MatchString(strings.TrimSpace(lines[i+1])) &&
+			len(splitTableRow(rest)) == len(splitTableRow(lines[i+1])):
+			var table *Node
+			table, i = p.table(lines, i)
+			blocks = append(blocks, table)
+		default:
+			var block *Node
+			block, i = p.paragraph(lines, i)
+			if block != nil {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+
+	return blocks
+}
+
+// fencedCode parses a fenced code block starting at lines[i] and returns the index after it
+func (p *markdownParser) fencedCode(lines []string, i int) (*Node, int) {
+	indent := leadingSpaces(lines[i])
+	m := fenceRegex.FindStringSubmatch(lines[i][indent:])
+	fence := m[1]
+	language := ""
+	if fields := strings.Fields(m[2]); len(fields) > 0 {
+		language = unescapeMarkdown(fields[0])
+	}
+
+	var code []string
+	for i++; i < len(lines); i++ {
+		l := lines[i]
+		if leadingSpaces(l) < 4 {
+			trimmed := strings.TrimSpace(l)
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				i++
+				break
+			}
+		}
+		code = append(code, removeIndent(l, indent))
+	}
+
+	return newCodeBlock(language, strings.Join(code, "\n")), i
+}
+
+// list parses a list starting at lines[i] and returns the index after it
+// Items continue on lines indented to their content; a new marker of the same kind starts the next item
+func (p *markdownParser) list(lines []string, i int) (*Node, int) {
+	first := listItemRegex.FindStringSubmatch(strings.TrimLeft(lines[i], " "))
+	ordered := first[2] != ""
+	list := NewNode(NodeBulletList)
+	if ordered {
+		list.Type = NodeOrderedList
+		if start, err := strconv.Atoi(first[2]); err == nil && start != 1 {
+			list.SetAttr("start", start)
+		}
+	}
+	delimiter := first[1][len(first[1])-1:]
+
+	for i < len(lines) {
+		line := lines[i]
+		indent := leadingSpaces(line)
+		m := listItemRegex.FindStringSubmatch(line[indent:])
+		if indent >= 4 || m == nil || (m[2] != "") != ordered || m[1][len(m[1])-1:] != delimiter {
+			break
+		}
+
+		// Content starts after the marker and its spaces, unless it is indented code
+		width := indent + len(m[1]) + len(m[3])
+		if len(m[3]) > 4 || m[3] == "" {
+			width = indent + len(m[1]) + 1
+		}
+		var content []string
+		if width <= len(line) {
+			content = append(content, line[width:])
+		} else {
+			content = append(content, "")
+		}
+
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			switch {
+			case isBlankLine(l):
+				content = append(content, "")
+				continue
+			case leadingSpaces(l) >= width:
+				content = append(content, l[width:])
+				continue
+			case !isBlankLine(content[len(content)-1]) && !startsBlock(l) && !listItemRegex.MatchString(strings.TrimLeft(l, " ")):
+				// Lazy continuation of a paragraph
+				content = append(content, strings.TrimLeft(l, " "))
+				continue
+			}
+			break
+		}
+
+		item := NewNode(NodeListItem, p.blocks(content)...)
+		if len(item.Content) == 0 {
+			item.Content = []*Node{NewNode(NodeParagraph)}
+		}
+		list.Content = append(list.Content, item)
+
+		// Blank lines at the end of an item end the list unless another item follows
+		for i < len(lines) && isBlankLine(lines[i]) {
+			i++
+		}
+	}
+
+	return list, i
+}
+
+// table parses a GitHub-flavored table starting at lines[i] and returns the index after it
+func (p *markdownParser) table(lines []string, i int) (*Node, int) {
+	header := splitTableRow(lines[i])
+	var aligns []string
+	for _, cell := range splitTableRow(lines[i+1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "center")
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, "right")
+		default:
+			aligns = append(aligns, "")
+		}
+	}
+
+	row := func(cells []string, cellType string) *Node {
+		r := NewNode(NodeTableRow)
+		for j := range header {
+			text := ""
+			if j < len(cells) {
+				text = cells[j]
+			}
+			para := newRawBlock(NodeParagraph, text)
+			if aligns[j] != "" {
+				para.SetAttr("textAlign", aligns[j])
+			}
+			r.Content = append(r.Content, NewNode(cellType, para))
+		}
+		return r
+	}
+
+	table := NewNode(NodeTable, row(header, NodeTableHeader))
+	for i += 2; i < len(lines) && !isBlankLine(lines[i]) && !startsBlock(lines[i]); i++ {
+		table.Content = append(table.Content, row(splitTableRow(lines[i]), NodeTableCell))
+	}
+	return table, i
+}
+
+// paragraph parses a paragraph or setext heading starting at lines[i] and returns the index after it
+// Link reference definitions at its start are collected; the block is nil if nothing else is left
+func (p *markdownParser) paragraph(lines []string, i int) (*Node, int) {
+	var text []string
+	for ; i < len(lines); i++ {
+		l := lines[i]
+		if isBlankLine(l) {
+			break
+		}
+		if len(text) > 0 && leadingSpaces(l) < 4 {
+			if m := setextRegex.FindStringSubmatch(strings.TrimSpace(l)); m != nil {
+				heading := newRawBlock(NodeHeading, strings.TrimSpace(strings.Join(text, "\n")))
+				heading.SetAttr("level", map[byte]int{'=': 1, '-': 2}[m[1][0]])
+				return heading, i + 1
+			}
+			if startsBlock(l) {
+				break
+			}
+		}
+		text = append(text, strings.TrimLeft(l, " "))
+	}
+
+	for len(text) > 0 {
+		m := linkReferenceRegex.FindStringSubmatch(text[0])
+		if m == nil {
+			break
+		}
+		label := normalizeLabel(m[1])
+		if _, exists := p.refs[label]; !exists {
+			ref := linkReference{href: unescapeMarkdown(strings.Trim(m[2], "<>"))}
+			if m[3] != "" {
+				ref.title = unescapeMarkdown(m[3][1 : len(m[3])-1])
+			}
+			p.refs[label] = ref
+		}
+		text = text[1:]
+	}
+	if len(text) == 0 {
+		return nil, i
+	}
+
+	return newRawBlock(NodeParagraph, strings.TrimRight(strings.Join(text, "\n"), " \t")), i
+}
+
+// startsBlock reports whether a line starts a block that interrupts a paragraph
+func startsBlock(line string) bool {
+	indent := leadingSpaces(line)
+	if indent >= 4 {
+		return false
+	}
+	rest := line[indent:]
+	if fenceRegex.MatchString(rest) || atxHeadingRegex.MatchString(rest) || thematicBreakRegex.MatchString(rest) ||
+		strings.HasPrefix(rest, ">") {
+		return true
+	}
+	// Only non-empty bullet items and ordered items starting at 1 interrupt a paragraph
+	if m := listItemRegex.FindStringSubmatch(rest); m != nil && strings.TrimSpace(rest[len(m[0]):]) != "" {
+		return m[2] == "" || m[2] == "1"
+	}
+	return false
+}
+
+// inlineItem is an element of the inline parser's list: text, a delimiter run, a bracket,
+// or a finished span (code, break, mark or image)
+type inlineItem struct {
+	kind inlineKind
+	text string
+
+	// Delimiter runs of *, _ or ~
+	char              byte
+	count, origCount  int
+	canOpen, canClose bool
+
+	// Brackets: [ or ![
+	image  bool
+	active bool
+	pos    int // Offset just after the bracket in the source
+
+	// Spans
+	mark     *Mark
+	attrs    map[string]interface{}
+	children *inlineList
+
+	prev, next *inlineItem
+}
+
+// inlineKind identifies the kind of an inlineItem
+type inlineKind int
+
+const (
+	inlineText inlineKind = iota
+	inlineDelimiter
+	inlineBracket
+	inlineCode
+	inlineBreak
+	inlineSpan
+	inlineImage
+)
+
+// inlineList is a doubly linked list of inline items, so spans can be cut out cheaply
+type inlineList struct {
+	head, tail *inlineItem
+}
+
+// push appends an item
+func (l *inlineList) push(it *inlineItem) {
+	it.prev, it.next = l.tail, nil
+	if l.tail != nil {
+		l.tail.next = it
+	} else {
+		l.head = it
+	}
+	l.tail = it
+}
+
+// remove unlinks an item
+func (l *inlineList) remove(it *inlineItem) {
+	if it.prev != nil {
+		it.prev.next = it.next
+	} else {
+		l.head = it.next
+	}
+	if it.next != nil {
+		it.next.prev = it.prev
+	} else {
+		l.tail = it.prev
+	}
+	it.prev, it.next = nil, nil
+}
+
+// wrap moves the items strictly between from and to (or the end, if to is nil) into span,
+// which takes their place
+func (l *inlineList) wrap(from, to *inlineItem, span *inlineItem) {
+	first, last := from.next, l.tail
+	if to != nil {
+		last = to.prev
+	}
+
+	span.children = &inlineList{}
+	if first != to {
+		first.prev, last.next = nil, nil
+		span.children.head, span.children.tail = first, last
+	}
+
+	span.prev, span.next = from, to
+	from.next = span
+	if to != nil {
+		to.prev = span
+	} else {
+		l.tail = span
+	}
+}
+
+// inlines parses inline Markdown into text and inline nodes
+func (p *markdownParser) inlines(src string) []*Node {
+	list := &inlineList{}
+	var brackets []*inlineItem
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			list.push(&inlineItem{kind: inlineText, text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			flush()
+			list.push(&inlineItem{kind: inlineBreak})
+			i += 2
+			i += leadingSpaces(src[i:])
+		case c == '\\' && i+1 < len(src) && isASCIIPunct(src[i+1]):
+			text.WriteByte(src[i+1])
+			i += 2
+		case c == '`':
+			run := countRun(src[i:], '`')
+			if end := findBacktickRun(src[i+run:], run); end >= 0 {
+				flush()
+				list.push(&inlineItem{kind: inlineCode, text: normalizeCodeSpan(src[i+run : i+run+end])})
+				i += run + end + run
+			} else {
+				text.WriteString(src[i : i+run])
+				i += run
+			}
+		case c == '*' || c == '_' || c == '~':
+			flush()
+			run := countRun(src[i:], c)
+			before, _ := utf8.DecodeLastRuneInString(src[:i])
+			after, _ := utf8.DecodeRuneInString(src[i+run:])
+			if i == 0 {
+				before = '\n'
+			}
+			if i+run == len(src) {
+				after = '\n'
+			}
+			left := !unicode.IsSpace(after) && (!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+			right := !unicode.IsSpace(before) && (!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+			item := &inlineItem{kind: inlineDelimiter, char: c, count: run, origCount: run, canOpen: left, canClose: right}
+			if c == '_' {
+				item.canOpen = left && (!right || isPunct(before))
+				item.canClose = right && (!left || isPunct(after))
+			}
+			if c == '~' && run > 2 {
+				item.canOpen, item.canClose = false, false
+			}
+			list.push(item)
+			i += run
+		case c == '!' && i+1 < len(src) && src[i+1] == '[':
+			flush()
+			item := &inlineItem{kind: inlineBracket, text: "![", image: true, active: true, pos: i + 2}
+			list.push(item)
+			brackets = append(brackets, item)
+			i += 2
+		case c == '[':
+			flush()
+			item := &inlineItem{kind: inlineBracket, text: "[", active: true, pos: i + 1}
+			list.push(item)
+			brackets = append(brackets, item)
+			i++
+		case c == ']':
+			flush()
+			i++
+			if len(brackets) == 0 {
+				text.WriteByte(']')
+				continue
+			}
+			opener := brackets[len(brackets)-1]
+			brackets = brackets[:len(brackets)-1]
+			if !opener.active {
+				text.WriteByte(']')
+				continue
+			}
+
+			href, title, next, ok := p.linkTarget(src, i, src[opener.pos:i-1])
+			if !ok {
+				text.WriteByte(']')
+				continue
+			}
+			i = next
+
+			p.processEmphasis(list, opener)
+			span := &inlineItem{kind: inlineSpan, mark: &Mark{Type: MarkLink, Attrs: map[string]interface{}{"href": href}}}
+			if title != "" {
+				span.mark.Attrs["title"] = title
+			}
+			if opener.image {
+				span = &inlineItem{kind: inlineImage, attrs: map[string]interface{}{"src": href}}
+				if title != "" {
+					span.attrs["title"] = title
+				}
+			}
+			list.wrap(opener, nil, span)
+			list.remove(opener)
+			if !opener.image {
+				// Links cannot contain other links
+				for _, b := range brackets {
+					if !b.image {
+						b.active = false
+					}
+				}
+			}
+		case c == '<':
+			if m := autolinkRegex.FindStringSubmatch(src[i:]); m != nil {
+				flush()
+				p.pushAutolink(list, m[1], m[1])
+				i += len(m[0])
+			} else if m := emailAutolinkRegex.FindStringSubmatch(src[i:]); m != nil {
+				flush()
+				p.pushAutolink(list, m[1], "mailto:"+m[1])
+				i += len(m[0])
+			} else {
+				text.WriteByte('<')
+				i++
+			}
+		case c == '&':
+			if m := entityRegex.FindString(src[i:]); m != "" {
+				text.WriteString(html.UnescapeString(m))
+				i += len(m)
+			} else {
+				text.WriteByte('&')
+				i++
+			}
+		case c == '\n':
+			// Two trailing spaces make a hard break; otherwise the line break is a space
+			current := text.String()
+			trimmed := strings.TrimRight(current, " ")
+			text.Reset()
+			text.WriteString(trimmed)
+			if len(current)-len(trimmed) >= 2 {
+				flush()
+				list.push(&inlineItem{kind: inlineBreak})
+			} else {
+				text.WriteByte(' ')
+			}
+			i++
+			i += leadingSpaces(src[i:])
+		default:
+			text.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+
+	p.processEmphasis(list, nil)
+	return mergeTextNodes(inlineNodes(list, nil))
+}
+
+// linkTarget parses what follows the ] of a link: an inline destination and title, or a reference
+// Returns the destination, the title and the offset after the link
+func (p *markdownParser) linkTarget(src string, i int, label string) (string, string, int, bool) {
+	if i < len(src) && src[i] == '(' {
+		if href, title, next, ok := parseInlineDestination(src, i+1); ok {
+			return href, title, next, true
+		}
+	}
+
+	// Full [text][label], collapsed [text][] and shortcut [text] references
+	next := i
+	if i < len(src) && src[i] == '[' {
+		if end := strings.IndexByte(src[i+1:], ']'); end >= 0 {
+			if end > 0 {
+				label = src[i+1 : i+1+end]
+			}
+			next = i + end + 2
+		}
+	}
+	if ref, ok := p.refs[normalizeLabel(label)]; ok {
+		return ref.href, ref.title, next, true
+	}
+	return "", "", i, false
+}
+
+// parseInlineDestination parses `dest "title")` starting just after the opening parenthesis
+func parseInlineDestination(src string, i int) (string, string, int, bool) {
+	skipSpace := func() {
+		for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '\n') {
+			i++
+		}
+	}
+	skipSpace()
+
+	var href string
+	if i < len(src) && src[i] == '<' {
+		end := strings.IndexAny(src[i+1:], ">\n")
+		if end < 0 || src[i+1+end] != '>' {
+			return "", "", 0, false
+		}
+		href = src[i+1 : i+1+end]
+		i += end + 2
+	} else {
+		start, depth := i, 0
+		for ; i < len(src); i++ {
+			c := src[i]
+			if c == '\\' && i+1 < len(src) && isASCIIPunct(src[i+1]) {
+				i++
+				continue
+			}
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				if depth == 0 {
+					break
+				}
+				depth--
+			} else if c <= ' ' {
+				break
+			}
+		}
+		href = src[start:i]
+	}
+
+	beforeTitle := i
+	skipSpace()
+	title := ""
+	if i < len(src) && i > beforeTitle && (src[i] == '"' || src[i] == '\'' || src[i] == '(') {
+		closing := map[byte]byte{'"': '"', '\'': '\'', '(': ')'}[src[i]]
+		end := i + 1
+		for ; end < len(src) && src[end] != closing; end++ {
+			if src[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(src) {
+			return "", "", 0, false
+		}
+		title = src[i+1 : end]
+		i = end + 1
+		skipSpace()
+	}
+
+	if i >= len(src) || src[i] != ')' {
+		return "", "", 0, false
+	}
+	return unescapeMarkdown(href), unescapeMarkdown(title), i + 1, true
+}
+
+// pushAutolink adds a link whose text is its address
+func (p *markdownParser) pushAutolink(list *inlineList, text, href string) {
+	span := &inlineItem{kind: inlineSpan, mark: &Mark{Type: MarkLink, Attrs: map[string]interface{}{"href": href}}}
+	span.children = &inlineList{}
+	span.children.push(&inlineItem{kind: inlineText, text: text})
+	list.push(span)
+}
+
+// processEmphasis matches the delimiter runs after bottom into bold, italic and strikethrough spans,
+// following the CommonMark delimiter algorithm
+func (p *markdownParser) processEmphasis(list *inlineList, bottom *inlineItem) {
+	first := list.head
+	if bottom != nil {
+		first = bottom.next
+	}
+
+	// openersBottom remembers where the search for an opener can stop, per kind of closer
+	openersBottom := map[[3]int]*inlineItem{}
+
+	for closer := first; closer != nil; {
+		if closer.kind != inlineDelimiter || !closer.canClose {
+			closer = closer.next
+			continue
+		}
+
+		key := [3]int{int(closer.char), boolInt(closer.canOpen), closer.origCount % 3}
+		var opener *inlineItem
+		for o := closer.prev; o != nil && o != bottom && o != openersBottom[key]; o = o.prev {
+			if o.kind != inlineDelimiter || o.char != closer.char || !o.canOpen {
+				continue
+			}
+			if closer.char == '~' {
+				if o.count == closer.count {
+					opener = o
+					break
+				}
+				continue
+			}
+			oddMatch := (closer.canOpen || o.canClose) && closer.origCount%3 != 0 && (o.origCount+closer.origCount)%3 == 0
+			if !oddMatch {
+				opener = o
+				break
+			}
+		}
+
+		if opener == nil {
+			openersBottom[key] = closer.prev
+			next := closer.next
+			if !closer.canOpen {
+				closer.kind = inlineText
+				closer.text = strings.Repeat(string(closer.char), closer.count)
+			}
+			closer = next
+			continue
+		}
+
+		n, markType := 1, MarkItalic
+		switch {
+		case closer.char == '~':
+			n, markType = closer.count, MarkStrike
+		case opener.count >= 2 && closer.count >= 2:
+			n, markType = 2, MarkBold
+		}
+		opener.count -= n
+		closer.count -= n
+
+		list.wrap(opener, closer, &inlineItem{kind: inlineSpan, mark: &Mark{Type: markType}})
+		if opener.count == 0 {
+			list.remove(opener)
+		}
+		if closer.count == 0 {
+			next := closer.next
+			list.remove(closer)
+			closer = next
+		}
+	}
+}
+
+// inlineNodes converts a processed inline list to nodes, applying the marks of enclosing spans
+func inlineNodes(list *inlineList, marks []Mark) []*Node {
+	var nodes []*Node
+	for it := list.head; it != nil; it = it.next {
+		switch it.kind {
+		case inlineText:
+			nodes = append(nodes, NewText(it.text, marks...))
+		case inlineDelimiter:
+			nodes = append(nodes, NewText(strings.Repeat(string(it.char), it.count), marks...))
+		case inlineBracket:
+			nodes = append(nodes, NewText(it.text, marks...))
+		case inlineCode:
+			nodes = append(nodes, NewText(it.text, append(cloneMarks(marks), Mark{Type: MarkCode})...))
+		case inlineBreak:
+			nodes = append(nodes, NewNode(NodeHardBreak))
+		case inlineSpan:
+			nodes = append(nodes, inlineNodes(it.children, append(cloneMarks(marks), *it.mark))...)
+		case inlineImage:
+			image := &Node{Type: NodeImage, Attrs: it.attrs}
+			if alt := NewNode(NodeParagraph, inlineNodes(it.children, nil)...).TextContent(); alt != "" {
+				image.SetAttr("alt", alt)
+			}
+			nodes = append(nodes, image)
+		}
+	}
+	return nodes
+}
+
+// newRawBlock creates a block whose inline content is parsed later
+func newRawBlock(nodeType, text string) *Node {
+	return NewNode(nodeType, &Node{Type: nodeRawInline, Text: text})
+}
+
+// newCodeBlock creates a code block
+func newCodeBlock(language, code string) *Node {
+	block := NewNode(NodeCodeBlock)
+	if language != "" {
+		block.SetAttr("language", language)
+	}
+	if code != "" {
+		block.Content = []*Node{NewText(code)}
+	}
+	return block
+}
+
+// splitTableRow splits a table row into trimmed cells, honoring escaped pipes
+func splitTableRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) && line[i+1] == '|' {
+			cell.WriteByte('|')
+			i++
+			continue
+		}
+		if line[i] == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(line[i])
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// normalizeCodeSpan turns line breaks into spaces and strips one surrounding space
+func normalizeCodeSpan(code string) string {
+	code = strings.ReplaceAll(code, "\n", " ")
+	if len(code) >= 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+		code = code[1 : len(code)-1]
+	}
+	return code
+}
+
+// findBacktickRun returns the offset of the next run of exactly n backticks, or -1
+func findBacktickRun(s string, n int) int {
+	for i := 0; i < len(s); {
+		if s[i] != '`' {
+			i++
+			continue
+		}
+		run := countRun(s[i:], '`')
+		if run == n {
+			return i
+		}
+		i += run
+	}
+	return -1
+}
+
+// unescapeMarkdown resolves backslash escapes and entities
+func unescapeMarkdown(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return entityRegex.ReplaceAllStringFunc(b.String(), html.UnescapeString)
+}
+
+// normalizeLabel case-folds a link label and collapses its whitespace
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// expandLeadingTabs replaces tabs in a line's indentation with spaces to the next multiple of four
+func expandLeadingTabs(line string) string {
+	var b strings.Builder
+	column := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			b.WriteByte(' ')
+			column++
+		case '\t':
+			spaces := 4 - column%4
+			b.WriteString(strings.Repeat(" ", spaces))
+			column += spaces
+		default:
+			return b.String() + line[i:]
+		}
+	}
+	return b.String()
+}
+
+// removeIndent removes up to n leading spaces
+func removeIndent(line string, n int) string {
+	return line[min(leadingSpaces(line), n):]
+}
+
+// leadingSpaces counts the spaces at the start of a line
+func leadingSpaces(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// countRun counts the repetitions of c at the start of s
+func countRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// isBlankLine reports whether a line contains only whitespace
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// isASCIIPunct reports whether c can be backslash-escaped
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// isPunct reports whether r counts as punctuation for emphasis
+func isPunct(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSymbol(r)
+}
+
+// boolInt converts a bool to 0 or 1
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -8,13 +8,19 @@ import (
 
 // Document represents a collaborative document
 type Document struct {
-	ID              string    `json:"id"`
-	Title           string    `json:"title"`
-	Content         string    `json:"content"`
-	OwnerID         string    `json:"owner_id"`
-	CollaboratorIDs []string  `json:"collaborator_ids"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Content         string     `json:"content"`
+	OwnerID         string     `json:"owner_id"`
+	WorkspaceID     string     `json:"workspace_id,omitempty"` // Workspace that owns the document, if any
+	FolderID        string     `json:"folder_id,omitempty"`    // Folder containing the document, if any
+	Tags            []string   `json:"tags,omitempty"`         // Lowercase labels shared by everyone with access
+	IsTemplate      bool       `json:"is_template,omitempty"`  // Offered as a starting point for new documents
+	CollaboratorIDs []string   `json:"collaborator_ids"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // When the document was moved to the trash, if it is there
+	DeletedBy       string     `json:"deleted_by,omitempty"` // Who moved the document to the trash
 }
 
 // NewDocument creates a new document instance
@@ -32,13 +38,19 @@ func NewDocument(title, content, ownerID string) *Document {
 
 // DocumentDocument represents the document as stored in Couchbase
 type DocumentDocument struct {
-	ID              string    `json:"id"`
-	Title           string    `json:"title"`
-	Content         string    `json:"content"`
-	OwnerID         string    `json:"owner_id"`
-	CollaboratorIDs []string  `json:"collaborator_ids"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              string     `json:"id"`
+	Title           string     `json:"title"`
+	Content         string     `json:"content"`
+	OwnerID         string     `json:"owner_id"`
+	WorkspaceID     string     `json:"workspace_id,omitempty"`
+	FolderID        string     `json:"folder_id,omitempty"`
+	Tags            []string   `json:"tags,omitempty"`
+	IsTemplate      bool       `json:"is_template,omitempty"`
+	CollaboratorIDs []string   `json:"collaborator_ids"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy       string     `json:"deleted_by,omitempty"`
 }
 
 // ToDocument converts Document to DocumentDocument for database storage
@@ -48,9 +60,15 @@ func (d *Document) ToDocument() *DocumentDocument {
 		Title:           d.Title,
 		Content:         d.Content,
 		OwnerID:         d.OwnerID,
+		WorkspaceID:     d.WorkspaceID,
+		FolderID:        d.FolderID,
+		Tags:            d.Tags,
+		IsTemplate:      d.IsTemplate,
 		CollaboratorIDs: d.CollaboratorIDs,
 		CreatedAt:       d.CreatedAt,
 		UpdatedAt:       d.UpdatedAt,
+		DeletedAt:       d.DeletedAt,
+		DeletedBy:       d.DeletedBy,
 	}
 }
 
@@ -64,8 +82,47 @@ func FromDocument(doc *DocumentDocument) *Document {
 		Title:           doc.Title,
 		Content:         doc.Content,
 		OwnerID:         doc.OwnerID,
+		WorkspaceID:     doc.WorkspaceID,
+		FolderID:        doc.FolderID,
+		Tags:            doc.Tags,
+		IsTemplate:      doc.IsTemplate,
 		CollaboratorIDs: doc.CollaboratorIDs,
 		CreatedAt:       doc.CreatedAt,
 		UpdatedAt:       doc.UpdatedAt,
+		DeletedAt:       doc.DeletedAt,
+		DeletedBy:       doc.DeletedBy,
+	}
+}
+
+// IsTrashed reports whether the document is in the trash
+func (d *Document) IsTrashed() bool {
+	return d.DeletedAt != nil
+}
+
+// DocumentSummary is a lightweight view of a document without its content, used in listings
+type DocumentSummary struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	OwnerID     string    `json:"owner_id"`
+	WorkspaceID string    `json:"workspace_id,omitempty"`
+	FolderID    string    `json:"folder_id,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	IsTemplate  bool      `json:"is_template,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Summary returns the document without its content
+func (d *Document) Summary() *DocumentSummary {
+	return &DocumentSummary{
+		ID:          d.ID,
+		Title:       d.Title,
+		OwnerID:     d.OwnerID,
+		WorkspaceID: d.WorkspaceID,
+		FolderID:    d.FolderID,
+		Tags:        d.Tags,
+		IsTemplate:  d.IsTemplate,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
 	}
 }
@@ -0,0 +1,162 @@
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Node types of the editor's rich-text tree, named as in Tiptap
+const (
+	NodeDoc            = "doc"
+	NodeParagraph      = "paragraph" // attrs: textAlign
+	NodeHeading        = "heading"   // attrs: level (1-6), textAlign
+	NodeBlockquote     = "blockquote"
+	NodeBulletList     = "bulletList"
+	NodeOrderedList    = "orderedList" // attrs: start
+	NodeListItem       = "listItem"
+	NodeCodeBlock      = "codeBlock" // attrs: language
+	NodeHorizontalRule = "horizontalRule"
+	NodeHardBreak      = "hardBreak"
+	NodeImage          = "image" // attrs: src, alt, title
+	NodeTable          = "table"
+	NodeTableRow       = "tableRow"
+	NodeTableHeader    = "tableHeader"
+	NodeTableCell      = "tableCell"
+	NodeText           = "text"
+)
+
+// Mark types applied to text nodes
+const (
+	MarkBold      = "bold"
+	MarkItalic    = "italic"
+	MarkStrike    = "strike"
+	MarkCode      = "code"
+	MarkUnderline = "underline"
+	MarkHighlight = "highlight"
+	MarkLink      = "link" // attrs: href, title
+)
+
+// Node is a node of a document's rich-text tree, shaped like Tiptap/ProseMirror JSON
+type Node struct {
+	Type    string                 `json:"type"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*Node                `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []Mark                 `json:"marks,omitempty"`
+}
+
+// Mark is formatting applied to a text node
+type Mark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// NewNode creates a node with the given children
+func NewNode(nodeType string, content ...*Node) *Node {
+	return &Node{Type: nodeType, Content: content}
+}
+
+// NewText creates a text node with the given marks
+func NewText(text string, marks ...Mark) *Node {
+	return &Node{Type: NodeText, Text: text, Marks: marks}
+}
+
+// ParseContent parses document content stored as Tiptap JSON or HTML into a tree
+func ParseContent(content string) (*Node, error) {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") {
+		var doc Node
+		if err := json.Unmarshal([]byte(trimmed), &doc); err != nil {
+			return nil, fmt.Errorf("invalid document JSON: %w", err)
+		}
+		if doc.Type != NodeDoc {
+			return nil, fmt.Errorf("invalid document JSON: root node is %q", doc.Type)
+		}
+		return &doc, nil
+	}
+	return ParseHTML(content)
+}
+
+// Attr returns a string attribute, or "" if it is missing
+func (n *Node) Attr(name string) string {
+	switch value := n.Attrs[name].(type) {
+	case string:
+		return value
+	case float64:
+		return fmt.Sprintf("%g", value)
+	case int:
+		return fmt.Sprintf("%d", value)
+	}
+	return ""
+}
+
+// IntAttr returns an integer attribute, or fallback if it is missing
+func (n *Node) IntAttr(name string, fallback int) int {
+	switch value := n.Attrs[name].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	}
+	return fallback
+}
+
+// SetAttr sets an attribute
+func (n *Node) SetAttr(name string, value interface{}) {
+	if n.Attrs == nil {
+		n.Attrs = map[string]interface{}{}
+	}
+	n.Attrs[name] = value
+}
+
+// HasMark reports whether a text node has a mark of the given type
+func (n *Node) HasMark(markType string) bool {
+	for _, m := range n.Marks {
+		if m.Type == markType {
+			return true
+		}
+	}
+	return false
+}
+
+// Mark returns the text node's mark of the given type, or nil
+func (n *Node) Mark(markType string) *Mark {
+	for i := range n.Marks {
+		if n.Marks[i].Type == markType {
+			return &n.Marks[i]
+		}
+	}
+	return nil
+}
+
+// Attr returns a string attribute of a mark, or "" if it is missing
+func (m *Mark) Attr(name string) string {
+	value, _ := m.Attrs[name].(string)
+	return value
+}
+
+// TextContent returns the concatenated text of a node and its descendants
+func (n *Node) TextContent() string {
+	var b strings.Builder
+	n.Walk(func(node *Node) bool {
+		b.WriteString(node.Text)
+		return true
+	})
+	return b.String()
+}
+
+// Walk calls fn for the node and its descendants in document order; returning false skips the children
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Content {
+		child.Walk(fn)
+	}
+}
+
+// IsInline reports whether the node is inline content of a paragraph or heading
+func (n *Node) IsInline() bool {
+	return n.Type == NodeText || n.Type == NodeHardBreak || n.Type == NodeImage
+}
@@ -0,0 +1,714 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Page geometry and type sizes of PDF exports, in points (A4)
+const (
+	pdfPageWidth   = 595.28
+	pdfPageHeight  = 841.89
+	pdfMargin      = 56.0
+	pdfBodySize    = 11.0
+	pdfCodeSize    = 9.0
+	pdfLineSpacing = 1.4
+	pdfListIndent  = 18.0
+	pdfQuoteIndent = 14.0
+	pdfCellPadding = 4.0
+)
+
+// pdfHeadingSizes are the font sizes of heading levels 1-6
+var pdfHeadingSizes = []float64{20, 17, 15, 13, 12, 11}
+
+// PDF fonts; the standard Type 1 fonts are built into every viewer, so nothing is embedded
+const (
+	pdfFontRegular = iota
+	pdfFontBold
+	pdfFontItalic
+	pdfFontBoldItalic
+	pdfFontMono
+)
+
+// pdfFontNames are the base font names of the PDF fonts
+var pdfFontNames = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique", "Courier"}
+
+// Colors of PDF exports
+var (
+	pdfBlack     = [3]float64{0, 0, 0}
+	pdfGray      = [3]float64{0.4, 0.4, 0.4}
+	pdfLightGray = [3]float64{0.95, 0.95, 0.95}
+	pdfRuleGray  = [3]float64{0.8, 0.8, 0.8}
+	pdfLinkBlue  = [3]float64{0.07, 0.33, 0.8}
+	pdfHighlight = [3]float64{1, 0.95, 0.6}
+)
+
+// helveticaWidths and helveticaBoldWidths are the glyph widths of printable ASCII, in 1/1000 of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556,
+	278, 278, 584, 584, 584, 556, 1015,
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611,
+	278, 278, 278, 469, 556, 333,
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500,
+	334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556,
+	333, 333, 584, 584, 584, 611, 975,
+	722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611,
+	333, 278, 333, 584, 556, 333,
+	556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, 611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500,
+	389, 280, 389, 584,
+}
+
+// latin1BaseLetters maps the letters from 0xC0 to 0xFF to the ASCII letter whose width they share, or '?'
+const latin1BaseLetters = "AAAAAA?CEEEEIIII?NOOOOO?OUUUUY??aaaaaa?ceeeeiiii?nooooo?ouuuuy?y"
+
+// winAnsiSpecials maps the characters of WinAnsiEncoding outside Latin-1 to their codes
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// pdfSpecialWidths are the widths of punctuation outside ASCII, for the regular and bold fonts
+var pdfSpecialWidths = map[byte][2]int{
+	0x85: {1000, 1000}, 0x91: {222, 278}, 0x92: {222, 278}, 0x93: {333, 500}, 0x94: {333, 500},
+	0x95: {350, 350}, 0x96: {556, 556}, 0x97: {1000, 1000}, 0xA0: {278, 278}, 0xB7: {278, 278},
+}
+
+// toWinAnsi encodes a character in WinAnsiEncoding, the encoding of the standard fonts,
+// replacing characters it cannot represent with '?'
+func toWinAnsi(r rune) byte {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return ' '
+	case r >= 0x20 && r <= 0x7E, r >= 0xA0 && r <= 0xFF:
+		return byte(r)
+	}
+	if b, ok := winAnsiSpecials[r]; ok {
+		return b
+	}
+	return '?'
+}
+
+// pdfStyle is the formatting of a run of text
+type pdfStyle struct {
+	size                         float64
+	bold, italic, mono           bool
+	underline, strike, highlight bool
+	color                        [3]float64
+}
+
+// font returns the PDF font of the style
+func (s pdfStyle) font() int {
+	switch {
+	case s.mono:
+		return pdfFontMono
+	case s.bold && s.italic:
+		return pdfFontBoldItalic
+	case s.bold:
+		return pdfFontBold
+	case s.italic:
+		return pdfFontItalic
+	}
+	return pdfFontRegular
+}
+
+// width returns the width of a WinAnsi-encoded character in the style, in points
+// Characters beyond ASCII are approximated by their base letter
+func (s pdfStyle) width(c byte) float64 {
+	if s.mono {
+		return 0.6 * s.size
+	}
+
+	column := 0
+	widths := &helveticaWidths
+	if s.bold {
+		column = 1
+		widths = &helveticaBoldWidths
+	}
+
+	units := []int{556, 611}[column]
+	switch {
+	case c >= 0x20 && c <= 0x7E:
+		units = widths[c-0x20]
+	case c >= 0xC0 && latin1BaseLetters[c-0xC0] != '?':
+		units = widths[latin1BaseLetters[c-0xC0]-0x20]
+	default:
+		if special, ok := pdfSpecialWidths[c]; ok {
+			units = special[column]
+		}
+	}
+	return float64(units) * s.size / 1000
+}
+
+// withMarks returns the style with a text node's marks applied
+func (s pdfStyle) withMarks(marks []Mark) pdfStyle {
+	for _, m := range marks {
+		switch m.Type {
+		case MarkBold:
+			s.bold = true
+		case MarkItalic:
+			s.italic = true
+		case MarkCode:
+			s.mono = true
+		case MarkUnderline:
+			s.underline = true
+		case MarkStrike:
+			s.strike = true
+		case MarkHighlight:
+			s.highlight = true
+		case MarkLink:
+			s.underline = true
+			s.color = pdfLinkBlue
+		}
+	}
+	return s
+}
+
+// pdfItem is a measured run of WinAnsi-encoded text in one style
+type pdfItem struct {
+	text  string
+	style pdfStyle
+	width float64
+}
+
+// Kinds of pdfToken
+const (
+	pdfTokenWord = iota
+	pdfTokenSpace
+	pdfTokenBreak
+)
+
+// pdfToken is a word, a space or a line break, the units of line breaking
+type pdfToken struct {
+	kind  int
+	items []pdfItem
+	width float64
+}
+
+// add appends a character in a style, extending the last item when the style matches
+func (t *pdfToken) add(c byte, style pdfStyle) {
+	w := style.width(c)
+	if last := len(t.items) - 1; last >= 0 && t.items[last].style == style {
+		t.items[last].text += string([]byte{c})
+		t.items[last].width += w
+	} else {
+		t.items = append(t.items, pdfItem{text: string([]byte{c}), style: style, width: w})
+	}
+	t.width += w
+}
+
+// pdfLine is a laid-out line of text
+type pdfLine struct {
+	items []pdfItem
+	width float64
+	size  float64 // The largest font size, which sets the line height
+}
+
+// height returns the height the line takes up
+func (l *pdfLine) height() float64 {
+	return l.size * pdfLineSpacing
+}
+
+// add appends items to the line, merging runs in the same style
+func (l *pdfLine) add(items ...pdfItem) {
+	for _, item := range items {
+		if last := len(l.items) - 1; last >= 0 && l.items[last].style == item.style {
+			l.items[last].text += item.text
+			l.items[last].width += item.width
+		} else {
+			l.items = append(l.items, item)
+		}
+		l.width += item.width
+		l.size = max(l.size, item.style.size)
+	}
+}
+
+// inlineTokens splits inline nodes into words, spaces and line breaks
+func inlineTokens(nodes []*Node, base pdfStyle) []pdfToken {
+	var tokens []pdfToken
+
+	addText := func(text string, style pdfStyle) {
+		for _, r := range text {
+			c := toWinAnsi(r)
+			kind := pdfTokenWord
+			if c == ' ' {
+				kind = pdfTokenSpace
+			}
+			if last := len(tokens) - 1; kind == pdfTokenWord && last >= 0 && tokens[last].kind == pdfTokenWord {
+				tokens[last].add(c, style)
+				continue
+			}
+			tokens = append(tokens, pdfToken{kind: kind})
+			tokens[len(tokens)-1].add(c, style)
+		}
+	}
+
+	for _, n := range nodes {
+		switch n.Type {
+		case NodeText:
+			addText(n.Text, base.withMarks(n.Marks))
+		case NodeHardBreak:
+			tokens = append(tokens, pdfToken{kind: pdfTokenBreak})
+		case NodeImage:
+			style := base
+			style.italic, style.color = true, pdfGray
+			addText(imagePlaceholder(n), style)
+		}
+	}
+	return tokens
+}
+
+// codeTokens splits code into one unbreakable token per line, keeping its spaces
+func codeTokens(code string, style pdfStyle) []pdfToken {
+	var tokens []pdfToken
+	for i, line := range strings.Split(strings.ReplaceAll(code, "\t", "    "), "\n") {
+		if i > 0 {
+			tokens = append(tokens, pdfToken{kind: pdfTokenBreak})
+		}
+		if line == "" {
+			continue
+		}
+		token := pdfToken{kind: pdfTokenWord}
+		for _, r := range line {
+			token.add(toWinAnsi(r), style)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// breakLines fills lines no wider than maxWidth with tokens, breaking at spaces
+// Words wider than a line are split between characters
+func breakLines(tokens []pdfToken, maxWidth, size float64) []pdfLine {
+	var lines []pdfLine
+	line := pdfLine{size: size}
+	var space *pdfToken
+
+	emit := func() {
+		lines = append(lines, line)
+		line = pdfLine{size: size}
+		space = nil
+	}
+
+	for i := range tokens {
+		token := &tokens[i]
+		switch token.kind {
+		case pdfTokenBreak:
+			emit()
+		case pdfTokenSpace:
+			if len(line.items) > 0 {
+				space = token
+			}
+		case pdfTokenWord:
+			needed := token.width
+			if space != nil {
+				needed += space.width
+			}
+			if len(line.items) > 0 && line.width+needed > maxWidth {
+				emit()
+			} else if space != nil {
+				line.add(space.items...)
+			}
+			space = nil
+
+			if token.width <= maxWidth {
+				line.add(token.items...)
+				continue
+			}
+			for _, item := range token.items {
+				for j := 0; j < len(item.text); j++ {
+					w := item.style.width(item.text[j])
+					if len(line.items) > 0 && line.width+w > maxWidth {
+						emit()
+					}
+					line.add(pdfItem{text: item.text[j : j+1], style: item.style, width: w})
+				}
+			}
+		}
+	}
+	if len(line.items) > 0 || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfMarker is a list marker drawn beside the next line
+type pdfMarker struct {
+	text  string
+	x     float64
+	style pdfStyle
+}
+
+// pdfRenderer lays out a tree on pages
+type pdfRenderer struct {
+	pages  []*bytes.Buffer
+	page   *bytes.Buffer
+	y      float64 // Top of the next line
+	marker *pdfMarker
+	quotes []float64 // Positions of the bars of the enclosing block quotes
+}
+
+// RenderPDF renders a tree as a PDF document headed by its title, author and last-modified date
+// The PDF uses the standard fonts, so characters outside Windows-1252 are shown as '?'
+func RenderPDF(doc *Node, meta ExportMetadata) []byte {
+	r := &pdfRenderer{}
+	r.newPage()
+
+	width := pdfPageWidth - 2*pdfMargin
+	body := pdfStyle{size: pdfBodySize, color: pdfBlack}
+
+	title := body
+	title.size, title.bold = pdfHeadingSizes[0]+4, true
+	for _, line := range breakLines(inlineTokens([]*Node{NewText(meta.Title)}, title), width, title.size) {
+		r.drawLine(line, pdfMargin, width, "")
+	}
+	if byline := meta.byline(); byline != "" {
+		small := body
+		small.size, small.color = 9, pdfGray
+		for _, line := range breakLines(inlineTokens([]*Node{NewText(byline)}, small), width, small.size) {
+			r.drawLine(line, pdfMargin, width, "")
+		}
+	}
+	r.rule(pdfMargin, width)
+
+	r.blocks(doc.Content, pdfMargin, width, body)
+
+	r.pageNumbers()
+	return r.encode(meta)
+}
+
+// newPage starts a page
+func (r *pdfRenderer) newPage() {
+	r.page = &bytes.Buffer{}
+	r.pages = append(r.pages, r.page)
+	r.y = pdfPageHeight - pdfMargin
+}
+
+// ensure starts a new page unless height fits on the current one
+func (r *pdfRenderer) ensure(height float64) {
+	if r.y-height < pdfMargin && r.y < pdfPageHeight-pdfMargin {
+		r.newPage()
+	}
+}
+
+// blocks lays out block nodes in a column
+func (r *pdfRenderer) blocks(nodes []*Node, x, width float64, base pdfStyle) {
+	for _, n := range nodes {
+		r.block(n, x, width, base)
+	}
+}
+
+// block lays out one block node in a column
+func (r *pdfRenderer) block(n *Node, x, width float64, base pdfStyle) {
+	switch n.Type {
+	case NodeParagraph:
+		r.paragraph(inlineTokens(n.Content, base), x, width, base.size, n.Attr("textAlign"))
+	case NodeHeading:
+		style := base
+		style.size = pdfHeadingSizes[min(max(n.IntAttr("level", 1), 1), 6)-1]
+		style.bold = true
+		// Keep a heading with the line after it
+		r.ensure(style.size*pdfLineSpacing + base.size*pdfLineSpacing*2)
+		r.y -= style.size * 0.4
+		r.paragraph(inlineTokens(n.Content, style), x, width, style.size, n.Attr("textAlign"))
+	case NodeBlockquote:
+		style := base
+		style.color = pdfGray
+		r.quotes = append(r.quotes, x+2)
+		r.blocks(n.Content, x+pdfQuoteIndent, width-pdfQuoteIndent, style)
+		r.quotes = r.quotes[:len(r.quotes)-1]
+	case NodeBulletList, NodeOrderedList:
+		start := n.IntAttr("start", 1)
+		for i, item := range n.Content {
+			marker := "\x95"
+			if n.Type == NodeOrderedList {
+				marker = fmt.Sprintf("%d.", start+i)
+			}
+			markerWidth := 0.0
+			for j := 0; j < len(marker); j++ {
+				markerWidth += base.width(marker[j])
+			}
+			r.marker = &pdfMarker{text: marker, x: x + pdfListIndent - markerWidth - 5, style: base}
+			r.blocks(item.Content, x+pdfListIndent, width-pdfListIndent, base)
+			r.marker = nil
+		}
+	case NodeCodeBlock:
+		style := base
+		style.mono, style.size, style.color = true, pdfCodeSize, pdfBlack
+		r.y -= 2
+		for _, line := range breakLines(codeTokens(n.TextContent(), style), width-8, style.size) {
+			r.fill(x, line.height(), width, pdfLightGray)
+			r.drawLine(line, x+4, width-8, "")
+		}
+		r.y -= base.size * 0.6
+	case NodeHorizontalRule:
+		r.rule(x, width)
+	case NodeTable:
+		r.table(n, x, width, base)
+	case NodeImage, NodeText, NodeHardBreak:
+		r.paragraph(inlineTokens([]*Node{n}, base), x, width, base.size, "")
+	default:
+		r.blocks(n.Content, x, width, base)
+	}
+}
+
+// paragraph lays out inline content followed by paragraph spacing
+func (r *pdfRenderer) paragraph(tokens []pdfToken, x, width, size float64, align string) {
+	for _, line := range breakLines(tokens, width, size) {
+		r.drawLine(line, x, width, align)
+	}
+	r.y -= size * 0.6
+}
+
+// table lays out a table with equal column widths, repeating nothing across pages
+func (r *pdfRenderer) table(n *Node, x, width float64, base pdfStyle) {
+	columns := 0
+	for _, row := range n.Content {
+		columns = max(columns, len(row.Content))
+	}
+	if columns == 0 {
+		return
+	}
+	columnWidth := width / float64(columns)
+
+	type cellLine struct {
+		line  pdfLine
+		align string
+	}
+
+	for _, row := range n.Content {
+		cells := make([][]cellLine, len(row.Content))
+		rowHeight := 0.0
+		for j, cell := range row.Content {
+			style := base
+			style.bold = cell.Type == NodeTableHeader
+			height := 2 * pdfCellPadding
+			for _, block := range cell.Content {
+				content := block.Content
+				if block.Type != NodeParagraph && block.Type != NodeHeading {
+					content = []*Node{NewText(block.TextContent())}
+				}
+				for _, line := range breakLines(inlineTokens(content, style), columnWidth-2*pdfCellPadding, style.size) {
+					cells[j] = append(cells[j], cellLine{line, block.Attr("textAlign")})
+					height += line.height()
+				}
+			}
+			rowHeight = max(rowHeight, height)
+		}
+
+		r.ensure(rowHeight)
+		for j, cell := range row.Content {
+			cellX := x + float64(j)*columnWidth
+			if cell.Type == NodeTableHeader {
+				r.rect(cellX, r.y-rowHeight, columnWidth, rowHeight, pdfLightGray, true)
+			}
+			r.rect(cellX, r.y-rowHeight, columnWidth, rowHeight, pdfRuleGray, false)
+
+			top := r.y - pdfCellPadding
+			for _, cl := range cells[j] {
+				r.drawText(cl.line, r.alignX(cl.line, cellX+pdfCellPadding, columnWidth-2*pdfCellPadding, cl.align), top)
+				top -= cl.line.height()
+			}
+		}
+		r.y -= rowHeight
+	}
+	r.y -= base.size * 0.6
+}
+
+// drawLine draws a line of text at the top of the remaining space and moves below it
+func (r *pdfRenderer) drawLine(line pdfLine, x, width float64, align string) {
+	height := line.height()
+	r.ensure(height)
+
+	for _, quoteX := range r.quotes {
+		fmt.Fprintf(r.page, "%s RG 2 w %.2f %.2f m %.2f %.2f l S\n", pdfColor(pdfRuleGray), quoteX, r.y, quoteX, r.y-height)
+	}
+	if m := r.marker; m != nil {
+		r.marker = nil
+		marker := pdfLine{size: line.size}
+		marker.add(pdfItem{text: m.text, style: m.style})
+		r.drawText(marker, m.x, r.y)
+	}
+
+	r.drawText(line, r.alignX(line, x, width, align), r.y)
+	r.y -= height
+}
+
+// alignX returns where a line starts in a column of the given width
+func (r *pdfRenderer) alignX(line pdfLine, x, width float64, align string) float64 {
+	switch align {
+	case "center":
+		return x + (width-line.width)/2
+	case "right":
+		return x + width - line.width
+	}
+	return x
+}
+
+// drawText draws the items of a line whose top is at top
+func (r *pdfRenderer) drawText(line pdfLine, x, top float64) {
+	leading := line.height() - line.size
+	baseline := top - leading/2 - line.size*0.8
+
+	for _, item := range line.items {
+		s := item.style
+		if s.highlight {
+			r.rect(x, baseline-s.size*0.25, item.width, s.size*1.1, pdfHighlight, true)
+		}
+		fmt.Fprintf(r.page, "BT %s rg /F%d %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+			pdfColor(s.color), s.font(), s.size, x, baseline, pdfEscape(item.text))
+		if s.underline {
+			r.stroke(x, baseline-s.size*0.12, item.width, s.color)
+		}
+		if s.strike {
+			r.stroke(x, baseline+s.size*0.3, item.width, s.color)
+		}
+		x += item.width
+	}
+}
+
+// rule draws a horizontal rule across a column
+func (r *pdfRenderer) rule(x, width float64) {
+	r.ensure(16)
+	r.y -= 6
+	r.stroke(x, r.y, width, pdfRuleGray)
+	r.y -= 10
+}
+
+// fill paints the background of the next line across a column
+func (r *pdfRenderer) fill(x, height, width float64, color [3]float64) {
+	r.ensure(height)
+	r.rect(x, r.y-height, width, height, color, true)
+}
+
+// stroke draws a thin horizontal line
+func (r *pdfRenderer) stroke(x, y, width float64, color [3]float64) {
+	fmt.Fprintf(r.page, "%s RG 0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfColor(color), x, y, x+width, y)
+}
+
+// rect fills or outlines a rectangle
+func (r *pdfRenderer) rect(x, y, width, height float64, color [3]float64, filled bool) {
+	if filled {
+		fmt.Fprintf(r.page, "%s rg %.2f %.2f %.2f %.2f re f\n", pdfColor(color), x, y, width, height)
+		return
+	}
+	fmt.Fprintf(r.page, "%s RG 0.5 w %.2f %.2f %.2f %.2f re S\n", pdfColor(color), x, y, width, height)
+}
+
+// pageNumbers writes "n / total" at the foot of every page
+func (r *pdfRenderer) pageNumbers() {
+	style := pdfStyle{size: 9, color: pdfGray}
+	for i, page := range r.pages {
+		r.page = page
+		line := breakLines(inlineTokens([]*Node{NewText(fmt.Sprintf("%d / %d", i+1, len(r.pages)))}, style), pdfPageWidth, style.size)[0]
+		r.drawText(line, (pdfPageWidth-line.width)/2, pdfMargin/2+line.height()/2)
+	}
+}
+
+// encode assembles the pages into a PDF file
+func (r *pdfRenderer) encode(meta ExportMetadata) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-3 are the catalog, page tree and metadata, followed by the fonts and a page and content
+	// stream per page
+	firstPage := 4 + len(pdfFontNames)
+	var kids, fonts []string
+	for i := range r.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", firstPage+2*i))
+	}
+	for i := range pdfFontNames {
+		fonts = append(fonts, fmt.Sprintf("/F%d %d 0 R", i, 4+i))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(r.pages)))
+
+	info := fmt.Sprintf("<< /Title %s /Producer (Collaborative Editor)", pdfTextString(meta.Title))
+	if meta.Author != "" {
+		info += " /Author " + pdfTextString(meta.Author)
+	}
+	if !meta.ModifiedAt.IsZero() {
+		info += fmt.Sprintf(" /ModDate (%s)", pdfDate(meta.ModifiedAt))
+	}
+	object(info + fmt.Sprintf(" /CreationDate (%s) >>", pdfDate(time.Now())))
+
+	for _, name := range pdfFontNames {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+	}
+
+	for i, page := range r.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << %s >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, strings.Join(fonts, " "), firstPage+2*i+1))
+
+		var stream bytes.Buffer
+		zw := zlib.NewWriter(&stream)
+		zw.Write(page.Bytes())
+		zw.Close()
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", stream.Len(), stream.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// pdfColor formats a color as PDF operands
+func pdfColor(c [3]float64) string {
+	return fmt.Sprintf("%.3f %.3f %.3f", c[0], c[1], c[2])
+}
+
+// pdfEscape escapes WinAnsi-encoded text for a PDF string literal
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7E:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// pdfTextString encodes metadata text as a UTF-16 PDF string, so any character is kept
+func pdfTextString(text string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfDate formats a time as a PDF date
+func pdfDate(t time.Time) string {
+	return t.UTC().Format("D:20060102150405Z")
+}
@@ -0,0 +1,249 @@
+package document
+
+import (
+	"encoding/json"
+	"html"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// languageClassRegex matches the class the editor puts on code inside a code block
+	languageClassRegex = regexp.MustCompile(`^language-[A-Za-z0-9_+#.\-]{1,32}$`)
+	// colwidthRegex matches the comma-separated column widths of a table cell
+	colwidthRegex = regexp.MustCompile(`^[0-9]{1,5}(,[0-9]{1,5})*$`)
+)
+
+// sanitizedAttrs lists the elements kept by SanitizeHTML and the attributes each may carry
+// Every other element is unwrapped, keeping its content, except for droppedElements
+var sanitizedAttrs = map[atom.Atom][]string{
+	atom.P:          {"style"},
+	atom.H1:         {"style"},
+	atom.H2:         {"style"},
+	atom.H3:         {"style"},
+	atom.H4:         {"style"},
+	atom.H5:         {"style"},
+	atom.H6:         {"style"},
+	atom.Blockquote: nil,
+	atom.Ul:         nil,
+	atom.Ol:         {"start"},
+	atom.Li:         nil,
+	atom.Pre:        nil,
+	atom.Code:       {"class"},
+	atom.Hr:         nil,
+	atom.Br:         nil,
+	atom.Img:        {"src", "alt", "title"},
+	atom.Table:      nil,
+	atom.Thead:      nil,
+	atom.Tbody:      nil,
+	atom.Tfoot:      nil,
+	atom.Tr:         nil,
+	atom.Th:         {"colspan", "rowspan", "colwidth"},
+	atom.Td:         {"colspan", "rowspan", "colwidth"},
+	atom.Strong:     nil,
+	atom.B:          nil,
+	atom.Em:         nil,
+	atom.I:          nil,
+	atom.S:          nil,
+	atom.Strike:     nil,
+	atom.Del:        nil,
+	atom.U:          nil,
+	atom.Mark:       nil,
+	atom.A:          {"href", "title", "target", "rel"},
+}
+
+// droppedElements are removed together with their content, which is script, styling or foreign markup
+var droppedElements = []atom.Atom{
+	atom.Script, atom.Style, atom.Template, atom.Noscript, atom.Iframe, atom.Frame, atom.Frameset, atom.Noframes,
+	atom.Object, atom.Embed, atom.Applet, atom.Svg, atom.Math, atom.Textarea, atom.Select, atom.Xmp, atom.Noembed,
+	atom.Title, atom.Head, atom.Meta, atom.Link, atom.Base,
+}
+
+// linkRelValues are the rel keywords kept on links
+var linkRelValues = []string{"noopener", "noreferrer", "nofollow", "ugc"}
+
+// SanitizeContent removes anything that could run script from document content stored as Tiptap JSON or HTML
+// JSON that cannot be parsed is returned unchanged for validation to reject
+func SanitizeContent(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "{") {
+		return SanitizeHTML(content)
+	}
+
+	var doc Node
+	if err := json.Unmarshal([]byte(trimmed), &doc); err != nil {
+		return content
+	}
+	SanitizeTree(&doc)
+	data, err := json.Marshal(&doc)
+	if err != nil {
+		return content
+	}
+	return string(data)
+}
+
+// SanitizeHTML keeps only the elements and attributes the editor produces, dropping scripts,
+// event handlers, styles other than text alignment and links or images with unsafe URLs
+// The input is parsed as the browser would, so the output is always well-formed
+func SanitizeHTML(content string) string {
+	body := &nethtml.Node{Type: nethtml.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := nethtml.ParseFragment(strings.NewReader(content), body)
+	if err != nil {
+		return html.EscapeString(content)
+	}
+
+	var b strings.Builder
+	for _, n := range nodes {
+		sanitizeHTMLNode(&b, n)
+	}
+	return b.String()
+}
+
+// sanitizeHTMLNode writes an HTML node and its children, keeping only allowed markup
+func sanitizeHTMLNode(b *strings.Builder, n *nethtml.Node) {
+	switch n.Type {
+	case nethtml.TextNode:
+		b.WriteString(html.EscapeString(n.Data))
+		return
+	case nethtml.ElementNode:
+	default:
+		// Comments and doctypes are dropped
+		return
+	}
+
+	// Elements outside the HTML namespace only come from <svg> and <math>, which are dropped whole
+	if n.Namespace != "" || slices.Contains(droppedElements, n.DataAtom) {
+		return
+	}
+
+	allowed, ok := sanitizedAttrs[n.DataAtom]
+	if ok && n.DataAtom == atom.Img && (attr(n, "src") == "" || !IsSafeImageURL(attr(n, "src"))) {
+		return
+	}
+	if ok && n.DataAtom == atom.A && !IsSafeURL(attr(n, "href")) {
+		ok = false
+	}
+	if !ok {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			sanitizeHTMLNode(b, c)
+		}
+		return
+	}
+
+	b.WriteString("<")
+	b.WriteString(n.Data)
+	for _, a := range n.Attr {
+		if a.Namespace != "" || !slices.Contains(allowed, a.Key) {
+			continue
+		}
+		if value, keep := sanitizeAttr(n.DataAtom, a.Key, a.Val); keep {
+			b.WriteString(" ")
+			b.WriteString(a.Key)
+			b.WriteString(`="`)
+			b.WriteString(html.EscapeString(value))
+			b.WriteString(`"`)
+		}
+	}
+	b.WriteString(">")
+
+	switch n.DataAtom {
+	case atom.Br, atom.Hr, atom.Img:
+		return
+	}
+	// The parser drops a newline right after <pre>, so one that belongs to the content must be doubled
+	if n.DataAtom == atom.Pre && n.FirstChild != nil && n.FirstChild.Type == nethtml.TextNode && strings.HasPrefix(n.FirstChild.Data, "\n") {
+		b.WriteString("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeHTMLNode(b, c)
+	}
+	b.WriteString("</")
+	b.WriteString(n.Data)
+	b.WriteString(">")
+}
+
+// sanitizeAttr returns the value to keep for an allowed attribute; keep is false to drop it
+func sanitizeAttr(element atom.Atom, name, value string) (string, bool) {
+	switch name {
+	case "style":
+		// Only the text alignment set by the editor survives
+		if match := textAlignRegex.FindStringSubmatch(value); match != nil {
+			return "text-align: " + match[1], true
+		}
+		return "", false
+	case "href":
+		return value, IsSafeURL(value)
+	case "src":
+		return value, IsSafeImageURL(value)
+	case "class":
+		var classes []string
+		for _, class := range strings.Fields(value) {
+			if element == atom.Code && languageClassRegex.MatchString(class) {
+				classes = append(classes, class)
+			}
+		}
+		return strings.Join(classes, " "), len(classes) > 0
+	case "start", "colspan", "rowspan":
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		return strconv.Itoa(n), err == nil && n >= 0 && n <= 100000
+	case "colwidth":
+		return value, colwidthRegex.MatchString(value)
+	case "target":
+		return value, value == "_blank"
+	case "rel":
+		var rels []string
+		for _, rel := range strings.Fields(strings.ToLower(value)) {
+			if slices.Contains(linkRelValues, rel) {
+				rels = append(rels, rel)
+			}
+		}
+		return strings.Join(rels, " "), len(rels) > 0
+	}
+	return value, true
+}
+
+// sanitizedNodeAttrs lists the attributes kept on each node type by SanitizeTree
+var sanitizedNodeAttrs = map[string][]string{
+	NodeParagraph:   {"textAlign"},
+	NodeHeading:     {"level", "textAlign"},
+	NodeOrderedList: {"start"},
+	NodeCodeBlock:   {"language"},
+	NodeImage:       {"src", "alt", "title"},
+	NodeTableHeader: {"colspan", "rowspan", "colwidth"},
+	NodeTableCell:   {"colspan", "rowspan", "colwidth"},
+}
+
+// SanitizeTree removes links with unsafe URLs and images without a safe source from a tree, along with null nodes and unknown attributes
+func SanitizeTree(doc *Node) {
+	doc.Walk(func(n *Node) bool {
+		n.Content = slices.DeleteFunc(n.Content, func(child *Node) bool {
+			return child == nil || (child.Type == NodeImage && (child.Attr("src") == "" || !IsSafeImageURL(child.Attr("src"))))
+		})
+
+		for name := range n.Attrs {
+			if !slices.Contains(sanitizedNodeAttrs[n.Type], name) {
+				delete(n.Attrs, name)
+			}
+		}
+		if len(n.Attrs) == 0 {
+			n.Attrs = nil
+		}
+
+		n.Marks = slices.DeleteFunc(n.Marks, func(m Mark) bool {
+			return m.Type == MarkLink && !IsSafeURL(m.Attr("href"))
+		})
+		for i := range n.Marks {
+			for name := range n.Marks[i].Attrs {
+				if n.Marks[i].Type != MarkLink || !slices.Contains(sanitizedAttrs[atom.A], name) {
+					delete(n.Marks[i].Attrs, name)
+				}
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,189 @@
+package document
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	nethtml "golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// xssPayloads is a regression corpus of script injection attempts; none may survive SanitizeHTML
+var xssPayloads = []string{
+	`<script>alert(1)</script>`,
+	`<SCRIPT SRC=https://evil.example/xss.js></SCRIPT>`,
+	`"><script>alert(1)</script>`,
+	`<img src=x onerror=alert(1)>`,
+	`<img src="javascript:alert(1)">`,
+	`<img src=x:alert(alt) onerror=eval(src) alt=0>`,
+	`<img src="data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9YWxlcnQoMSk+">`,
+	`<img """><script>alert(1)</script>">`,
+	`<a href="javascript:alert(1)">click</a>`,
+	`<a href="JaVaScRiPt:alert(1)">click</a>`,
+	`<a href=" javascript:alert(1)">click</a>`,
+	`<a href="java&#x09;script:alert(1)">click</a>`,
+	`<a href="java&#10;script:alert(1)">click</a>`,
+	`<a href="&#106;&#97;&#118;&#97;&#115;&#99;&#114;&#105;&#112;&#116;&#58;alert(1)">click</a>`,
+	`<a href="&#x6A;avascript&colon;alert(1)">click</a>`,
+	`<a href="vbscript:msgbox(1)">click</a>`,
+	`<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">click</a>`,
+	`<a href="#" onclick="alert(1)">click</a>`,
+	`<a href="https://example.com" onmouseover="alert(1)" style="position:fixed;inset:0">click</a>`,
+	`<p onclick="alert(1)" style="text-align: center; background-image: url(javascript:alert(1))">text</p>`,
+	`<div style="background:url(javascript:alert(1))">text</div>`,
+	`<svg onload=alert(1)>`,
+	`<svg><script>alert(1)</script></svg>`,
+	`<svg><a xlink:href="javascript:alert(1)"><text x="20" y="20">click</text></a></svg>`,
+	`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+	`<math href="javascript:alert(1)">click</math>`,
+	`<iframe src="javascript:alert(1)"></iframe>`,
+	`<iframe srcdoc="<script>alert(1)</script>"></iframe>`,
+	`<object data="javascript:alert(1)"></object>`,
+	`<embed src="javascript:alert(1)">`,
+	`<body onload=alert(1)>`,
+	`<form action="javascript:alert(1)"><button>submit</button></form>`,
+	`<button formaction="javascript:alert(1)">submit</button>`,
+	`<input onfocus=alert(1) autofocus>`,
+	`<details open ontoggle=alert(1)>`,
+	`<video><source onerror="alert(1)"></video>`,
+	`<audio src=x onerror=alert(1)>`,
+	`<marquee onstart=alert(1)>`,
+	`<meta http-equiv="refresh" content="0;url=javascript:alert(1)">`,
+	`<base href="javascript:alert(1)//">`,
+	`<link rel=stylesheet href="https://evil.example/x.css">`,
+	`<style>@import 'https://evil.example/x.css';</style>`,
+	`<style><img src=x onerror=alert(1)></style>`,
+	`<!--<img src=x onerror=alert(1)>-->`,
+	`<noscript><p title="</noscript><img src=x onerror=alert(1)>">`,
+	`<textarea><script>alert(1)</script></textarea>`,
+	`<xmp><img src=x onerror=alert(1)></xmp>`,
+	`<template><script>alert(1)</script></template>`,
+	`<table background="javascript:alert(1)"><tr><td onmouseover=alert(1)>cell</td></tr></table>`,
+	`<pre><code class="language-go&quot; onclick=&quot;alert(1)">code</code></pre>`,
+	`<p><a href="https://example.com" target="_top" rel="opener">link</a></p>`,
+	`<scr<script>ipt>alert(1)</scr</script>ipt>`,
+	`<<script>script>alert(1)//<</script>/script>`,
+}
+
+func TestSanitizeHTMLRemovesXSS(t *testing.T) {
+	for _, payload := range xssPayloads {
+		out := SanitizeHTML(payload)
+		if problem := unsafeMarkup(out); problem != "" {
+			t.Errorf("SanitizeHTML(%q) = %q: %s", payload, out, problem)
+		}
+		if again := SanitizeHTML(out); again != out {
+			t.Errorf("SanitizeHTML is not idempotent for %q: %q then %q", payload, out, again)
+		}
+	}
+}
+
+func TestSanitizeHTMLKeepsEditorFormatting(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"paragraphs", `<p>Hello <strong>bold</strong> <em>italic</em> <u>under</u> <s>strike</s> <mark>hi</mark> <code>x</code></p><p></p>`,
+			`<p>Hello <strong>bold</strong> <em>italic</em> <u>under</u> <s>strike</s> <mark>hi</mark> <code>x</code></p><p></p>`},
+		{"alignment", `<h2 style="text-align: center">Title</h2><p style="text-align:right">r</p>`,
+			`<h2 style="text-align: center">Title</h2><p style="text-align: right">r</p>`},
+		{"link", `<p><a target="_blank" rel="noopener noreferrer nofollow" href="https://example.com/?a=1&amp;b=2">link</a></p>`,
+			`<p><a target="_blank" rel="noopener noreferrer nofollow" href="https://example.com/?a=1&amp;b=2">link</a></p>`},
+		{"relative and mailto links", `<p><a href="/docs/1">a</a> <a href="mailto:me@example.com">b</a></p>`,
+			`<p><a href="/docs/1">a</a> <a href="mailto:me@example.com">b</a></p>`},
+		{"lists", `<ul><li><p>one</p></li></ul><ol start="3"><li><p>three</p></li></ol>`,
+			`<ul><li><p>one</p></li></ul><ol start="3"><li><p>three</p></li></ol>`},
+		{"code block", "<pre><code class=\"language-go\">if a &lt; b {\n}</code></pre>",
+			"<pre><code class=\"language-go\">if a &lt; b {\n}</code></pre>"},
+		{"quote and rule", `<blockquote><p>q</p></blockquote><hr><p>a<br>b</p>`,
+			`<blockquote><p>q</p></blockquote><hr><p>a<br>b</p>`},
+		{"images", `<img src="https://example.com/a.png" alt="a"><img src="data:image/png;base64,iVBORw0KGgo=">`,
+			`<img src="https://example.com/a.png" alt="a"><img src="data:image/png;base64,iVBORw0KGgo=">`},
+		{"table", `<table><tbody><tr><th colspan="2"><p>h</p></th></tr><tr><td><p>c</p></td></tr></tbody></table>`,
+			`<table><tbody><tr><th colspan="2"><p>h</p></th></tr><tr><td><p>c</p></td></tr></tbody></table>`},
+		{"unknown elements are unwrapped", `<div class="x"><span style="color:red">text</span></div>`, `text`},
+		{"text is escaped", `a &lt;b&gt; &amp; "c"`, `a &lt;b&gt; &amp; &#34;c&#34;`},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeHTML(tt.in); got != tt.want {
+			t.Errorf("%s: SanitizeHTML(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeContentJSON(t *testing.T) {
+	in := `{"type":"doc","content":[{"type":"paragraph","attrs":{"textAlign":"center","onclick":"alert(1)"},"content":[` +
+		`{"type":"text","text":"bad","marks":[{"type":"link","attrs":{"href":"javascript:alert(1)"}}]},` +
+		`{"type":"text","text":"good","marks":[{"type":"bold"},{"type":"link","attrs":{"href":"https://example.com","onclick":"x"}}]},` +
+		`{"type":"image","attrs":{"src":"javascript:alert(1)"}},` +
+		`{"type":"image","attrs":{"src":"https://example.com/a.png","onerror":"alert(1)"}}]}]}`
+
+	var doc Node
+	if err := json.Unmarshal([]byte(SanitizeContent(in)), &doc); err != nil {
+		t.Fatalf("SanitizeContent returned invalid JSON: %v", err)
+	}
+
+	para := doc.Content[0]
+	if len(para.Attrs) != 1 || para.Attr("textAlign") != "center" {
+		t.Errorf("paragraph attrs = %v, want only textAlign", para.Attrs)
+	}
+	if len(para.Content) != 3 {
+		t.Fatalf("paragraph has %d children, want 3 (the unsafe image removed)", len(para.Content))
+	}
+	if para.Content[0].HasMark(MarkLink) {
+		t.Errorf("javascript: link was kept")
+	}
+	if link := para.Content[1].Mark(MarkLink); link == nil || len(link.Attrs) != 1 || link.Attr("href") != "https://example.com" {
+		t.Errorf("safe link = %v, want only its href", link)
+	}
+	if image := para.Content[2]; len(image.Attrs) != 1 || image.Attr("src") != "https://example.com/a.png" {
+		t.Errorf("safe image attrs = %v, want only src", image.Attrs)
+	}
+}
+
+// unsafeMarkup describes the first element or attribute in sanitized HTML outside the allowlist, or returns ""
+func unsafeMarkup(out string) string {
+	body := &nethtml.Node{Type: nethtml.ElementNode, DataAtom: atom.Body, Data: "body"}
+	nodes, err := nethtml.ParseFragment(strings.NewReader(out), body)
+	if err != nil {
+		return err.Error()
+	}
+
+	var check func(n *nethtml.Node) string
+	check = func(n *nethtml.Node) string {
+		if n.Type == nethtml.CommentNode {
+			return "comment kept"
+		}
+		if n.Type == nethtml.ElementNode {
+			allowed, ok := sanitizedAttrs[n.DataAtom]
+			if !ok || n.Namespace != "" {
+				return "element <" + n.Data + "> kept"
+			}
+			for _, a := range n.Attr {
+				if !slices.Contains(allowed, a.Key) {
+					return "attribute " + a.Key + " kept on <" + n.Data + ">"
+				}
+				if a.Key == "href" && !IsSafeURL(a.Val) || a.Key == "src" && !IsSafeImageURL(a.Val) {
+					return "unsafe URL " + a.Val + " kept"
+				}
+				if a.Key == "style" && strings.ContainsAny(strings.TrimPrefix(a.Val, "text-align:"), ":;(") {
+					return "style " + a.Val + " kept"
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if problem := check(c); problem != "" {
+				return problem
+			}
+		}
+		return ""
+	}
+
+	for _, n := range nodes {
+		if problem := check(n); problem != "" {
+			return problem
+		}
+	}
+	return ""
+}
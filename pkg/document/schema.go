@@ -0,0 +1,267 @@
+package document
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits bounds the size and shape of document content
+type Limits struct {
+	MaxContentBytes int // Size of the stored content, JSON or HTML
+	MaxDepth        int // Nesting depth of the tree, counting the doc node
+	MaxNodes        int // Number of nodes in the tree, including text nodes
+}
+
+// DefaultLimits are the limits used unless the server is configured otherwise
+var DefaultLimits = Limits{
+	MaxContentBytes: 2 << 20,
+	MaxDepth:        32,
+	MaxNodes:        50000,
+}
+
+// maxValidationErrors is how many problems are reported before validation gives up
+const maxValidationErrors = 20
+
+// ValidationError describes one problem with document content
+type ValidationError struct {
+	Path    string `json:"path"` // JSON pointer to the offending node, such as "/content/2/content/0"; "" for the whole document
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists the problems found in document content
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, problem := range e {
+		if problem.Path == "" {
+			messages[i] = problem.Message
+		} else {
+			messages[i] = problem.Path + ": " + problem.Message
+		}
+	}
+	return "invalid document content: " + strings.Join(messages, "; ")
+}
+
+// allowedContent lists the node types allowed as children of each node type
+// Images may stand alone as blocks as well as sit inline in text
+var (
+	blockTypes  = []string{NodeParagraph, NodeHeading, NodeBlockquote, NodeBulletList, NodeOrderedList, NodeCodeBlock, NodeHorizontalRule, NodeImage, NodeTable}
+	inlineTypes = []string{NodeText, NodeHardBreak, NodeImage}
+
+	allowedContent = map[string][]string{
+		NodeDoc:            blockTypes,
+		NodeParagraph:      inlineTypes,
+		NodeHeading:        inlineTypes,
+		NodeBlockquote:     blockTypes,
+		NodeBulletList:     {NodeListItem},
+		NodeOrderedList:    {NodeListItem},
+		NodeListItem:       blockTypes,
+		NodeCodeBlock:      {NodeText},
+		NodeHorizontalRule: nil,
+		NodeHardBreak:      nil,
+		NodeImage:          nil,
+		NodeTable:          {NodeTableRow},
+		NodeTableRow:       {NodeTableHeader, NodeTableCell},
+		NodeTableHeader:    blockTypes,
+		NodeTableCell:      blockTypes,
+		NodeText:           nil,
+	}
+
+	// nonEmptyTypes must have at least one child
+	nonEmptyTypes = []string{NodeBlockquote, NodeBulletList, NodeOrderedList, NodeListItem, NodeTable, NodeTableRow, NodeTableHeader, NodeTableCell}
+
+	knownMarks = []string{MarkBold, MarkItalic, MarkStrike, MarkCode, MarkUnderline, MarkHighlight, MarkLink}
+
+	textAlignments = []string{"left", "center", "right", "justify"}
+)
+
+// ValidateContent parses stored document content and checks it against the editor schema and limits
+// It returns the parsed tree, or the problems found; content that cannot be parsed yields a single problem
+func ValidateContent(content string, limits Limits) (*Node, ValidationErrors) {
+	if limits.MaxContentBytes > 0 && len(content) > limits.MaxContentBytes {
+		return nil, ValidationErrors{{Message: fmt.Sprintf("content is %d bytes, more than the limit of %d", len(content), limits.MaxContentBytes)}}
+	}
+	if !utf8.ValidString(content) {
+		return nil, ValidationErrors{{Message: "content is not valid UTF-8"}}
+	}
+
+	doc, err := ParseContent(content)
+	if err != nil {
+		return nil, ValidationErrors{{Message: err.Error()}}
+	}
+
+	if problems := Validate(doc, limits); len(problems) > 0 {
+		return nil, problems
+	}
+	return doc, nil
+}
+
+// Validate checks a tree against the editor schema and the depth and node limits
+func Validate(doc *Node, limits Limits) ValidationErrors {
+	v := &validator{limits: limits}
+	if doc.Type != NodeDoc {
+		v.report("", "root node must be %q, not %q", NodeDoc, doc.Type)
+		return v.problems
+	}
+	v.node(doc, "", 1)
+	return v.problems
+}
+
+// validator walks a tree collecting schema violations
+type validator struct {
+	limits   Limits
+	nodes    int
+	problems ValidationErrors
+	stopped  bool // Set once a limit is exceeded or too many problems were found
+}
+
+// report records a problem, stopping validation once enough have been found
+func (v *validator) report(path, format string, args ...interface{}) {
+	if v.stopped {
+		return
+	}
+	v.problems = append(v.problems, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	if len(v.problems) >= maxValidationErrors {
+		v.stopped = true
+	}
+}
+
+// node checks a node and its descendants
+func (v *validator) node(n *Node, path string, depth int) {
+	if v.stopped {
+		return
+	}
+
+	v.nodes++
+	if v.limits.MaxNodes > 0 && v.nodes > v.limits.MaxNodes {
+		v.report(path, "document has more than %d nodes", v.limits.MaxNodes)
+		v.stopped = true
+		return
+	}
+	if v.limits.MaxDepth > 0 && depth > v.limits.MaxDepth {
+		v.report(path, "document is nested more than %d levels deep", v.limits.MaxDepth)
+		v.stopped = true
+		return
+	}
+
+	allowed, known := allowedContent[n.Type]
+	if !known {
+		v.report(path, "unknown node type %q", n.Type)
+		return
+	}
+
+	if n.Type == NodeText {
+		v.text(n, path)
+	} else {
+		if n.Text != "" {
+			v.report(path, "%s node cannot have text", n.Type)
+		}
+		if len(n.Marks) > 0 {
+			v.report(path, "%s node cannot have marks", n.Type)
+		}
+	}
+	v.attrs(n, path)
+
+	if len(n.Content) == 0 && slices.Contains(nonEmptyTypes, n.Type) {
+		v.report(path, "%s node must have content", n.Type)
+	}
+	for i, child := range n.Content {
+		childPath := path + "/content/" + strconv.Itoa(i)
+		if child == nil {
+			v.report(childPath, "node is null")
+			continue
+		}
+		if !slices.Contains(allowed, child.Type) {
+			if _, ok := allowedContent[child.Type]; !ok {
+				v.report(childPath, "unknown node type %q", child.Type)
+			} else if len(allowed) == 0 {
+				v.report(childPath, "%s node cannot have content", n.Type)
+			} else {
+				v.report(childPath, "%s node is not allowed inside %s", child.Type, n.Type)
+			}
+			continue
+		}
+		if n.Type == NodeCodeBlock && len(child.Marks) > 0 {
+			v.report(childPath, "text in a code block cannot have marks")
+		}
+		v.node(child, childPath, depth+1)
+	}
+}
+
+// text checks a text node and its marks
+func (v *validator) text(n *Node, path string) {
+	if n.Text == "" {
+		v.report(path, "text node cannot be empty")
+	}
+	if len(n.Content) > 0 {
+		v.report(path, "text node cannot have content")
+	}
+
+	seen := make(map[string]bool, len(n.Marks))
+	for i, m := range n.Marks {
+		markPath := path + "/marks/" + strconv.Itoa(i)
+		if !slices.Contains(knownMarks, m.Type) {
+			v.report(markPath, "unknown mark type %q", m.Type)
+			continue
+		}
+		if seen[m.Type] {
+			v.report(markPath, "%s mark is applied twice", m.Type)
+		}
+		seen[m.Type] = true
+
+		if m.Type == MarkLink {
+			href := m.Attr("href")
+			if href == "" {
+				v.report(markPath, "link must have an href")
+			} else if !IsSafeURL(href) {
+				v.report(markPath, "link href %q uses a scheme that is not allowed", href)
+			}
+		}
+	}
+}
+
+// attrs checks the attributes of a node
+func (v *validator) attrs(n *Node, path string) {
+	switch n.Type {
+	case NodeHeading:
+		if level, ok := integerAttr(n, "level"); !ok || level < 1 || level > 6 {
+			v.report(path, "heading level must be an integer from 1 to 6")
+		}
+	case NodeOrderedList:
+		if _, present := n.Attrs["start"]; present {
+			if start, ok := integerAttr(n, "start"); !ok || start < 0 {
+				v.report(path, "ordered list start must be a non-negative integer")
+			}
+		}
+	case NodeImage:
+		src := n.Attr("src")
+		if src == "" {
+			v.report(path, "image must have a src")
+		} else if !IsSafeImageURL(src) {
+			v.report(path, "image src uses a scheme that is not allowed")
+		}
+	}
+
+	if align, present := n.Attrs["textAlign"]; present && align != nil {
+		if s, ok := align.(string); !ok || !slices.Contains(textAlignments, s) {
+			v.report(path, "textAlign must be one of %s", strings.Join(textAlignments, ", "))
+		}
+	}
+}
+
+// integerAttr returns an attribute holding a whole number; ok is false if it is missing or not one
+func integerAttr(n *Node, name string) (int, bool) {
+	switch value := n.Attrs[name].(type) {
+	case int:
+		return value, true
+	case float64:
+		if value == float64(int(value)) {
+			return int(value), true
+		}
+	}
+	return 0, false
+}
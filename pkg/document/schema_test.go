@@ -0,0 +1,84 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateContent(t *testing.T) {
+	small := Limits{MaxContentBytes: 1000, MaxDepth: 4, MaxNodes: 6}
+
+	tests := []struct {
+		name     string
+		content  string
+		limits   Limits
+		wantPath string // Path of the first problem; ignored when wantErr is empty
+		wantErr  string // Substring of the first problem's message; "" if the content is valid
+	}{
+		{"valid JSON", `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":"hi","marks":[{"type":"bold"}]}]}]}`,
+			DefaultLimits, "", ""},
+		{"valid HTML", `<h1>Title</h1><ul><li><p>one</p></li></ul>`, DefaultLimits, "", ""},
+		{"empty paragraph", `{"type":"doc","content":[{"type":"paragraph"}]}`, DefaultLimits, "", ""},
+		{"too large", `<p>` + strings.Repeat("a", 1000) + `</p>`, small,
+			"", "more than the limit of 1000"},
+		{"invalid UTF-8", "<p>\xff</p>", DefaultLimits,
+			"", "not valid UTF-8"},
+		{"invalid JSON", `{"type":"doc",`, DefaultLimits,
+			"", "invalid document JSON"},
+		{"too deep", `{"type":"doc","content":[{"type":"blockquote","content":[{"type":"blockquote","content":[{"type":"blockquote","content":[{"type":"paragraph"}]}]}]}]}`, small,
+			"/content/0/content/0/content/0/content/0", "nested more than 4 levels"},
+		{"too many nodes", `{"type":"doc","content":[{"type":"paragraph"},{"type":"paragraph"},{"type":"paragraph"},{"type":"paragraph"},{"type":"paragraph"},{"type":"paragraph"}]}`, small,
+			"/content/5", "more than 6 nodes"},
+		{"unknown node", `{"type":"doc","content":[{"type":"iframe"}]}`, DefaultLimits,
+			"/content/0", `unknown node type "iframe"`},
+		{"block inside paragraph", `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"heading"}]}]}`, DefaultLimits,
+			"/content/0/content/0", "heading node is not allowed inside paragraph"},
+		{"text at top level", `{"type":"doc","content":[{"type":"text","text":"loose"}]}`, DefaultLimits,
+			"/content/0", "text node is not allowed inside doc"},
+		{"children of a leaf", `{"type":"doc","content":[{"type":"horizontalRule","content":[{"type":"paragraph"}]}]}`, DefaultLimits,
+			"/content/0/content/0", "horizontalRule node cannot have content"},
+		{"empty list", `{"type":"doc","content":[{"type":"bulletList"}]}`, DefaultLimits,
+			"/content/0", "bulletList node must have content"},
+		{"empty table cell", `{"type":"doc","content":[{"type":"table","content":[{"type":"tableRow","content":[{"type":"tableCell"}]}]}]}`, DefaultLimits,
+			"/content/0/content/0/content/0", "tableCell node must have content"},
+		{"empty text", `{"type":"doc","content":[{"type":"paragraph","content":[{"type":"text","text":""}]}]}`, DefaultLimits,
+			"/content/0/content/0", "text node cannot be empty"},
+	}
+
+	for _, tt := range tests {
+		doc, problems := ValidateContent(tt.content, tt.limits)
+		if tt.wantErr == "" {
+			if len(problems) > 0 {
+				t.Errorf("%s: unexpected problems: %v", tt.name, problems)
+			} else if doc == nil || doc.Type != NodeDoc {
+				t.Errorf("%s: got tree %v, want a doc node", tt.name, doc)
+			}
+			continue
+		}
+
+		if len(problems) == 0 {
+			t.Errorf("%s: no problems found, want %q", tt.name, tt.wantErr)
+			continue
+		}
+		if doc != nil {
+			t.Errorf("%s: a tree was returned along with problems", tt.name)
+		}
+		if got := problems[0]; got.Path != tt.wantPath || !strings.Contains(got.Message, tt.wantErr) {
+			t.Errorf("%s: first problem = %q at %q, want %q at %q", tt.name, got.Message, got.Path, tt.wantErr, tt.wantPath)
+		}
+	}
+}
+
+func TestValidateRootAndProblemLimit(t *testing.T) {
+	if problems := Validate(&Node{Type: NodeParagraph}, DefaultLimits); len(problems) != 1 || problems[0].Path != "" {
+		t.Errorf("non-doc root: problems = %v, want one for the whole document", problems)
+	}
+
+	doc := &Node{Type: NodeDoc}
+	for i := 0; i < 2*maxValidationErrors; i++ {
+		doc.Content = append(doc.Content, &Node{Type: "unknown"})
+	}
+	if problems := Validate(doc, DefaultLimits); len(problems) != maxValidationErrors {
+		t.Errorf("got %d problems, want validation to stop at %d", len(problems), maxValidationErrors)
+	}
+}
@@ -0,0 +1,76 @@
+package document
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// OutlineEntry is a heading in a document's outline
+type OutlineEntry struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Block int    `json:"block"` // Index of the top-level block containing the heading
+}
+
+// Outline returns the document's headings in order, including those nested in quotes, lists and tables
+func Outline(doc *Node) []OutlineEntry {
+	outline := []OutlineEntry{}
+	for i, block := range doc.Content {
+		block.Walk(func(n *Node) bool {
+			if n.Type != NodeHeading {
+				return true
+			}
+			if text := strings.Join(strings.Fields(textblockText(n)), " "); text != "" {
+				outline = append(outline, OutlineEntry{Level: n.IntAttr("level", 1), Text: text, Block: i})
+			}
+			return false
+		})
+	}
+	return outline
+}
+
+// WordCount returns the number of whitespace-separated words in the document
+func WordCount(doc *Node) int {
+	count := 0
+	for _, text := range textblocks(doc) {
+		count += len(strings.Fields(text))
+	}
+	return count
+}
+
+// CharacterCount returns the number of characters in the document's text, including spaces
+func CharacterCount(doc *Node) int {
+	count := 0
+	for _, text := range textblocks(doc) {
+		count += utf8.RuneCountInString(text)
+	}
+	return count
+}
+
+// textblocks returns the text of each paragraph, heading and code block, in document order
+// Words never run on from one block into the next
+func textblocks(doc *Node) []string {
+	var texts []string
+	doc.Walk(func(n *Node) bool {
+		switch n.Type {
+		case NodeParagraph, NodeHeading, NodeCodeBlock:
+			texts = append(texts, textblockText(n))
+			return false
+		}
+		return true
+	})
+	return texts
+}
+
+// textblockText returns the text of a block of inline content, with hard breaks as newlines
+func textblockText(n *Node) string {
+	var b strings.Builder
+	for _, child := range n.Content {
+		if child.Type == NodeHardBreak {
+			b.WriteByte('\n')
+		} else {
+			b.WriteString(child.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package folder
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Folder represents a folder that groups documents and other folders
+type Folder struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	ParentID        string    `json:"parent_id,omitempty"` // Empty for top-level folders
+	OwnerID         string    `json:"owner_id"`
+	WorkspaceID     string    `json:"workspace_id,omitempty"` // Workspace the folder belongs to, if any
+	CollaboratorIDs []string  `json:"collaborator_ids"`       // Shared with; cascades to everything inside
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// NewFolder creates a new folder instance
+func NewFolder(name, parentID, ownerID, workspaceID string) *Folder {
+	return &Folder{
+		ID:              uuid.New().String(),
+		Name:            name,
+		ParentID:        parentID,
+		OwnerID:         ownerID,
+		WorkspaceID:     workspaceID,
+		CollaboratorIDs: []string{},
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+}
+
+// FolderDocument represents the folder as stored in Couchbase
+type FolderDocument struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	ParentID        string    `json:"parent_id,omitempty"`
+	OwnerID         string    `json:"owner_id"`
+	WorkspaceID     string    `json:"workspace_id,omitempty"`
+	CollaboratorIDs []string  `json:"collaborator_ids"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ToDocument converts Folder to FolderDocument for database storage
+func (f *Folder) ToDocument() *FolderDocument {
+	return &FolderDocument{
+		ID:              f.ID,
+		Name:            f.Name,
+		ParentID:        f.ParentID,
+		OwnerID:         f.OwnerID,
+		WorkspaceID:     f.WorkspaceID,
+		CollaboratorIDs: f.CollaboratorIDs,
+		CreatedAt:       f.CreatedAt,
+		UpdatedAt:       f.UpdatedAt,
+	}
+}
+
+// FromDocument creates a Folder from FolderDocument
+func FromDocument(doc *FolderDocument) *Folder {
+	if doc == nil {
+		return nil
+	}
+	collaboratorIDs := doc.CollaboratorIDs
+	if collaboratorIDs == nil {
+		collaboratorIDs = []string{}
+	}
+	return &Folder{
+		ID:              doc.ID,
+		Name:            doc.Name,
+		ParentID:        doc.ParentID,
+		OwnerID:         doc.OwnerID,
+		WorkspaceID:     doc.WorkspaceID,
+		CollaboratorIDs: collaboratorIDs,
+		CreatedAt:       doc.CreatedAt,
+		UpdatedAt:       doc.UpdatedAt,
+	}
+}
@@ -8,12 +8,40 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID           string    `json:"id" couchbase:"id"`
-	Username     string    `json:"username" couchbase:"username"`
-	Email        string    `json:"email" couchbase:"email"`
-	PasswordHash string    `json:"-" couchbase:"password_hash"` // Excluded from JSON API responses but stored in DB
-	CreatedAt    time.Time `json:"created_at" couchbase:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" couchbase:"updated_at"`
+	ID           string      `json:"id" couchbase:"id"`
+	Username     string      `json:"username" couchbase:"username"`
+	Email        string      `json:"email" couchbase:"email"`
+	PasswordHash string      `json:"-" couchbase:"password_hash"`   // Excluded from JSON API responses but stored in DB
+	OIDCIssuer   string      `json:"-" couchbase:"oidc_issuer"`     // Identity provider the account is linked to (if any)
+	OIDCSubject  string      `json:"-" couchbase:"oidc_subject"`    // Subject identifier at the identity provider
+	Verified     bool        `json:"verified" couchbase:"verified"` // Whether the email address has been verified
+	TOTP         TOTP        `json:"-" couchbase:"totp"`            // Two-factor authentication settings
+	DisplayName  string      `json:"display_name" couchbase:"display_name"`
+	AvatarURL    string      `json:"avatar_url" couchbase:"avatar_url"`
+	Preferences  Preferences `json:"preferences" couchbase:"preferences"`
+	CreatedAt    time.Time   `json:"created_at" couchbase:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at" couchbase:"updated_at"`
+}
+
+// Preferences holds user interface and notification settings
+type Preferences struct {
+	Theme              string `json:"theme,omitempty"`  // "light", "dark" or "system"
+	Locale             string `json:"locale,omitempty"` // BCP 47 language tag, e.g. "en-US"
+	EmailNotifications bool   `json:"email_notifications"`
+	Discoverable       *bool  `json:"discoverable,omitempty"` // Whether others can find the user in search; defaults to true
+}
+
+// IsDiscoverable reports whether the user can be found in user search
+func (p Preferences) IsDiscoverable() bool {
+	return p.Discoverable == nil || *p.Discoverable
+}
+
+// TOTP holds the time-based one-time password (2FA) settings of a user
+type TOTP struct {
+	Enabled            bool     `json:"enabled"`
+	Secret             string   `json:"secret,omitempty"`               // Base32 secret, set on enrollment
+	RecoveryCodeHashes []string `json:"recovery_code_hashes,omitempty"` // SHA256 hashes of unused recovery codes
+	LastUsedStep       int64    `json:"last_used_step,omitempty"`       // Prevents reuse of a code within its time window
 }
 
 // NewUser creates a new user
@@ -31,12 +59,19 @@ func NewUser(username, email, passwordHash string) *User {
 // UserDocument represents the user as stored in Couchbase
 // This ensures PasswordHash is included when storing to database
 type UserDocument struct {
-	ID           string    `json:"id"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"password_hash"` // Explicitly included for storage
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string      `json:"id"`
+	Username     string      `json:"username"`
+	Email        string      `json:"email"`
+	PasswordHash string      `json:"password_hash"` // Explicitly included for storage
+	OIDCIssuer   string      `json:"oidc_issuer,omitempty"`
+	OIDCSubject  string      `json:"oidc_subject,omitempty"`
+	Verified     bool        `json:"verified"`
+	TOTP         TOTP        `json:"totp"`
+	DisplayName  string      `json:"display_name,omitempty"`
+	AvatarURL    string      `json:"avatar_url,omitempty"`
+	Preferences  Preferences `json:"preferences"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
 }
 
 // ToDocument converts User to UserDocument for database storage
@@ -46,6 +81,13 @@ func (u *User) ToDocument() *UserDocument {
 		Username:     u.Username,
 		Email:        u.Email,
 		PasswordHash: u.PasswordHash,
+		OIDCIssuer:   u.OIDCIssuer,
+		OIDCSubject:  u.OIDCSubject,
+		Verified:     u.Verified,
+		TOTP:         u.TOTP,
+		DisplayName:  u.DisplayName,
+		AvatarURL:    u.AvatarURL,
+		Preferences:  u.Preferences,
 		CreatedAt:    u.CreatedAt,
 		UpdatedAt:    u.UpdatedAt,
 	}
@@ -61,6 +103,13 @@ func FromDocument(doc *UserDocument) *User {
 		Username:     doc.Username,
 		Email:        doc.Email,
 		PasswordHash: doc.PasswordHash,
+		OIDCIssuer:   doc.OIDCIssuer,
+		OIDCSubject:  doc.OIDCSubject,
+		Verified:     doc.Verified,
+		TOTP:         doc.TOTP,
+		DisplayName:  doc.DisplayName,
+		AvatarURL:    doc.AvatarURL,
+		Preferences:  doc.Preferences,
 		CreatedAt:    doc.CreatedAt,
 		UpdatedAt:    doc.UpdatedAt,
 	}
@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SignatureHeader carries the signature of a delivery, "sha256=" followed by the hex-encoded
+// HMAC-SHA256 of the TimestampHeader value, a dot and the request body, keyed with the webhook's secret
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery" // The same for every attempt of a delivery, so receivers can skip duplicates
+)
+
+// EventPing is sent by the test endpoint to check a webhook is reachable
+const EventPing = "ping"
+
+// Webhook represents a subscription that posts a document's or a workspace's events to a URL
+// Exactly one of DocumentID and WorkspaceID is set; the secret is only shown once on creation
+type Webhook struct {
+	ID          string    `json:"id"`
+	CreatedBy   string    `json:"created_by"`
+	DocumentID  string    `json:"document_id,omitempty"`
+	WorkspaceID string    `json:"workspace_id,omitempty"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// NewWebhook creates a new active webhook instance
+func NewWebhook(createdBy, documentID, workspaceID, url string, events []string, secret string) *Webhook {
+	now := time.Now()
+	return &Webhook{
+		ID:          uuid.New().String(),
+		CreatedBy:   createdBy,
+		DocumentID:  documentID,
+		WorkspaceID: workspaceID,
+		URL:         url,
+		Events:      events,
+		Active:      true,
+		Secret:      secret,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// Subscribes reports whether the webhook is active and wants events of the given type
+func (w *Webhook) Subscribes(eventType string) bool {
+	return w.Active && slices.Contains(w.Events, eventType)
+}
+
+// Sign computes the value of the SignatureHeader for a request body sent at the given time
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookDocument represents the webhook as stored in Couchbase
+type WebhookDocument struct {
+	ID          string    `json:"id"`
+	CreatedBy   string    `json:"created_by"`
+	DocumentID  string    `json:"document_id,omitempty"`
+	WorkspaceID string    `json:"workspace_id,omitempty"`
+	URL         string    `json:"url"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	Secret      string    `json:"secret"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ToDocument converts Webhook to WebhookDocument for database storage
+func (w *Webhook) ToDocument() *WebhookDocument {
+	return &WebhookDocument{
+		ID:          w.ID,
+		CreatedBy:   w.CreatedBy,
+		DocumentID:  w.DocumentID,
+		WorkspaceID: w.WorkspaceID,
+		URL:         w.URL,
+		Events:      w.Events,
+		Active:      w.Active,
+		Secret:      w.Secret,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+}
+
+// FromDocument creates a Webhook from WebhookDocument
+func FromDocument(doc *WebhookDocument) *Webhook {
+	if doc == nil {
+		return nil
+	}
+	return &Webhook{
+		ID:          doc.ID,
+		CreatedBy:   doc.CreatedBy,
+		DocumentID:  doc.DocumentID,
+		WorkspaceID: doc.WorkspaceID,
+		URL:         doc.URL,
+		Events:      doc.Events,
+		Active:      doc.Active,
+		Secret:      doc.Secret,
+		CreatedAt:   doc.CreatedAt,
+		UpdatedAt:   doc.UpdatedAt,
+	}
+}
+
+// Delivery records one attempt to deliver an event to a webhook
+type Delivery struct {
+	ID           string     `json:"id"`
+	WebhookID    string     `json:"webhook_id"`
+	DeliveryID   string     `json:"delivery_id"` // Shared by the attempts of one delivery; sent in DeliveryHeader
+	EventID      string     `json:"event_id"`
+	Event        string     `json:"event"`
+	Attempt      int        `json:"attempt"`
+	URL          string     `json:"url"`
+	RequestBody  string     `json:"request_body"`
+	StatusCode   int        `json:"status_code,omitempty"`
+	ResponseBody string     `json:"response_body,omitempty"` // Truncated
+	Error        string     `json:"error,omitempty"`
+	Success      bool       `json:"success"`
+	DurationMs   int64      `json:"duration_ms"`
+	NextRetryAt  *time.Time `json:"next_retry_at,omitempty"` // Set when the attempt failed and will be retried
+	CreatedAt    time.Time  `json:"created_at"`
+}
@@ -0,0 +1,127 @@
+package workspace
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Member roles, from most to least privileged
+const (
+	RoleOwner  = "owner"  // Manage the workspace, its members and all documents; delete the workspace
+	RoleAdmin  = "admin"  // Manage members and all documents
+	RoleEditor = "editor" // Create and edit documents
+	RoleViewer = "viewer" // Read documents
+)
+
+// roleRanks orders roles so permissions can be compared
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// IsValidRole reports whether role is a known role
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role grants at least the permissions of minimum
+func RoleAtLeast(role, minimum string) bool {
+	return roleRanks[role] > 0 && roleRanks[role] >= roleRanks[minimum]
+}
+
+// Member represents a user's membership in a workspace
+type Member struct {
+	UserID   string    `json:"user_id"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Workspace represents a team that owns documents
+type Workspace struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Members   []Member  `json:"members"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewWorkspace creates a new workspace with the creator as its owner
+func NewWorkspace(name, creatorID string) *Workspace {
+	now := time.Now()
+	return &Workspace{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Members:   []Member{{UserID: creatorID, Role: RoleOwner, JoinedAt: now}},
+		CreatedBy: creatorID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// RoleOf returns the role of a user in the workspace, or "" if they are not a member
+func (w *Workspace) RoleOf(userID string) string {
+	for _, m := range w.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// HasRole reports whether a user is a member with at least the given role
+func (w *Workspace) HasRole(userID, minimum string) bool {
+	return RoleAtLeast(w.RoleOf(userID), minimum)
+}
+
+// CountRole returns the number of members with exactly the given role
+func (w *Workspace) CountRole(role string) int {
+	count := 0
+	for _, m := range w.Members {
+		if m.Role == role {
+			count++
+		}
+	}
+	return count
+}
+
+// WorkspaceDocument represents the workspace as stored in Couchbase
+type WorkspaceDocument struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Members   []Member  `json:"members"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ToDocument converts Workspace to WorkspaceDocument for database storage
+func (w *Workspace) ToDocument() *WorkspaceDocument {
+	return &WorkspaceDocument{
+		ID:        w.ID,
+		Name:      w.Name,
+		Members:   w.Members,
+		CreatedBy: w.CreatedBy,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// FromDocument creates a Workspace from WorkspaceDocument
+func FromDocument(doc *WorkspaceDocument) *Workspace {
+	if doc == nil {
+		return nil
+	}
+	return &Workspace{
+		ID:        doc.ID,
+		Name:      doc.Name,
+		Members:   doc.Members,
+		CreatedBy: doc.CreatedBy,
+		CreatedAt: doc.CreatedAt,
+		UpdatedAt: doc.UpdatedAt,
+	}
+}